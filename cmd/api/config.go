@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"go-gin-sqlc/internal/config"
+)
+
+// configUsage は config サブコマンドの使い方です
+const configUsage = `使い方: api config <コマンド>
+
+コマンド:
+  print   設定ファイルと環境変数から読み込んだ設定を KEY=value の形式で表示し、検証します
+          パスワードなどの秘密情報は伏せます`
+
+// runConfig は設定を確認する config サブコマンドを実行します
+func runConfig(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintln(out, configUsage)
+		return errUsage
+	}
+
+	if err := cfg.WriteRedacted(out); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("設定が不正です:\n%w", err)
+	}
+	return nil
+}
@@ -1,76 +1,85 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 
 	"go-gin-sqlc/internal/config"
-	"go-gin-sqlc/internal/handler"
-	"go-gin-sqlc/internal/infrastructure/database"
-	"go-gin-sqlc/internal/middleware"
-
-	"github.com/gin-gonic/gin"
 )
 
-func main() {
-	// 設定の読み込み
-	cfg := config.New()
+// usage はコマンドの使い方です
+const usage = `使い方: api [コマンド]
 
-	// データベース接続
-	db, err := database.Connect(cfg.DB)
-	if err != nil {
-		log.Fatal("データベース接続の確立に失敗しました:", err)
-	}
-	defer db.Close()
+コマンド:
+  serve           APIサーバーを起動します（コマンドを省略した場合）
+  migrate         マイグレーションを操作します
+  user            ユーザーを管理します
+  tokens          トークンを管理します
+  config print    読み込んだ設定を表示して検証します（パスワードなどの秘密情報は伏せます）
 
-	// Ginルーターの初期化
-	r := gin.Default()
+各コマンドの使い方は api <コマンド> で表示します`
 
-	// ミドルウェアの適用
-	r.Use(middleware.Logger())
+// errUsage はコマンドの引数が不正なことを表します
+var errUsage = errors.New("引数が不正です")
 
-	// パブリックルート
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "Welcome to Go-Gin-SQLC API",
-		})
-	})
+func main() {
+	// 設定の読み込み。設定ファイルを使用する場合は CONFIG_FILE にパスを指定する
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("設定の読み込みに失敗しました:\n", err)
+	}
 
-	r.GET("/health", func(c *gin.Context) {
-		err := db.Ping()
-		if err != nil {
-			c.JSON(500, gin.H{
-				"status":  "error",
-				"message": "データベース接続エラー",
-			})
-			return
+	if err := run(context.Background(), cfg, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		// 使い方は表示済みのため、終了コードだけで引数の誤りを伝える
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
 		}
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "サービスは正常に動作しています",
-		})
-	})
+		log.Fatal(err)
+	}
+}
 
-	// 認証ハンドラーの初期化と登録
-	authHandler := handler.NewAuthHandler(db)
-	authHandler.RegisterRoutes(r)
+// run は args の先頭のコマンドを実行します。コマンドを省略した場合はAPIサーバーを起動します
+// 管理用のコマンドはサーバーと同じ設定とデータベース接続を使用します
+func run(ctx context.Context, cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
 
-	// パスワードリセットハンドラーの初期化と登録
-	passwordHandler := handler.NewPasswordHandler(db, cfg)
-	passwordHandler.RegisterRoutes(r)
+	switch command {
+	case "help", "-h", "--help":
+		fmt.Fprintln(out, usage)
+		return nil
+	case "config":
+		// 不正な設定も確認できるよう、表示してから検証する
+		return runConfig(cfg, args, out)
+	case "serve", "migrate", "user", "tokens":
+	default:
+		fmt.Fprintln(out, usage)
+		return errUsage
+	}
 
-	// 認証が必要なルート
-	authorized := r.Group("/api")
-	authorized.Use(middleware.AuthRequired())
-	{
-		// ユーザーハンドラーの初期化と登録
-		userHandler := handler.NewUserHandler(db)
-		userHandler.RegisterRoutes(authorized)
+	// 処理の途中で設定の誤りに気付くことがないよう、実行する前に全ての設定を検証する
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("設定が不正です:\n%w", err)
 	}
 
-	// サーバーの起動
-	addr := fmt.Sprintf(":%s", cfg.Server.Port)
-	if err := r.Run(addr); err != nil {
-		log.Fatal("サーバーの起動に失敗しました:", err)
+	switch command {
+	case "migrate":
+		err := runMigrate(ctx, cfg, args, out)
+		if err != nil && !errors.Is(err, errUsage) {
+			return fmt.Errorf("マイグレーションに失敗しました: %w", err)
+		}
+		return err
+	case "user":
+		return runUser(ctx, cfg, args, in, out)
+	case "tokens":
+		return runTokens(ctx, cfg, args, out)
+	default:
+		return serve(ctx, cfg)
 	}
 }
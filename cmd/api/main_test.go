@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sqlcdb "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/handler"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig は検証を通過する開発環境の設定を返します
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.DB.User = "app"
+	return cfg
+}
+
+func TestRunUsage(t *testing.T) {
+	// データベースに接続する前に引数の誤りを検出する
+	tests := []struct {
+		name          string
+		args          []string
+		stdin         string
+		expectedUsage string
+	}{
+		{
+			name:          "存在しないコマンド",
+			args:          []string{"unknown"},
+			expectedUsage: usage,
+		},
+		{
+			name:          "user のコマンドを省略",
+			args:          []string{"user"},
+			expectedUsage: userUsage,
+		},
+		{
+			name:          "無効なメールアドレス",
+			args:          []string{"user", "reset-password", "-email", "invalid"},
+			expectedUsage: userUsage,
+		},
+		{
+			name:          "管理者の氏名を省略",
+			args:          []string{"user", "create-admin", "-email", "admin@example.com"},
+			stdin:         "password123\n",
+			expectedUsage: userUsage,
+		},
+		{
+			name:          "無効なステータス",
+			args:          []string{"user", "set-status", "-email", "user@example.com", "-status", "deleted"},
+			expectedUsage: userUsage,
+		},
+		{
+			name:          "tokens のコマンドが不正",
+			args:          []string{"tokens", "purge"},
+			expectedUsage: tokensUsage,
+		},
+		{
+			name:          "config のコマンドを省略",
+			args:          []string{"config"},
+			expectedUsage: configUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := run(context.Background(), testConfig(), tt.args, strings.NewReader(tt.stdin), &out)
+			assert.ErrorIs(t, err, errUsage)
+			assert.Contains(t, out.String(), tt.expectedUsage)
+		})
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name        string
+		stdin       string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "1行目だけを読み込む",
+			stdin:    "password123\nsecond line\n",
+			expected: "password123",
+		},
+		{
+			name:     "改行がCRLF",
+			stdin:    "password123\r\n",
+			expected: "password123",
+		},
+		{
+			name:     "末尾に改行がない",
+			stdin:    "パスワードは八文字",
+			expected: "パスワードは八文字",
+		},
+		{
+			name:        "短すぎる",
+			stdin:       "short\n",
+			expectedErr: true,
+		},
+		{
+			name:        "入力がない",
+			stdin:       "",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := readPassword(strings.NewReader(tt.stdin))
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, password)
+		})
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	// データベースに接続する前に設定の誤りを検出する
+	cfg := testConfig()
+	cfg.DB.User = ""
+
+	var out bytes.Buffer
+	err := run(context.Background(), cfg, []string{"tokens", "purge-expired"}, nil, &out)
+	assert.ErrorContains(t, err, "DB_USER を指定してください")
+	assert.NotErrorIs(t, err, errUsage)
+}
+
+func TestRunConfigPrint(t *testing.T) {
+	cfg := testConfig()
+	cfg.DB.Password = "db-password"
+	cfg.JWT.Secret = "jwt-secret"
+
+	var out bytes.Buffer
+	err := run(context.Background(), cfg, []string{"config", "print"}, nil, &out)
+	require.NoError(t, err)
+
+	assert.Contains(t, out.String(), "DB_USER=app\n")
+	assert.Contains(t, out.String(), "DB_PASSWORD=[REDACTED]\n")
+	assert.Contains(t, out.String(), "JWT_SECRET=[REDACTED]\n")
+	// 設定されていない秘密情報は空のまま表示する
+	assert.Contains(t, out.String(), "MAIL_PASSWORD=\n")
+	assert.NotContains(t, out.String(), "db-password")
+	assert.NotContains(t, out.String(), "jwt-secret")
+
+	t.Run("不正な設定も表示してから検証する", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Env = config.EnvProduction
+
+		var out bytes.Buffer
+		err := run(context.Background(), cfg, []string{"config", "print"}, nil, &out)
+		assert.ErrorContains(t, err, "本番環境では DB_PASSWORD を指定してください")
+		assert.Contains(t, out.String(), "APP_ENV=production\n")
+	})
+}
+
+// purgeRecorder は期限切れのトークンの削除で呼ばれたクエリを記録します
+type purgeRecorder struct {
+	sqlcdb.Querier
+	calls []string
+}
+
+func (r *purgeRecorder) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	r.calls = append(r.calls, "DeleteExpiredRefreshTokens")
+	return 3, nil
+}
+
+func (r *purgeRecorder) DeleteExpiredPasswordResets(ctx context.Context) (int64, error) {
+	r.calls = append(r.calls, "DeleteExpiredPasswordResets")
+	return 1, nil
+}
+
+func (r *purgeRecorder) DeleteExpiredRevokedTokens(ctx context.Context) error {
+	r.calls = append(r.calls, "DeleteExpiredRevokedTokens")
+	return nil
+}
+
+func (r *purgeRecorder) DeleteExpiredUserTokenRevocations(ctx context.Context) error {
+	r.calls = append(r.calls, "DeleteExpiredUserTokenRevocations")
+	return nil
+}
+
+func TestPurgeExpiredTokens(t *testing.T) {
+	queries := &purgeRecorder{}
+	var out bytes.Buffer
+	err := purgeExpiredTokens(context.Background(), queries, &out)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"DeleteExpiredRefreshTokens",
+		"DeleteExpiredPasswordResets",
+		"DeleteExpiredRevokedTokens",
+		"DeleteExpiredUserTokenRevocations",
+	}, queries.calls)
+	assert.Contains(t, out.String(), "リフレッシュトークンを3件削除しました")
+	assert.Contains(t, out.String(), "パスワードリセット用のリンクを1件削除しました")
+}
+
+// ipRecorder はログイン試行の制限に渡された送信元IPを記録し、常に試行を制限します
+type ipRecorder struct {
+	util.LoginGuard
+	ips []string
+}
+
+func (g *ipRecorder) Check(ctx context.Context, email, ip string) (time.Duration, bool, error) {
+	g.ips = append(g.ips, ip)
+	return time.Minute, false, nil
+}
+
+func TestRouterClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		forwarded      []string
+		expectedIP     string
+	}{
+		{
+			// 送信元IPを毎回詐称しても、ログイン試行の制限の対象は変わらない
+			name:       "既定ではX-Forwarded-Forを信頼しない",
+			forwarded:  []string{"203.0.113.9", "198.51.100.7"},
+			expectedIP: "192.0.2.1",
+		},
+		{
+			name:           "信頼するプロキシからの接続ではX-Forwarded-Forを使用する",
+			trustedProxies: []string{"192.0.2.0/24"},
+			forwarded:      []string{"203.0.113.9"},
+			expectedIP:     "203.0.113.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Server.TrustedProxies = tt.trustedProxies
+			r, err := newRouter(cfg.Server)
+			require.NoError(t, err)
+
+			guard := &ipRecorder{}
+			authService := service.NewAuthService(nil, nil, guard, service.SystemClock{}, cfg)
+			handler.NewAuthHandler(authService, nil).RegisterRoutes(r)
+
+			for _, forwarded := range tt.forwarded {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"user@example.com","password":"password123"}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Forwarded-For", forwarded)
+				req.RemoteAddr = "192.0.2.1:12345"
+				r.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusTooManyRequests, w.Code)
+			}
+
+			require.Len(t, guard.ips, len(tt.forwarded))
+			for _, ip := range guard.ips {
+				assert.Equal(t, tt.expectedIP, ip)
+			}
+		})
+	}
+}
+
+func TestNewRouterInvalidProxy(t *testing.T) {
+	_, err := newRouter(config.ServerConfig{TrustedProxies: []string{"proxy.local"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"go-gin-sqlc/db/migration"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/infrastructure/database"
+)
+
+// migrateUsage は migrate サブコマンドの使い方です
+const migrateUsage = `使い方: api migrate <コマンド>
+
+コマンド:
+  up           未適用のマイグレーションを全て適用します
+  down [N]     適用済みのマイグレーションを新しい順に N 件（省略時は1件）取り消します
+  status       マイグレーションの適用状況を表示します
+  force <V>    マイグレーションを実行せずにバージョン V を記録し、途中で失敗した状態を解除します
+               0 を指定すると、1件も適用されていない状態にします`
+
+// runMigrate はバイナリに埋め込んだマイグレーションを操作する migrate サブコマンドを実行します
+func runMigrate(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintln(out, migrateUsage)
+		return errUsage
+	}
+
+	db, err := database.Connect(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, migration.Files)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "適用しました: %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "未適用のマイグレーションはありません")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				fmt.Fprintln(out, migrateUsage)
+				return errUsage
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "取り消しました: %d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			mark := " "
+			if s.Applied {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "[%s] %06d_%s\n", mark, s.Version, s.Name)
+		}
+		fmt.Fprintf(out, "現在のバージョン: %d", version)
+		if dirty {
+			fmt.Fprint(out, "（途中で失敗しています。修正後に force を実行してください）")
+		}
+		fmt.Fprintln(out)
+		return nil
+
+	case "force":
+		if len(args) < 2 {
+			fmt.Fprintln(out, migrateUsage)
+			return errUsage
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			fmt.Fprintln(out, migrateUsage)
+			return errUsage
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "バージョンを %d に設定しました\n", version)
+		return nil
+
+	default:
+		fmt.Fprintln(out, migrateUsage)
+		return errUsage
+	}
+}
+
+// autoMigrate はサーバーの起動時に未適用のマイグレーションを適用します
+func autoMigrate(ctx context.Context, migrator *database.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		log.Printf("マイグレーションを適用しました: %d_%s", m.Version, m.Name)
+	}
+	return err
+}
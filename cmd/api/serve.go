@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"go-gin-sqlc/db/migration"
+	sqlcdb "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/handler"
+	"go-gin-sqlc/internal/infrastructure/database"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serve はAPIサーバーを起動し、停止するまで戻りません
+func serve(ctx context.Context, cfg *config.Config) error {
+	// JWT署名鍵の読み込み
+	jwtConfig := cfg.JWT
+	if jwtConfig.Algorithm == "HS256" && jwtConfig.Secret == "" {
+		// 開発環境でシークレットを省略した場合（本番環境では設定の検証で拒否される）
+		secret, err := util.GenerateRandomToken(32)
+		if err != nil {
+			return fmt.Errorf("JWT署名鍵の生成に失敗しました: %w", err)
+		}
+		jwtConfig.Secret = secret
+		log.Println("JWT_SECRET が設定されていないため、ランダムな署名鍵を使用します。再起動すると発行済みのトークンは使用できなくなります")
+	}
+	keys, err := util.NewKeySet(jwtConfig)
+	if err != nil {
+		return fmt.Errorf("JWT署名鍵の読み込みに失敗しました: %w", err)
+	}
+	util.SetKeySet(keys)
+
+	// メールテンプレートの読み込み
+	mailTemplates, err := util.LoadMailTemplates(cfg.Mail.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("メールテンプレートの読み込みに失敗しました: %w", err)
+	}
+	util.SetMailTemplates(mailTemplates)
+
+	// データベース接続
+	db, err := database.Connect(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("データベース接続の確立に失敗しました: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 起動時のマイグレーションの適用
+	if cfg.DB.AutoMigrate {
+		migrator, err := database.NewMigrator(db, migration.Files)
+		if err != nil {
+			return fmt.Errorf("マイグレーションファイルの読み込みに失敗しました: %w", err)
+		}
+		if err := autoMigrate(ctx, migrator); err != nil {
+			return fmt.Errorf("マイグレーションに失敗しました: %w", err)
+		}
+	}
+
+	// トークン失効リストの読み込みと定期的な更新
+	revocations := util.NewDBRevocationStore(sqlcdb.New(db))
+	if err := revocations.Load(ctx); err != nil {
+		return fmt.Errorf("トークン失効リストの読み込みに失敗しました: %w", err)
+	}
+	go revocations.Run(ctx, time.Minute)
+
+	// ロールと権限の対応の読み込みと定期的な更新
+	permissions := util.NewDBPermissionResolver(sqlcdb.New(db))
+	if err := permissions.Load(ctx); err != nil {
+		return fmt.Errorf("ロールと権限の読み込みに失敗しました: %w", err)
+	}
+	go permissions.Run(ctx, time.Minute)
+
+	// 保持期間を過ぎた削除済みユーザーの定期的な完全削除
+	go purgeDeletedUsers(ctx, sqlcdb.New(db), cfg.UserRetention, time.Hour)
+
+	// ログイン失敗の記録と試行の制限
+	loginGuard := util.NewDBLoginGuard(sqlcdb.New(db), cfg.LoginGuard)
+
+	// 設定された送信方法での送信待ちのメールのバックグラウンドでの配信
+	mailer, err := util.NewMailer(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("メールの送信方法の設定が不正です: %w", err)
+	}
+	store := sqlcdb.NewStore(db)
+	outbox := util.NewOutboxWorker(store, mailer, cfg.Outbox)
+	go deliverEmails(ctx, outbox, cfg.Outbox.PollInterval)
+	go purgeEmails(ctx, outbox, time.Hour)
+
+	// 業務処理のサービス。ハンドラーはリクエストとレスポンスの変換だけを行う
+	clock := service.SystemClock{}
+	authService := service.NewAuthService(store, revocations, loginGuard, clock, cfg)
+	passwordService := service.NewPasswordService(store, revocations, clock, cfg)
+	userService := service.NewUserService(store, revocations, loginGuard, clock, cfg)
+	mfaService := service.NewMFAService(store, clock)
+	emailService := service.NewEmailService(store, clock)
+
+	// Ginルーターの初期化
+	r, err := newRouter(cfg.Server)
+	if err != nil {
+		return err
+	}
+
+	// パブリックルート
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "Welcome to Go-Gin-SQLC API",
+		})
+	})
+
+	r.GET("/health", func(c *gin.Context) {
+		err := db.Ping()
+		if err != nil {
+			c.JSON(500, gin.H{
+				"status":  "error",
+				"message": "データベース接続エラー",
+			})
+			return
+		}
+		c.JSON(200, gin.H{
+			"status":  "ok",
+			"message": "サービスは正常に動作しています",
+		})
+	})
+
+	// 開発用の受信箱を使用する場合は、受け取ったメールを /dev/mail で確認できるようにする
+	if inbox, ok := mailer.(*util.DevInbox); ok {
+		devMailHandler := handler.NewDevMailHandler(inbox)
+		devMailHandler.RegisterRoutes(r)
+	}
+
+	// 公開鍵（JWKS）の配布
+	jwksHandler := handler.NewJWKSHandler(keys)
+	jwksHandler.RegisterRoutes(r)
+
+	// 認証ハンドラーの初期化と登録
+	authHandler := handler.NewAuthHandler(authService, revocations)
+	authHandler.RegisterRoutes(r)
+
+	// パスワードリセットハンドラーの初期化と登録
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	passwordHandler.RegisterRoutes(r)
+
+	// 認証が必要なルート
+	authorized := r.Group("/api")
+	authorized.Use(middleware.AuthRequired(revocations), middleware.LoadPermissions(permissions))
+	{
+		// ユーザーハンドラーの初期化と登録
+		userHandler := handler.NewUserHandler(userService, cfg)
+		userHandler.RegisterRoutes(authorized)
+
+		// 自分自身のプロフィールを扱うハンドラーの初期化と登録
+		meHandler := handler.NewMeHandler(userService, passwordService)
+		meHandler.RegisterRoutes(authorized)
+
+		// 二要素認証ハンドラーの初期化と登録
+		mfaHandler := handler.NewMFAHandler(mfaService)
+		mfaHandler.RegisterRoutes(authorized)
+
+		// 送信待ち・配信を停止したメールを管理するハンドラーの初期化と登録
+		emailHandler := handler.NewEmailHandler(emailService)
+		emailHandler.RegisterRoutes(authorized)
+	}
+
+	// サーバーの起動
+	addr := fmt.Sprintf(":%d", cfg.Server.Port)
+	if err := r.Run(addr); err != nil {
+		return fmt.Errorf("サーバーの起動に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// newRouter は共通のミドルウェアを適用したGinルーターを作成します
+// X-Forwarded-For は設定したプロキシからの接続の場合だけ使用し、ログイン試行の制限を送信元IPの詐称で回避させない
+func newRouter(cfg config.ServerConfig) (*gin.Engine, error) {
+	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("信頼するプロキシの設定が不正です: %w", err)
+	}
+
+	// ミドルウェアの適用
+	// ErrorHandler はハンドラーが c.Error に渡したエラーを problem+json で返し、
+	// Locale は Accept-Language からメッセージの言語を決めます
+	r.Use(middleware.Logger(), middleware.ErrorHandler(), middleware.Locale())
+	return r, nil
+}
+
+// purgeDeletedUsers はコンテキストがキャンセルされるまで、保持期間を過ぎた削除済みユーザーを定期的に完全に削除します
+func purgeDeletedUsers(ctx context.Context, queries sqlcdb.Querier, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := queries.PurgeDeletedUsers(ctx, sql.NullTime{Time: time.Now().Add(-retention), Valid: true})
+			if err != nil {
+				log.Println("削除済みユーザーの完全削除に失敗しました:", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("保持期間を過ぎた削除済みユーザーを%d件完全に削除しました", purged)
+			}
+		}
+	}
+}
+
+// purgeEmails はコンテキストがキャンセルされるまで、保持期間を過ぎた配信済み・配信を停止したメールを定期的に削除します
+func purgeEmails(ctx context.Context, outbox *util.OutboxWorker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := outbox.Purge(ctx)
+			if err != nil {
+				log.Println("配信済みのメールの削除に失敗しました:", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("保持期間を過ぎた配信済み・配信を停止したメールを%d件削除しました", purged)
+			}
+		}
+	}
+}
+
+// deliverEmails はコンテキストがキャンセルされるまで、送信待ちのメールを定期的に配信します
+// 送信待ちのメールが残っている間は、次の間隔を待たずに続けて配信します
+func deliverEmails(ctx context.Context, outbox *util.OutboxWorker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				delivered, err := outbox.Deliver(ctx)
+				if err != nil {
+					log.Println("送信待ちのメールの取り出しに失敗しました:", err)
+					break
+				}
+				if delivered == 0 {
+					break
+				}
+			}
+		}
+	}
+}
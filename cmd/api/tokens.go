@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	sqlcdb "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/infrastructure/database"
+	"go-gin-sqlc/internal/util"
+)
+
+// tokensUsage は tokens サブコマンドの使い方です
+const tokensUsage = `使い方: api tokens <コマンド>
+
+コマンド:
+  purge-expired   有効期限を過ぎたリフレッシュトークン、パスワードリセット用のリンクと
+                  アクセストークンの失効リストを削除します`
+
+// runTokens はトークンを管理する tokens サブコマンドを実行します
+func runTokens(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "purge-expired" {
+		fmt.Fprintln(out, tokensUsage)
+		return errUsage
+	}
+
+	db, err := database.Connect(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return purgeExpiredTokens(ctx, sqlcdb.New(db), out)
+}
+
+// purgeExpiredTokens は有効期限を過ぎたトークンを削除し、削除した件数を表示します
+// 期限切れのトークンは使用できないため、削除しても利用中のセッションには影響しません
+func purgeExpiredTokens(ctx context.Context, queries sqlcdb.Querier, out io.Writer) error {
+	refreshTokens, err := queries.DeleteExpiredRefreshTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("リフレッシュトークンの削除に失敗しました: %w", err)
+	}
+	fmt.Fprintf(out, "期限切れのリフレッシュトークンを%d件削除しました\n", refreshTokens)
+
+	passwordResets, err := queries.DeleteExpiredPasswordResets(ctx)
+	if err != nil {
+		return fmt.Errorf("パスワードリセット用のリンクの削除に失敗しました: %w", err)
+	}
+	fmt.Fprintf(out, "期限切れのパスワードリセット用のリンクを%d件削除しました\n", passwordResets)
+
+	// 失効リストはサーバーも定期的に削除しているため、停止中に溜まった分を削除する
+	if err := util.NewDBRevocationStore(queries).Purge(ctx); err != nil {
+		return fmt.Errorf("失効リストの削除に失敗しました: %w", err)
+	}
+	fmt.Fprintln(out, "アクセストークンの失効リストから期限切れのエントリを削除しました")
+	return nil
+}
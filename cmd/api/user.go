@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	sqlcdb "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/infrastructure/database"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+)
+
+// userUsage は user サブコマンドの使い方です
+const userUsage = `使い方: api user <コマンド> [オプション]
+
+コマンド:
+  create-admin -email <メールアドレス> -first-name <名> -last-name <姓>
+                  管理者のロールを持つ有効なユーザーを作成します
+  set-status -email <メールアドレス> -status <ステータス>
+                  ステータス（active, inactive, suspended, pending_verification）を変更します
+                  active 以外にした場合は、発行済みのトークンを全て失効させます
+  reset-password -email <メールアドレス>
+                  パスワードを設定し、発行済みのトークンを全て失効させます
+
+パスワードはシェルの履歴やプロセス一覧に残らないよう、標準入力の1行目から読み込みます
+  例: echo "$ADMIN_PASSWORD" | api user create-admin -email admin@example.com -first-name 管理 -last-name 太郎`
+
+// minPasswordLength はAPIのリクエストと同じパスワードの最小文字数です
+const minPasswordLength = 8
+
+// userStatuses は set-status で指定できるステータスです
+var userStatuses = []sqlcdb.UsersStatus{
+	sqlcdb.UsersStatusActive,
+	sqlcdb.UsersStatusInactive,
+	sqlcdb.UsersStatusSuspended,
+	sqlcdb.UsersStatusPendingVerification,
+}
+
+// runUser はAPIを経由せずにユーザーを管理する user サブコマンドを実行します
+func runUser(ctx context.Context, cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintln(out, userUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create-admin":
+		return createAdmin(ctx, cfg, args[1:], in, out)
+	case "set-status":
+		return setUserStatus(ctx, cfg, args[1:], out)
+	case "reset-password":
+		return resetUserPassword(ctx, cfg, args[1:], in, out)
+	default:
+		fmt.Fprintln(out, userUsage)
+		return errUsage
+	}
+}
+
+// createAdmin は管理者と一般ユーザーのロールを持つ有効なユーザーを作成します
+func createAdmin(ctx context.Context, cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	flags, email := newUserFlagSet("create-admin", out)
+	firstName := flags.String("first-name", "", "名")
+	lastName := flags.String("last-name", "", "姓")
+	if err := parseUserFlags(flags, email, args, out); err != nil {
+		return err
+	}
+	if *firstName == "" || *lastName == "" {
+		return usageError(out, "-first-name と -last-name を指定してください")
+	}
+	password, err := readPassword(in)
+	if err != nil {
+		return err
+	}
+
+	return withUserServices(cfg, func(users *service.UserService, _ *service.PasswordService) error {
+		user, err := users.Create(ctx, service.CreateUserInput{
+			Email:     *email,
+			Password:  password,
+			FirstName: *firstName,
+			LastName:  *lastName,
+			Roles:     []string{util.RoleUser, util.RoleAdmin},
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "管理者を作成しました: id=%d email=%s\n", user.ID, user.Email)
+		return nil
+	})
+}
+
+// setUserStatus はユーザーのステータスを変更します
+func setUserStatus(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	flags, email := newUserFlagSet("set-status", out)
+	status := flags.String("status", "", "変更後のステータス")
+	if err := parseUserFlags(flags, email, args, out); err != nil {
+		return err
+	}
+	if !isUserStatus(*status) {
+		return usageError(out, fmt.Sprintf("無効なステータスです: %q", *status))
+	}
+
+	return withUserServices(cfg, func(users *service.UserService, _ *service.PasswordService) error {
+		user, err := users.GetByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		if err := users.SetStatus(ctx, user.ID, sqlcdb.UsersStatus(*status)); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "ステータスを変更しました: id=%d email=%s status=%s\n", user.ID, user.Email, *status)
+		return nil
+	})
+}
+
+// resetUserPassword は現在のパスワードを確認せずにユーザーのパスワードを設定します
+func resetUserPassword(ctx context.Context, cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	flags, email := newUserFlagSet("reset-password", out)
+	if err := parseUserFlags(flags, email, args, out); err != nil {
+		return err
+	}
+	password, err := readPassword(in)
+	if err != nil {
+		return err
+	}
+
+	return withUserServices(cfg, func(users *service.UserService, passwords *service.PasswordService) error {
+		user, err := users.GetByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		if err := passwords.Set(ctx, user.ID, password); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "パスワードを設定しました: id=%d email=%s\n", user.ID, user.Email)
+		return nil
+	})
+}
+
+// newUserFlagSet は対象のユーザーを指定する -email を持つフラグの集合を作成します
+func newUserFlagSet(name string, out io.Writer) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("user "+name, flag.ContinueOnError)
+	flags.SetOutput(out)
+	email := flags.String("email", "", "対象のユーザーのメールアドレス")
+	return flags, email
+}
+
+// parseUserFlags はフラグを解析し、-email に有効なメールアドレスが指定されているかを確認します
+func parseUserFlags(flags *flag.FlagSet, email *string, args []string, out io.Writer) error {
+	if err := flags.Parse(args); err != nil {
+		// -h の場合も flag パッケージが使い方を表示している
+		return errUsage
+	}
+	if address, err := mail.ParseAddress(*email); err != nil || address.Address != *email {
+		return usageError(out, "-email に有効なメールアドレスを指定してください")
+	}
+	return nil
+}
+
+// withUserServices はデータベースに接続し、APIと同じユーザーとパスワードのサービスで fn を実行します
+// ロールの付与やトークンの失効がAPIからの操作と同じになるよう、クエリを直接実行せずにサービスを使用します
+// 失効はデータベースに記録され、稼働中のサーバーには失効リストの定期的な再読み込みで反映されます
+func withUserServices(cfg *config.Config, fn func(*service.UserService, *service.PasswordService) error) error {
+	db, err := database.Connect(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	store := sqlcdb.NewStore(db)
+	revocations := util.NewDBRevocationStore(sqlcdb.New(db))
+	clock := service.SystemClock{}
+	users := service.NewUserService(store, revocations, nil, clock, cfg)
+	passwords := service.NewPasswordService(store, revocations, clock, cfg)
+	return fn(users, passwords)
+}
+
+// isUserStatus は set-status で指定できるステータスかを判定します
+func isUserStatus(status string) bool {
+	for _, s := range userStatuses {
+		if string(s) == status {
+			return true
+		}
+	}
+	return false
+}
+
+// readPassword は標準入力の1行目をパスワードとして読み込みます
+func readPassword(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("パスワードの読み込みに失敗しました: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return "", fmt.Errorf("パスワードは標準入力から%d文字以上で指定してください", minPasswordLength)
+	}
+	return password, nil
+}
+
+// usageError はメッセージと使い方を表示し、引数が不正なことを表すエラーを返します
+func usageError(out io.Writer, message string) error {
+	fmt.Fprintln(out, message)
+	fmt.Fprintln(out, userUsage)
+	return errUsage
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS email_verifications;
+
+UPDATE users SET status = 'inactive' WHERE status = 'pending_verification';
+
+ALTER TABLE users
+    MODIFY status ENUM('active', 'inactive', 'suspended') DEFAULT 'active';
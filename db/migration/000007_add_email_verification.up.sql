@@ -0,0 +1,8 @@
+ALTER TABLE users
+    MODIFY status ENUM('active', 'inactive', 'suspended', 'pending_verification') DEFAULT 'active';
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    user_id BIGINT PRIMARY KEY,
+    last_sent_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS login_failures (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    scope ENUM('account', 'ip') NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failed_count INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP NULL,
+    UNIQUE INDEX idx_login_failures_scope_subject (scope, subject)
+);
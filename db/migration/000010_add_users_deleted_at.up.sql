@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN deleted_at TIMESTAMP NULL,
+    ADD INDEX idx_users_deleted_at (deleted_at);
@@ -0,0 +1,3 @@
+-- 日本語の氏名も検索できるよう、ngramパーサーで全文検索インデックスを作成する
+ALTER TABLE users
+    ADD FULLTEXT INDEX idx_users_fulltext (email, first_name, last_name) WITH PARSER ngram;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP INDEX idx_users_created_at,
+    DROP INDEX idx_users_updated_at,
+    DROP INDEX idx_users_last_name;
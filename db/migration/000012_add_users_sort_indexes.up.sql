@@ -0,0 +1,5 @@
+-- ユーザー一覧の並び替えとキーセットページネーションで使用するインデックス
+ALTER TABLE users
+    ADD INDEX idx_users_created_at (created_at, id),
+    ADD INDEX idx_users_updated_at (updated_at, id),
+    ADD INDEX idx_users_last_name (last_name, id);
@@ -0,0 +1,3 @@
+-- 楽観的排他制御（ETag / If-Match）に使用するバージョン。ユーザーの行を更新するたびに加算する
+ALTER TABLE users
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- メールやメッセージの言語の設定。NULL の場合はリクエストの Accept-Language に従う
+ALTER TABLE users
+    ADD COLUMN locale VARCHAR(16) NULL;
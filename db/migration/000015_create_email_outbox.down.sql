@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE name IN ('emails:read', 'emails:requeue');
+DROP TABLE IF EXISTS email_outbox;
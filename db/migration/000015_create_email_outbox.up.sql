@@ -0,0 +1,25 @@
+-- 送信待ちのメール。業務上の変更と同じトランザクションで追加し、バックグラウンドのワーカーが配信する
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    recipient VARCHAR(255) NOT NULL,
+    template VARCHAR(100) NOT NULL,
+    locale VARCHAR(16) NOT NULL,
+    data JSON NOT NULL,
+    -- pending: 配信待ち、sent: 配信済み、dead: 再試行の上限に達したため配信を停止
+    status ENUM('pending', 'sent', 'dead') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_email_outbox_status_next_attempt_at (status, next_attempt_at)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('emails:read', '送信待ち・配信に失敗したメールの参照'),
+    ('emails:requeue', '配信に失敗したメールの再送');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'admin' AND p.name IN ('emails:read', 'emails:requeue');
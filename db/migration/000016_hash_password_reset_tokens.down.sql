@@ -0,0 +1,6 @@
+-- ハッシュから元のトークンには戻せないため、発行済みのトークンは削除する
+DELETE FROM password_resets;
+
+ALTER TABLE password_resets
+    CHANGE token_hash token VARCHAR(255) NOT NULL,
+    RENAME INDEX idx_password_resets_token_hash TO idx_password_resets_token;
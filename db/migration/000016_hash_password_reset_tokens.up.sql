@@ -0,0 +1,8 @@
+-- リセットトークンはSHA-256のハッシュだけを保存する
+-- SHA2() は HashToken と同じ16進の小文字を返すため、発行済みのトークンもそのまま使用できる
+UPDATE password_resets
+    SET token = SHA2(token, 256);
+
+ALTER TABLE password_resets
+    CHANGE token token_hash CHAR(64) NOT NULL,
+    RENAME INDEX idx_password_resets_token TO idx_password_resets_token_hash;
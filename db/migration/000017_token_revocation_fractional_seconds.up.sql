@@ -0,0 +1,4 @@
+-- アクセストークンの発行日時（iat）はマイクロ秒単位のため、失効の基準日時も同じ精度で保存する
+-- 秒単位に丸めると、失効の直後に発行したトークンまで失効扱いになる
+ALTER TABLE user_token_revocations
+    MODIFY revoked_before TIMESTAMP(6) NOT NULL;
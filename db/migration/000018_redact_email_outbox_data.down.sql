@@ -0,0 +1,2 @@
+-- 取り除いたリンクは復元できないため、何もしない
+SELECT 1;
@@ -0,0 +1,14 @@
+-- 配信済み・配信を停止したメールに残っているリンクを取り除く
+-- リンクにはリセット用・確認用のトークンがそのまま含まれるため、データベースの参照だけで悪用できてしまう
+UPDATE email_outbox
+    SET data = JSON_REPLACE(data, '$.ResetURL', '')
+    WHERE template = 'password_reset' AND status IN ('sent', 'dead');
+
+UPDATE email_outbox
+    SET data = JSON_REPLACE(data, '$.VerifyURL', '')
+    WHERE template = 'email_verification' AND status IN ('sent', 'dead');
+
+-- 型のわからないテンプレートのデータは全て取り除く
+UPDATE email_outbox
+    SET data = JSON_OBJECT()
+    WHERE template NOT IN ('password_reset', 'email_verification', 'account_locked') AND status IN ('sent', 'dead');
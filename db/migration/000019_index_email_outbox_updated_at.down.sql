@@ -0,0 +1 @@
+DROP INDEX idx_email_outbox_status_updated_at ON email_outbox;
@@ -0,0 +1,2 @@
+-- 保持期間を過ぎた配信済み・配信を停止したメールの削除に使用する
+CREATE INDEX idx_email_outbox_status_updated_at ON email_outbox (status, updated_at);
@@ -0,0 +1,10 @@
+// Package migration はデータベースのマイグレーションファイルをバイナリに埋め込みます
+// ファイルは golang-migrate の形式（<バージョン>_<名前>.up.sql / .down.sql）で作成してください
+package migration
+
+import "embed"
+
+// Files は埋め込んだマイグレーションファイルです
+//
+//go:embed *.sql
+var Files embed.FS
@@ -0,0 +1,63 @@
+-- name: EnqueueEmail :exec
+INSERT INTO email_outbox (
+    recipient, template, locale, data
+) VALUES (
+    ?, ?, ?, ?
+);
+
+-- name: ListDueEmails :many
+-- 配信時刻を過ぎた送信待ちのメールを取得し、他のワーカーが同時に取得しないよう行をロックする
+SELECT id, recipient, template, locale, data, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at
+FROM email_outbox
+WHERE status = 'pending' AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id
+LIMIT ?
+FOR UPDATE SKIP LOCKED;
+
+-- name: LeaseEmail :exec
+-- 配信中に他のワーカーが取得しないよう次の配信時刻を延ばし、試行回数を加算する
+UPDATE email_outbox
+SET attempts = attempts + 1, next_attempt_at = ?
+WHERE id = ?;
+
+-- name: MarkEmailSent :exec
+-- data にはリンクなどの秘密の値を取り除いたデータを指定する
+UPDATE email_outbox
+SET status = 'sent', sent_at = ?, last_error = NULL, data = ?
+WHERE id = ?;
+
+-- name: MarkEmailFailed :exec
+-- status が pending の場合は next_attempt_at に再試行し、dead の場合は配信を停止する
+-- dead の場合は data にリンクなどの秘密の値を取り除いたデータを指定する
+UPDATE email_outbox
+SET status = ?, last_error = ?, next_attempt_at = ?, data = ?
+WHERE id = ?;
+
+-- name: GetOutboxEmail :one
+SELECT id, recipient, template, locale, data, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at
+FROM email_outbox
+WHERE id = ?
+LIMIT 1;
+
+-- name: ListOutboxEmails :many
+SELECT id, recipient, template, locale, data, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at
+FROM email_outbox
+WHERE status = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountOutboxEmails :one
+SELECT COUNT(*)
+FROM email_outbox
+WHERE status = ?;
+
+-- name: PurgeOutboxEmails :execrows
+-- 保持期間を過ぎた配信済み・配信を停止したメールを削除する
+DELETE FROM email_outbox
+WHERE status IN ('sent', 'dead') AND updated_at < sqlc.arg(updated_before);
+
+-- name: RequeueEmail :execrows
+-- 配信を停止したメールの試行回数をリセットし、送信待ちに戻す
+UPDATE email_outbox
+SET status = 'pending', attempts = 0, next_attempt_at = ?
+WHERE id = ? AND status = 'dead';
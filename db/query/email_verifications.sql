@@ -0,0 +1,18 @@
+-- name: UpsertEmailVerification :exec
+INSERT INTO email_verifications (
+    user_id, last_sent_at
+) VALUES (
+    ?, ?
+)
+ON DUPLICATE KEY UPDATE
+    last_sent_at = VALUES(last_sent_at);
+
+-- name: GetEmailVerification :one
+SELECT user_id, last_sent_at
+FROM email_verifications
+WHERE user_id = ?
+LIMIT 1;
+
+-- name: DeleteEmailVerification :exec
+DELETE FROM email_verifications
+WHERE user_id = ?;
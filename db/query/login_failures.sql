@@ -0,0 +1,24 @@
+-- name: GetLoginFailure :one
+SELECT id, scope, subject, failed_count, last_failed_at, locked_until
+FROM login_failures
+WHERE scope = ? AND subject = ?
+LIMIT 1;
+
+-- name: RecordLoginFailure :exec
+INSERT INTO login_failures (
+    scope, subject, failed_count, last_failed_at
+) VALUES (
+    sqlc.arg(scope), sqlc.arg(subject), 1, sqlc.arg(failed_at)
+)
+ON DUPLICATE KEY UPDATE
+    failed_count = IF(last_failed_at < sqlc.arg(reset_before), 1, failed_count + 1),
+    last_failed_at = VALUES(last_failed_at);
+
+-- name: LockLoginSubject :exec
+UPDATE login_failures
+SET locked_until = ?
+WHERE scope = ? AND subject = ?;
+
+-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE scope = ? AND subject = ?;
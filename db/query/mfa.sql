@@ -0,0 +1,46 @@
+-- name: UpsertUserTOTP :exec
+INSERT INTO user_totp (
+    user_id, secret
+) VALUES (
+    ?, ?
+)
+ON DUPLICATE KEY UPDATE
+    secret = VALUES(secret),
+    confirmed_at = NULL,
+    last_used_step = 0;
+
+-- name: GetUserTOTP :one
+SELECT user_id, secret, confirmed_at, last_used_step, created_at, updated_at
+FROM user_totp
+WHERE user_id = ?
+LIMIT 1;
+
+-- name: ConfirmUserTOTP :exec
+UPDATE user_totp
+SET confirmed_at = NOW(), last_used_step = ?
+WHERE user_id = ?;
+
+-- name: UpdateTOTPLastUsedStep :execrows
+UPDATE user_totp
+SET last_used_step = ?
+WHERE user_id = ? AND last_used_step < ?;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp
+WHERE user_id = ?;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes (
+    user_id, code_hash
+) VALUES (
+    ?, ?
+);
+
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes
+SET used_at = NOW()
+WHERE user_id = ? AND code_hash = ? AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = ?;
@@ -1,16 +1,22 @@
 -- name: CreatePasswordReset :execresult
 INSERT INTO password_resets (
-    user_id, token, expires_at
+    user_id, token_hash, expires_at
 ) VALUES (
     ?, ?, ?
 );
 
--- name: GetPasswordResetByToken :one
-SELECT user_id, token, expires_at, created_at
+-- name: GetPasswordResetByHash :one
+-- 同じトークンで同時にリセットされないよう、使用が終わるまで行をロックする
+SELECT id, user_id, token_hash, expires_at, created_at
 FROM password_resets
-WHERE token = ? AND expires_at > NOW()
-LIMIT 1;
+WHERE token_hash = ? AND expires_at > NOW()
+LIMIT 1
+FOR UPDATE;
 
--- name: DeletePasswordReset :exec
+-- name: DeleteUserPasswordResets :exec
 DELETE FROM password_resets
-WHERE token = ?; 
\ No newline at end of file
+WHERE user_id = ?;
+
+-- name: DeleteExpiredPasswordResets :execrows
+DELETE FROM password_resets
+WHERE expires_at <= NOW();
@@ -0,0 +1,17 @@
+-- name: ListUserRoles :many
+SELECT r.name
+FROM roles r
+JOIN user_roles ur ON ur.role_id = r.id
+WHERE ur.user_id = ?
+ORDER BY r.name;
+
+-- name: ListRolePermissions :many
+SELECT r.name AS role_name, p.name AS permission_name
+FROM role_permissions rp
+JOIN roles r ON r.id = rp.role_id
+JOIN permissions p ON p.id = rp.permission_id
+ORDER BY r.name, p.name;
+
+-- name: AssignUserRole :exec
+INSERT IGNORE INTO user_roles (user_id, role_id)
+SELECT sqlc.arg(user_id), id FROM roles WHERE name = sqlc.arg(role_name);
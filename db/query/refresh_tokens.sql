@@ -0,0 +1,31 @@
+-- name: CreateRefreshToken :execresult
+INSERT INTO refresh_tokens (
+    user_id, token_hash, family_id, expires_at
+) VALUES (
+    ?, ?, ?, ?
+);
+
+-- name: GetRefreshTokenByHash :one
+SELECT id, user_id, token_hash, family_id, expires_at, revoked_at, created_at
+FROM refresh_tokens
+WHERE token_hash = ?
+LIMIT 1;
+
+-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE id = ? AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = ? AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = ? AND revoked_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE expires_at <= NOW();
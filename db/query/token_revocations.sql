@@ -0,0 +1,34 @@
+-- name: CreateRevokedToken :exec
+INSERT IGNORE INTO revoked_tokens (
+    jti, user_id, expires_at
+) VALUES (
+    ?, ?, ?
+);
+
+-- name: ListActiveRevokedTokens :many
+SELECT jti, expires_at
+FROM revoked_tokens
+WHERE expires_at > NOW();
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens
+WHERE expires_at <= NOW();
+
+-- name: UpsertUserTokenRevocation :exec
+INSERT INTO user_token_revocations (
+    user_id, revoked_before, expires_at
+) VALUES (
+    ?, ?, ?
+)
+ON DUPLICATE KEY UPDATE
+    revoked_before = VALUES(revoked_before),
+    expires_at = VALUES(expires_at);
+
+-- name: ListActiveUserTokenRevocations :many
+SELECT user_id, revoked_before, expires_at
+FROM user_token_revocations
+WHERE expires_at > NOW();
+
+-- name: DeleteExpiredUserTokenRevocations :exec
+DELETE FROM user_token_revocations
+WHERE expires_at <= NOW();
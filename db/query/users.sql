@@ -1,52 +1,103 @@
 -- name: CreateUser :execresult
 INSERT INTO users (
-    email, password_hash, first_name, last_name, status
+    email, password_hash, first_name, last_name, status, locale
 ) VALUES (
-    ?, ?, ?, ?, ?
+    ?, ?, ?, ?, ?, ?
 );
 
 -- name: GetUser :one
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
-WHERE id = ? LIMIT 1;
+WHERE id = ? AND deleted_at IS NULL LIMIT 1;
 
 -- name: ListUsers :many
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
+WHERE deleted_at IS NULL
 ORDER BY id
 LIMIT ? OFFSET ?;
 
--- name: UpdateUser :exec
+-- name: UpdateUser :execrows
+-- expected_version を指定した場合は、バージョンが一致するときだけ更新する
 UPDATE users
 SET 
-    email = ?,
-    first_name = ?,
-    last_name = ?,
-    status = ?
-WHERE id = ?;
+    email = sqlc.arg(email),
+    first_name = sqlc.arg(first_name),
+    last_name = sqlc.arg(last_name),
+    status = sqlc.arg(status),
+    version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+AND (sqlc.narg(expected_version) IS NULL OR version = sqlc.narg(expected_version));
 
--- name: DeleteUser :exec
-DELETE FROM users
-WHERE id = ?;
+-- name: DeleteUser :execrows
+-- expected_version を指定した場合は、バージョンが一致するときだけ削除する
+UPDATE users
+SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+AND (sqlc.narg(expected_version) IS NULL OR version = sqlc.narg(expected_version));
 
 -- name: GetUserByEmail :one
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
-WHERE email = ? LIMIT 1;
+WHERE email = ? AND deleted_at IS NULL LIMIT 1;
 
 -- name: SearchUsers :many
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
-WHERE (
-    email LIKE CONCAT('%', ?, '%') OR
-    first_name LIKE CONCAT('%', ?, '%') OR
-    last_name LIKE CONCAT('%', ?, '%')
-)
-AND (? IS NULL OR status = ?)
-ORDER BY id
-LIMIT ? OFFSET ?;
+WHERE deleted_at IS NULL
+AND (sqlc.arg(query) = '' OR MATCH(email, first_name, last_name) AGAINST (sqlc.arg(query) IN BOOLEAN MODE))
+AND (sqlc.narg(status) IS NULL OR status = sqlc.narg(status))
+ORDER BY MATCH(email, first_name, last_name) AGAINST (sqlc.arg(query) IN BOOLEAN MODE) DESC, id
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountSearchUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE deleted_at IS NULL
+AND (sqlc.arg(query) = '' OR MATCH(email, first_name, last_name) AGAINST (sqlc.arg(query) IN BOOLEAN MODE))
+AND (sqlc.narg(status) IS NULL OR status = sqlc.narg(status));
 
 -- name: UpdateUserPassword :exec
 UPDATE users
-SET password_hash = ?
-WHERE id = ?; 
\ No newline at end of file
+SET password_hash = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: UpdateUserLocale :execrows
+UPDATE users
+SET locale = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: UpdateUserStatus :execrows
+UPDATE users
+SET status = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: ActivateUser :execrows
+UPDATE users
+SET status = 'active', version = version + 1
+WHERE id = ? AND status = 'pending_verification' AND deleted_at IS NULL;
+
+-- name: UserEmailExists :one
+-- 削除済みのユーザーも復元できるよう、完全に削除されるまではメールアドレスを使用中とみなす
+SELECT EXISTS(SELECT 1 FROM users WHERE email = ?) AS email_exists;
+
+-- name: ListDeletedUsers :many
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
+FROM users
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT ? OFFSET ?;
+
+-- name: CountDeletedUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE deleted_at IS NOT NULL;
+
+-- name: RestoreUser :execrows
+UPDATE users
+SET deleted_at = NULL, version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at >= sqlc.arg(deleted_after);
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < sqlc.arg(deleted_before);
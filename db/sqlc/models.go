@@ -7,16 +7,103 @@ package db
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 )
 
+type EmailOutboxStatus string
+
+const (
+	EmailOutboxStatusPending EmailOutboxStatus = "pending"
+	EmailOutboxStatusSent    EmailOutboxStatus = "sent"
+	EmailOutboxStatusDead    EmailOutboxStatus = "dead"
+)
+
+func (e *EmailOutboxStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EmailOutboxStatus(s)
+	case string:
+		*e = EmailOutboxStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EmailOutboxStatus: %T", src)
+	}
+	return nil
+}
+
+type NullEmailOutboxStatus struct {
+	EmailOutboxStatus EmailOutboxStatus `json:"email_outbox_status"`
+	Valid             bool              `json:"valid"` // Valid is true if EmailOutboxStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullEmailOutboxStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.EmailOutboxStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.EmailOutboxStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullEmailOutboxStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.EmailOutboxStatus), nil
+}
+
+type LoginFailuresScope string
+
+const (
+	LoginFailuresScopeAccount LoginFailuresScope = "account"
+	LoginFailuresScopeIp      LoginFailuresScope = "ip"
+)
+
+func (e *LoginFailuresScope) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LoginFailuresScope(s)
+	case string:
+		*e = LoginFailuresScope(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LoginFailuresScope: %T", src)
+	}
+	return nil
+}
+
+type NullLoginFailuresScope struct {
+	LoginFailuresScope LoginFailuresScope `json:"login_failures_scope"`
+	Valid              bool               `json:"valid"` // Valid is true if LoginFailuresScope is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLoginFailuresScope) Scan(value interface{}) error {
+	if value == nil {
+		ns.LoginFailuresScope, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LoginFailuresScope.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLoginFailuresScope) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LoginFailuresScope), nil
+}
+
 type UsersStatus string
 
 const (
-	UsersStatusActive    UsersStatus = "active"
-	UsersStatusInactive  UsersStatus = "inactive"
-	UsersStatusSuspended UsersStatus = "suspended"
+	UsersStatusActive              UsersStatus = "active"
+	UsersStatusInactive            UsersStatus = "inactive"
+	UsersStatusSuspended           UsersStatus = "suspended"
+	UsersStatusPendingVerification UsersStatus = "pending_verification"
 )
 
 func (e *UsersStatus) Scan(src interface{}) error {
@@ -54,14 +141,87 @@ func (ns NullUsersStatus) Value() (driver.Value, error) {
 	return string(ns.UsersStatus), nil
 }
 
+type EmailOutbox struct {
+	ID            int64             `json:"id"`
+	Recipient     string            `json:"recipient"`
+	Template      string            `json:"template"`
+	Locale        string            `json:"locale"`
+	Data          json.RawMessage   `json:"data"`
+	Status        EmailOutboxStatus `json:"status"`
+	Attempts      int32             `json:"attempts"`
+	LastError     sql.NullString    `json:"last_error"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	SentAt        sql.NullTime      `json:"sent_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+type EmailVerification struct {
+	UserID     int64     `json:"user_id"`
+	LastSentAt time.Time `json:"last_sent_at"`
+}
+
+type LoginFailure struct {
+	ID           int64              `json:"id"`
+	Scope        LoginFailuresScope `json:"scope"`
+	Subject      string             `json:"subject"`
+	FailedCount  int32              `json:"failed_count"`
+	LastFailedAt time.Time          `json:"last_failed_at"`
+	LockedUntil  sql.NullTime       `json:"locked_until"`
+}
+
+type MfaRecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
 type PasswordReset struct {
 	ID        int64        `json:"id"`
 	UserID    int64        `json:"user_id"`
-	Token     string       `json:"token"`
+	TokenHash string       `json:"token_hash"`
 	ExpiresAt time.Time    `json:"expires_at"`
 	CreatedAt sql.NullTime `json:"created_at"`
 }
 
+type Permission struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	FamilyID  string       `json:"family_id"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RevokedToken struct {
+	Jti       string    `json:"jti"`
+	UserID    int64     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Role struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RolePermission struct {
+	RoleID       int64 `json:"role_id"`
+	PermissionID int64 `json:"permission_id"`
+}
+
 type User struct {
 	ID           int64           `json:"id"`
 	Email        string          `json:"email"`
@@ -71,4 +231,28 @@ type User struct {
 	Status       NullUsersStatus `json:"status"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
+	DeletedAt    sql.NullTime    `json:"deleted_at"`
+	Version      int32           `json:"version"`
+	Locale       sql.NullString  `json:"locale"`
+}
+
+type UserRole struct {
+	UserID    int64     `json:"user_id"`
+	RoleID    int64     `json:"role_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserTokenRevocation struct {
+	UserID        int64     `json:"user_id"`
+	RevokedBefore time.Time `json:"revoked_before"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type UserTotp struct {
+	UserID       int64        `json:"user_id"`
+	Secret       string       `json:"secret"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	LastUsedStep int64        `json:"last_used_step"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
 }
@@ -13,7 +13,7 @@ import (
 
 const createPasswordReset = `-- name: CreatePasswordReset :execresult
 INSERT INTO password_resets (
-    user_id, token, expires_at
+    user_id, token_hash, expires_at
 ) VALUES (
     ?, ?, ?
 )
@@ -21,44 +21,53 @@ INSERT INTO password_resets (
 
 type CreatePasswordResetParams struct {
 	UserID    int64     `json:"user_id"`
-	Token     string    `json:"token"`
+	TokenHash string    `json:"token_hash"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
 func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (sql.Result, error) {
-	return q.db.ExecContext(ctx, createPasswordReset, arg.UserID, arg.Token, arg.ExpiresAt)
+	return q.db.ExecContext(ctx, createPasswordReset, arg.UserID, arg.TokenHash, arg.ExpiresAt)
 }
 
-const deletePasswordReset = `-- name: DeletePasswordReset :exec
+const deleteExpiredPasswordResets = `-- name: DeleteExpiredPasswordResets :execrows
 DELETE FROM password_resets
-WHERE token = ?
+WHERE expires_at <= NOW()
 `
 
-func (q *Queries) DeletePasswordReset(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, deletePasswordReset, token)
+func (q *Queries) DeleteExpiredPasswordResets(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredPasswordResets)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserPasswordResets = `-- name: DeleteUserPasswordResets :exec
+DELETE FROM password_resets
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserPasswordResets(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserPasswordResets, userID)
 	return err
 }
 
-const getPasswordResetByToken = `-- name: GetPasswordResetByToken :one
-SELECT user_id, token, expires_at, created_at
+const getPasswordResetByHash = `-- name: GetPasswordResetByHash :one
+SELECT id, user_id, token_hash, expires_at, created_at
 FROM password_resets
-WHERE token = ? AND expires_at > NOW()
+WHERE token_hash = ? AND expires_at > NOW()
 LIMIT 1
+FOR UPDATE
 `
 
-type GetPasswordResetByTokenRow struct {
-	UserID    int64        `json:"user_id"`
-	Token     string       `json:"token"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	CreatedAt sql.NullTime `json:"created_at"`
-}
-
-func (q *Queries) GetPasswordResetByToken(ctx context.Context, token string) (GetPasswordResetByTokenRow, error) {
-	row := q.db.QueryRowContext(ctx, getPasswordResetByToken, token)
-	var i GetPasswordResetByTokenRow
+// 同じトークンで同時にリセットされないよう、使用が終わるまで行をロックする
+func (q *Queries) GetPasswordResetByHash(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetByHash, tokenHash)
+	var i PasswordReset
 	err := row.Scan(
+		&i.ID,
 		&i.UserID,
-		&i.Token,
+		&i.TokenHash,
 		&i.ExpiresAt,
 		&i.CreatedAt,
 	)
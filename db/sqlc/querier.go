@@ -7,20 +7,82 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	ActivateUser(ctx context.Context, id int64) (int64, error)
+	AssignUserRole(ctx context.Context, arg AssignUserRoleParams) error
+	ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) error
+	CountDeletedUsers(ctx context.Context) (int64, error)
+	CountOutboxEmails(ctx context.Context, status EmailOutboxStatus) (int64, error)
+	CountSearchUsers(ctx context.Context, arg CountSearchUsersParams) (int64, error)
 	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (sql.Result, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (sql.Result, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (sql.Result, error)
-	DeletePasswordReset(ctx context.Context, token string) error
-	DeleteUser(ctx context.Context, id int64) error
-	GetPasswordResetByToken(ctx context.Context, token string) (GetPasswordResetByTokenRow, error)
+	DeleteEmailVerification(ctx context.Context, userID int64) error
+	DeleteExpiredPasswordResets(ctx context.Context) (int64, error)
+	DeleteExpiredRefreshTokens(ctx context.Context) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) error
+	DeleteExpiredUserTokenRevocations(ctx context.Context) error
+	DeleteLoginFailure(ctx context.Context, arg DeleteLoginFailureParams) error
+	DeleteRecoveryCodes(ctx context.Context, userID int64) error
+	// expected_version を指定した場合は、バージョンが一致するときだけ削除する
+	DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error)
+	DeleteUserPasswordResets(ctx context.Context, userID int64) error
+	DeleteUserTOTP(ctx context.Context, userID int64) error
+	EnqueueEmail(ctx context.Context, arg EnqueueEmailParams) error
+	GetEmailVerification(ctx context.Context, userID int64) (EmailVerification, error)
+	GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error)
+	GetOutboxEmail(ctx context.Context, id int64) (EmailOutbox, error)
+	// 同じトークンで同時にリセットされないよう、使用が終わるまで行をロックする
+	GetPasswordResetByHash(ctx context.Context, tokenHash string) (PasswordReset, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
 	GetUser(ctx context.Context, id int64) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserTOTP(ctx context.Context, userID int64) (UserTotp, error)
+	// 配信中に他のワーカーが取得しないよう次の配信時刻を延ばし、試行回数を加算する
+	LeaseEmail(ctx context.Context, arg LeaseEmailParams) error
+	ListActiveRevokedTokens(ctx context.Context) ([]ListActiveRevokedTokensRow, error)
+	ListActiveUserTokenRevocations(ctx context.Context) ([]UserTokenRevocation, error)
+	ListDeletedUsers(ctx context.Context, arg ListDeletedUsersParams) ([]User, error)
+	// 配信時刻を過ぎた送信待ちのメールを取得し、他のワーカーが同時に取得しないよう行をロックする
+	ListDueEmails(ctx context.Context, arg ListDueEmailsParams) ([]EmailOutbox, error)
+	ListOutboxEmails(ctx context.Context, arg ListOutboxEmailsParams) ([]EmailOutbox, error)
+	ListRolePermissions(ctx context.Context) ([]ListRolePermissionsRow, error)
+	ListUserRoles(ctx context.Context, userID int64) ([]string, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	LockLoginSubject(ctx context.Context, arg LockLoginSubjectParams) error
+	// status が pending の場合は next_attempt_at に再試行し、dead の場合は配信を停止する
+	// dead の場合は data にリンクなどの秘密の値を取り除いたデータを指定する
+	MarkEmailFailed(ctx context.Context, arg MarkEmailFailedParams) error
+	// data にはリンクなどの秘密の値を取り除いたデータを指定する
+	MarkEmailSent(ctx context.Context, arg MarkEmailSentParams) error
+	PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error)
+	// 保持期間を過ぎた配信済み・配信を停止したメールを削除する
+	PurgeOutboxEmails(ctx context.Context, updatedBefore time.Time) (int64, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) error
+	// 配信を停止したメールの試行回数をリセットし、送信待ちに戻す
+	RequeueEmail(ctx context.Context, arg RequeueEmailParams) (int64, error)
+	RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error)
+	RevokeRefreshToken(ctx context.Context, id int64) (int64, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) error
 	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
-	UpdateUser(ctx context.Context, arg UpdateUserParams) error
+	UpdateTOTPLastUsedStep(ctx context.Context, arg UpdateTOTPLastUsedStepParams) (int64, error)
+	// expected_version を指定した場合は、バージョンが一致するときだけ更新する
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (int64, error)
+	UpdateUserLocale(ctx context.Context, arg UpdateUserLocaleParams) (int64, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (int64, error)
+	UpsertEmailVerification(ctx context.Context, arg UpsertEmailVerificationParams) error
+	UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) error
+	UpsertUserTokenRevocation(ctx context.Context, arg UpsertUserTokenRevocationParams) error
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	// 削除済みのユーザーも復元できるよう、完全に削除されるまではメールアドレスを使用中とみなす
+	UserEmailExists(ctx context.Context, email string) (bool, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLのエラー番号
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+// maxTxAttempts はデッドロックやロック待ちのタイムアウトでトランザクションを実行し直す場合の最大の試行回数です
+const maxTxAttempts = 3
+
+// txRetryDelay はトランザクションを実行し直すまでの待機時間です。実行し直すたびに倍になります
+const txRetryDelay = 20 * time.Millisecond
+
+// Store はsqlcが生成したクエリに加えて、条件によってSQLが変わる手書きのクエリとトランザクションを提供します
+type Store interface {
+	Querier
+	// FilterUsers は絞り込み条件と並び順を指定してユーザー一覧を取得します
+	FilterUsers(ctx context.Context, arg FilterUsersParams) ([]User, error)
+	// CountFilteredUsers は絞り込み条件に一致するユーザーの件数を取得します
+	CountFilteredUsers(ctx context.Context, filter UserFilter) (int64, error)
+	// ExecTx は fn に渡した Store のクエリを1つのトランザクションで実行します
+	// fn がエラーを返した場合はロールバックし、そのエラーを返します
+	// デッドロックやロック待ちのタイムアウトで失敗した場合は fn を最初から実行し直すため、
+	// fn はトランザクションの外に副作用を残さないようにしてください
+	ExecTx(ctx context.Context, fn func(Store) error) error
+}
+
+// SQLStore はデータベース接続を使用する Store の実装です
+type SQLStore struct {
+	*Queries
+	// db はトランザクション内の SQLStore では nil になります
+	db *sql.DB
+}
+
+// NewStore は新しいSQLStoreを作成します
+func NewStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		Queries: New(db),
+		db:      db,
+	}
+}
+
+// ExecTx は fn をトランザクション内で実行します
+// トランザクション内の Store から呼び出した場合は、外側のトランザクションでそのまま実行します
+func (s *SQLStore) ExecTx(ctx context.Context, fn func(Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	return retryTx(ctx, func() error {
+		return s.execTx(ctx, fn)
+	})
+}
+
+// execTx はトランザクションを開始して fn を実行し、結果に応じてコミットまたはロールバックします
+func (s *SQLStore) execTx(ctx context.Context, fn func(Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&SQLStore{Queries: s.Queries.WithTx(tx)}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback: %v)", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// retryTx はデッドロックやロック待ちのタイムアウトで失敗したトランザクションを、待機時間をおいて実行し直します
+func retryTx(ctx context.Context, run func() error) error {
+	delay := txRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= maxTxAttempts || !isRetryableTxError(err) {
+			return err
+		}
+
+		// 同時に失敗したトランザクションが同じ間隔で衝突し続けないよう、待機時間をばらつかせる
+		wait := delay + rand.N(delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// isRetryableTxError はトランザクションを実行し直すことで成功する可能性があるエラーかを判定します
+func isRetryableTxError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+}
+
+var _ Store = (*SQLStore)(nil)
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryTx(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: mysqlDeadlock, Message: "Deadlock found when trying to get lock"}
+	lockWait := &mysql.MySQLError{Number: mysqlLockWaitTimeout, Message: "Lock wait timeout exceeded"}
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+
+	// テストケースの定義
+	tests := []struct {
+		name             string
+		errs             []error
+		expectedErr      error
+		expectedAttempts int
+	}{
+		{
+			name:             "成功した場合は1回だけ実行する",
+			errs:             []error{nil},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "デッドロックの場合は実行し直す",
+			errs:             []error{deadlock, nil},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "ロック待ちのタイムアウトの場合は実行し直す",
+			errs:             []error{lockWait, fmt.Errorf("ロールバックに失敗: %w", deadlock), nil},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "試行回数の上限に達した場合は最後のエラーを返す",
+			errs:             []error{deadlock, deadlock, lockWait, nil},
+			expectedErr:      lockWait,
+			expectedAttempts: maxTxAttempts,
+		},
+		{
+			name:             "その他のエラーは実行し直さない",
+			errs:             []error{duplicate, nil},
+			expectedErr:      duplicate,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retryTx(context.Background(), func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedAttempts, attempts)
+		})
+	}
+
+	t.Run("キャンセルされた場合は待機せずにエラーを返す", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		attempts := 0
+		err := retryTx(ctx, func() error {
+			attempts++
+			return deadlock
+		})
+		assert.ErrorIs(t, err, deadlock)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	assert.True(t, isRetryableTxError(&mysql.MySQLError{Number: mysqlDeadlock}))
+	assert.True(t, isRetryableTxError(fmt.Errorf("wrapped: %w", &mysql.MySQLError{Number: mysqlLockWaitTimeout})))
+	assert.False(t, isRetryableTxError(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, isRetryableTxError(errors.New("connection refused")))
+}
@@ -0,0 +1,284 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserSortField はユーザー一覧の並び替えに使用できる列です
+type UserSortField string
+
+const (
+	UserSortID        UserSortField = "id"
+	UserSortCreatedAt UserSortField = "created_at"
+	UserSortUpdatedAt UserSortField = "updated_at"
+	UserSortEmail     UserSortField = "email"
+	UserSortLastName  UserSortField = "last_name"
+)
+
+// Valid は並び替えに使用できる列かを返します
+// 列名はSQLにそのまま埋め込むため、ここで許可したもの以外は使用できません
+func (f UserSortField) Valid() bool {
+	switch f {
+	case UserSortID, UserSortCreatedAt, UserSortUpdatedAt, UserSortEmail, UserSortLastName:
+		return true
+	}
+	return false
+}
+
+// UserSort は並び替えの列と方向です
+type UserSort struct {
+	Field UserSortField
+	Desc  bool
+}
+
+// UserFilter はユーザー一覧の絞り込み条件です
+// ゼロ値の項目は条件に含めません
+type UserFilter struct {
+	Status        NullUsersStatus
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	// EmailDomain はメールアドレスの@以降と完全一致させます
+	EmailDomain string
+	// NamePrefix は名または姓の前方一致で絞り込みます
+	NamePrefix string
+}
+
+// UserKeyset はキーセットページネーションの境界です
+type UserKeyset struct {
+	// Values は境界となるユーザーの並び替えキーで、UserSortKey で生成します
+	Values []string
+	// Before が true の場合は境界より前、false の場合は後のユーザーを取得します
+	Before bool
+}
+
+type FilterUsersParams struct {
+	Filter UserFilter
+	Sort   []UserSort
+	// Keyset を指定した場合は Offset を無視してキーセット方式で取得します
+	Keyset *UserKeyset
+	Limit  int32
+	Offset int32
+}
+
+// ErrInvalidKeyset は並び順と一致しない境界が指定された場合のエラーです
+var ErrInvalidKeyset = errors.New("db: keyset does not match sort order")
+
+const filterUsersColumns = "id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale"
+
+func (q *Queries) FilterUsers(ctx context.Context, arg FilterUsersParams) ([]User, error) {
+	sorts, err := completeUserSort(arg.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, args := arg.Filter.conditions()
+	if arg.Keyset != nil {
+		condition, keysetArgs, err := keysetCondition(sorts, *arg.Keyset)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, keysetArgs...)
+	}
+
+	// 前のページは逆順に取得し、取得後に元の並び順へ戻す
+	reverse := arg.Keyset != nil && arg.Keyset.Before
+
+	query := "SELECT " + filterUsersColumns + "\nFROM users\nWHERE " + strings.Join(conditions, " AND ") +
+		"\nORDER BY " + orderByClause(sorts, reverse) + "\nLIMIT ?"
+	args = append(args, arg.Limit)
+	if arg.Keyset == nil {
+		query += " OFFSET ?"
+		args = append(args, arg.Offset)
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.FirstName,
+			&i.LastName,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Locale,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if reverse {
+		for l, r := 0, len(items)-1; l < r; l, r = l+1, r-1 {
+			items[l], items[r] = items[r], items[l]
+		}
+	}
+	return items, nil
+}
+
+func (q *Queries) CountFilteredUsers(ctx context.Context, filter UserFilter) (int64, error) {
+	conditions, args := filter.conditions()
+	row := q.db.QueryRowContext(ctx, "SELECT COUNT(*)\nFROM users\nWHERE "+strings.Join(conditions, " AND "), args...)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+// UserSortKey は指定した並び順におけるユーザーの位置を UserKeyset の Values の形式で返します
+func UserSortKey(user User, sort []UserSort) ([]string, error) {
+	sorts, err := completeUserSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(sorts))
+	for i, s := range sorts {
+		switch s.Field {
+		case UserSortID:
+			values[i] = strconv.FormatInt(user.ID, 10)
+		case UserSortCreatedAt:
+			values[i] = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+		case UserSortUpdatedAt:
+			values[i] = user.UpdatedAt.UTC().Format(time.RFC3339Nano)
+		case UserSortEmail:
+			values[i] = user.Email
+		case UserSortLastName:
+			values[i] = user.LastName
+		}
+	}
+	return values, nil
+}
+
+// conditions は絞り込み条件をWHERE句の条件とその引数に変換します
+func (f UserFilter) conditions() ([]string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	if f.Status.Valid {
+		conditions = append(conditions, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.CreatedAfter.Valid {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, f.CreatedAfter.Time)
+	}
+	if f.CreatedBefore.Valid {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, f.CreatedBefore.Time)
+	}
+	if f.EmailDomain != "" {
+		conditions = append(conditions, "email LIKE ?")
+		args = append(args, "%@"+escapeLike(f.EmailDomain))
+	}
+	if f.NamePrefix != "" {
+		prefix := escapeLike(f.NamePrefix) + "%"
+		conditions = append(conditions, "(first_name LIKE ? OR last_name LIKE ?)")
+		args = append(args, prefix, prefix)
+	}
+	return conditions, args
+}
+
+// completeUserSort は並び順が一意になるよう、末尾にIDを加えます
+func completeUserSort(sort []UserSort) ([]UserSort, error) {
+	sorts := make([]UserSort, 0, len(sort)+1)
+	hasID := false
+	for _, s := range sort {
+		if !s.Field.Valid() {
+			return nil, fmt.Errorf("db: invalid sort field %q", s.Field)
+		}
+		if s.Field == UserSortID {
+			hasID = true
+		}
+		sorts = append(sorts, s)
+	}
+	if !hasID {
+		sorts = append(sorts, UserSort{Field: UserSortID})
+	}
+	return sorts, nil
+}
+
+// orderByClause はORDER BY句を生成します。reverse が true の場合は全ての方向を反転します
+func orderByClause(sorts []UserSort, reverse bool) string {
+	columns := make([]string, len(sorts))
+	for i, s := range sorts {
+		direction := "ASC"
+		if s.Desc != reverse {
+			direction = "DESC"
+		}
+		columns[i] = string(s.Field) + " " + direction
+	}
+	return strings.Join(columns, ", ")
+}
+
+// keysetCondition は境界より後（Before の場合は前）の行に一致する条件を生成します
+// 例えば created_at DESC, id ASC の場合は
+// (created_at < ?) OR (created_at = ? AND id > ?) になります
+func keysetCondition(sorts []UserSort, keyset UserKeyset) (string, []interface{}, error) {
+	if len(keyset.Values) != len(sorts) {
+		return "", nil, ErrInvalidKeyset
+	}
+	values := make([]interface{}, len(sorts))
+	for i, s := range sorts {
+		value, err := parseUserSortValue(s.Field, keyset.Values[i])
+		if err != nil {
+			return "", nil, ErrInvalidKeyset
+		}
+		values[i] = value
+	}
+
+	var (
+		alternatives []string
+		args         []interface{}
+	)
+	for i, s := range sorts {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, string(sorts[j].Field)+" = ?")
+			args = append(args, values[j])
+		}
+		operator := "<"
+		if s.Desc == keyset.Before {
+			operator = ">"
+		}
+		parts = append(parts, string(s.Field)+" "+operator+" ?")
+		args = append(args, values[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+// parseUserSortValue は UserSortKey で文字列にした値を元の型に戻します
+func parseUserSortValue(field UserSortField, value string) (interface{}, error) {
+	switch field {
+	case UserSortID:
+		return strconv.ParseInt(value, 10, 64)
+	case UserSortCreatedAt, UserSortUpdatedAt:
+		return time.Parse(time.RFC3339Nano, value)
+	default:
+		return value, nil
+	}
+}
+
+// escapeLike はLIKEのワイルドカードとして解釈されないよう特殊文字をエスケープします
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	createdAt := time.Date(2024, 1, 23, 12, 0, 0, 0, time.UTC)
+	sorts := []UserSort{{Field: UserSortCreatedAt, Desc: true}, {Field: UserSortID}}
+
+	tests := []struct {
+		name          string
+		keyset        UserKeyset
+		expectedSQL   string
+		expectedArgs  []interface{}
+		expectedError error
+	}{
+		{
+			name:         "次のページ",
+			keyset:       UserKeyset{Values: []string{"2024-01-23T12:00:00Z", "5"}},
+			expectedSQL:  "((created_at < ?) OR (created_at = ? AND id > ?))",
+			expectedArgs: []interface{}{createdAt, createdAt, int64(5)},
+		},
+		{
+			name:         "前のページは比較の向きを反転する",
+			keyset:       UserKeyset{Values: []string{"2024-01-23T12:00:00Z", "5"}, Before: true},
+			expectedSQL:  "((created_at > ?) OR (created_at = ? AND id < ?))",
+			expectedArgs: []interface{}{createdAt, createdAt, int64(5)},
+		},
+		{
+			name:          "並び順と値の数が異なる",
+			keyset:        UserKeyset{Values: []string{"5"}},
+			expectedError: ErrInvalidKeyset,
+		},
+		{
+			name:          "値の形式が不正",
+			keyset:        UserKeyset{Values: []string{"yesterday", "5"}},
+			expectedError: ErrInvalidKeyset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := keysetCondition(sorts, tt.keyset)
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedSQL, query)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestUserFilterConditions(t *testing.T) {
+	createdAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := UserFilter{
+		CreatedAfter: sql.NullTime{Time: createdAfter, Valid: true},
+		EmailDomain:  "example_corp.com",
+		NamePrefix:   "100%",
+	}
+
+	conditions, args := filter.conditions()
+
+	assert.Equal(t, []string{
+		"deleted_at IS NULL",
+		"created_at >= ?",
+		"email LIKE ?",
+		"(first_name LIKE ? OR last_name LIKE ?)",
+	}, conditions)
+	assert.Equal(t, []interface{}{createdAfter, `%@example\_corp.com`, `100\%%`, `100\%%`}, args)
+}
+
+func TestUserSortKey(t *testing.T) {
+	user := User{ID: 7, Email: "a@example.com", CreatedAt: time.Date(2024, 1, 23, 21, 0, 0, 0, time.FixedZone("JST", 9*60*60))}
+
+	keys, err := UserSortKey(user, []UserSort{{Field: UserSortEmail}, {Field: UserSortCreatedAt, Desc: true}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a@example.com", "2024-01-23T12:00:00Z", "7"}, keys)
+}
@@ -10,11 +10,63 @@ import (
 	"database/sql"
 )
 
+const activateUser = `-- name: ActivateUser :execrows
+UPDATE users
+SET status = 'active', version = version + 1
+WHERE id = ? AND status = 'pending_verification' AND deleted_at IS NULL
+`
+
+func (q *Queries) ActivateUser(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, activateUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countDeletedUsers = `-- name: CountDeletedUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountDeletedUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDeletedUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSearchUsers = `-- name: CountSearchUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE deleted_at IS NULL
+AND (? = '' OR MATCH(email, first_name, last_name) AGAINST (? IN BOOLEAN MODE))
+AND (? IS NULL OR status = ?)
+`
+
+type CountSearchUsersParams struct {
+	Query  string          `json:"query"`
+	Status NullUsersStatus `json:"status"`
+}
+
+func (q *Queries) CountSearchUsers(ctx context.Context, arg CountSearchUsersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchUsers,
+		arg.Query,
+		arg.Query,
+		arg.Status,
+		arg.Status,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :execresult
 INSERT INTO users (
-    email, password_hash, first_name, last_name, status
+    email, password_hash, first_name, last_name, status, locale
 ) VALUES (
-    ?, ?, ?, ?, ?
+    ?, ?, ?, ?, ?, ?
 )
 `
 
@@ -24,6 +76,7 @@ type CreateUserParams struct {
 	FirstName    string          `json:"first_name"`
 	LastName     string          `json:"last_name"`
 	Status       NullUsersStatus `json:"status"`
+	Locale       sql.NullString  `json:"locale"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (sql.Result, error) {
@@ -33,23 +86,35 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (sql.Res
 		arg.FirstName,
 		arg.LastName,
 		arg.Status,
+		arg.Locale,
 	)
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM users
-WHERE id = ?
+const deleteUser = `-- name: DeleteUser :execrows
+UPDATE users
+SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+WHERE id = ? AND deleted_at IS NULL
+AND (? IS NULL OR version = ?)
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, id)
-	return err
+type DeleteUserParams struct {
+	ID              int64         `json:"id"`
+	ExpectedVersion sql.NullInt32 `json:"expected_version"`
+}
+
+// expected_version を指定した場合は、バージョンが一致するときだけ削除する
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, arg.ID, arg.ExpectedVersion, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
-WHERE id = ? LIMIT 1
+WHERE id = ? AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
@@ -64,14 +129,17 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Locale,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
-WHERE email = ? LIMIT 1
+WHERE email = ? AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -86,13 +154,65 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Locale,
 	)
 	return i, err
 }
 
+const listDeletedUsers = `-- name: ListDeletedUsers :many
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
+FROM users
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT ? OFFSET ?
+`
+
+type ListDeletedUsersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListDeletedUsers(ctx context.Context, arg ListDeletedUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.FirstName,
+			&i.LastName,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Locale,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
+WHERE deleted_at IS NULL
 ORDER BY id
 LIMIT ? OFFSET ?
 `
@@ -120,6 +240,9 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Locale,
 		); err != nil {
 			return nil, err
 		}
@@ -134,36 +257,62 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < ?
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUsers, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+UPDATE users
+SET deleted_at = NULL, version = version + 1
+WHERE id = ? AND deleted_at >= ?
+`
+
+type RestoreUserParams struct {
+	ID           int64        `json:"id"`
+	DeletedAfter sql.NullTime `json:"deleted_after"`
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreUser, arg.ID, arg.DeletedAfter)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const searchUsers = `-- name: SearchUsers :many
-SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at
+SELECT id, email, password_hash, first_name, last_name, status, created_at, updated_at, deleted_at, version, locale
 FROM users
-WHERE (
-    email LIKE CONCAT('%', ?, '%') OR
-    first_name LIKE CONCAT('%', ?, '%') OR
-    last_name LIKE CONCAT('%', ?, '%')
-)
+WHERE deleted_at IS NULL
+AND (? = '' OR MATCH(email, first_name, last_name) AGAINST (? IN BOOLEAN MODE))
 AND (? IS NULL OR status = ?)
-ORDER BY id
+ORDER BY MATCH(email, first_name, last_name) AGAINST (? IN BOOLEAN MODE) DESC, id
 LIMIT ? OFFSET ?
 `
 
 type SearchUsersParams struct {
-	CONCAT   interface{}     `json:"CONCAT"`
-	CONCAT_2 interface{}     `json:"CONCAT_2"`
-	CONCAT_3 interface{}     `json:"CONCAT_3"`
-	Column4  interface{}     `json:"column_4"`
-	Status   NullUsersStatus `json:"status"`
-	Limit    int32           `json:"limit"`
-	Offset   int32           `json:"offset"`
+	Query  string          `json:"query"`
+	Status NullUsersStatus `json:"status"`
+	Limit  int32           `json:"limit"`
+	Offset int32           `json:"offset"`
 }
 
 func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
 	rows, err := q.db.QueryContext(ctx, searchUsers,
-		arg.CONCAT,
-		arg.CONCAT_2,
-		arg.CONCAT_3,
-		arg.Column4,
+		arg.Query,
+		arg.Query,
 		arg.Status,
+		arg.Status,
+		arg.Query,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -183,6 +332,9 @@ func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]Use
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Locale,
 		); err != nil {
 			return nil, err
 		}
@@ -197,39 +349,67 @@ func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]Use
 	return items, nil
 }
 
-const updateUser = `-- name: UpdateUser :exec
+const updateUser = `-- name: UpdateUser :execrows
 UPDATE users
 SET 
     email = ?,
     first_name = ?,
     last_name = ?,
-    status = ?
-WHERE id = ?
+    status = ?,
+    version = version + 1
+WHERE id = ? AND deleted_at IS NULL
+AND (? IS NULL OR version = ?)
 `
 
 type UpdateUserParams struct {
-	Email     string          `json:"email"`
-	FirstName string          `json:"first_name"`
-	LastName  string          `json:"last_name"`
-	Status    NullUsersStatus `json:"status"`
-	ID        int64           `json:"id"`
+	Email           string          `json:"email"`
+	FirstName       string          `json:"first_name"`
+	LastName        string          `json:"last_name"`
+	Status          NullUsersStatus `json:"status"`
+	ID              int64           `json:"id"`
+	ExpectedVersion sql.NullInt32   `json:"expected_version"`
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
-	_, err := q.db.ExecContext(ctx, updateUser,
+// expected_version を指定した場合は、バージョンが一致するときだけ更新する
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUser,
 		arg.Email,
 		arg.FirstName,
 		arg.LastName,
 		arg.Status,
 		arg.ID,
+		arg.ExpectedVersion,
+		arg.ExpectedVersion,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateUserLocale = `-- name: UpdateUserLocale :execrows
+UPDATE users
+SET locale = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL
+`
+
+type UpdateUserLocaleParams struct {
+	Locale sql.NullString `json:"locale"`
+	ID     int64          `json:"id"`
+}
+
+func (q *Queries) UpdateUserLocale(ctx context.Context, arg UpdateUserLocaleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserLocale, arg.Locale, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
-SET password_hash = ?
-WHERE id = ?
+SET password_hash = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL
 `
 
 type UpdateUserPasswordParams struct {
@@ -241,3 +421,34 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.PasswordHash, arg.ID)
 	return err
 }
+
+const updateUserStatus = `-- name: UpdateUserStatus :execrows
+UPDATE users
+SET status = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL
+`
+
+type UpdateUserStatusParams struct {
+	Status NullUsersStatus `json:"status"`
+	ID     int64           `json:"id"`
+}
+
+func (q *Queries) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserStatus, arg.Status, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const userEmailExists = `-- name: UserEmailExists :one
+SELECT EXISTS(SELECT 1 FROM users WHERE email = ?) AS email_exists
+`
+
+// 削除済みのユーザーも復元できるよう、完全に削除されるまではメールアドレスを使用中とみなす
+func (q *Queries) UserEmailExists(ctx context.Context, email string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, userEmailExists, email)
+	var email_exists bool
+	err := row.Scan(&email_exists)
+	return email_exists, err
+}
@@ -0,0 +1,145 @@
+// Package apperror はクライアントに返すエラーを安定したエラーコードで表現します
+// ハンドラーはエラーを c.Error に渡し、middleware.ErrorHandler が RFC 9457 の problem+json として返します
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-gin-sqlc/internal/i18n"
+)
+
+// Code はクライアントが処理を分岐するための安定したエラーコードです
+// 一度公開したコードの意味は変更しないでください
+type Code string
+
+const (
+	CodeInvalidRequest       Code = "invalid_request"
+	CodeValidation           Code = "validation_failed"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeEmailNotVerified     Code = "email_not_verified"
+	CodeForbidden            Code = "forbidden"
+	CodeNotFound             Code = "not_found"
+	CodeAlreadyExists        Code = "already_exists"
+	CodeConflict             Code = "conflict"
+	CodePreconditionFailed   Code = "precondition_failed"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodePreconditionRequired Code = "precondition_required"
+	CodeAccountLocked        Code = "account_locked"
+	CodeLoginThrottled       Code = "login_throttled"
+	CodeInternal             Code = "internal_error"
+)
+
+// codeDefinition はエラーコードごとのHTTPステータスとタイトルです
+type codeDefinition struct {
+	status int
+	title  i18n.Message
+}
+
+var definitions = map[Code]codeDefinition{
+	CodeInvalidRequest:       {http.StatusBadRequest, i18n.TitleInvalidRequest},
+	CodeValidation:           {http.StatusBadRequest, i18n.TitleValidation},
+	CodeUnauthorized:         {http.StatusUnauthorized, i18n.TitleUnauthorized},
+	CodeEmailNotVerified:     {http.StatusForbidden, i18n.TitleEmailNotVerified},
+	CodeForbidden:            {http.StatusForbidden, i18n.TitleForbidden},
+	CodeNotFound:             {http.StatusNotFound, i18n.TitleNotFound},
+	CodeAlreadyExists:        {http.StatusConflict, i18n.TitleAlreadyExists},
+	CodeConflict:             {http.StatusConflict, i18n.TitleConflict},
+	CodePreconditionFailed:   {http.StatusPreconditionFailed, i18n.TitlePreconditionFailed},
+	CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, i18n.TitleUnsupportedMediaType},
+	CodePreconditionRequired: {http.StatusPreconditionRequired, i18n.TitlePreconditionRequired},
+	CodeAccountLocked:        {http.StatusTooManyRequests, i18n.TitleAccountLocked},
+	CodeLoginThrottled:       {http.StatusTooManyRequests, i18n.TitleLoginThrottled},
+	CodeInternal:             {http.StatusInternalServerError, i18n.TitleInternal},
+}
+
+// FieldError は入力項目ごとの検証エラーです
+type FieldError struct {
+	// Field はリクエストのJSONやクエリパラメータでの項目名です
+	Field string
+	// Reason は検証ルールの名前です（required, email など）
+	Reason  string
+	Message i18n.Message
+	Args    []interface{}
+}
+
+// Error はクライアントに返すエラーです
+// Message と Fields はリクエストの言語に翻訳してそのままクライアントに返すため、内部の情報を含めないでください
+type Error struct {
+	Code    Code
+	Message i18n.Message
+	// Args は Message の書式指定子に埋め込む値です
+	Args   []interface{}
+	Fields []FieldError
+	// RetryAfter は再試行できるまでの時間です。0より大きい場合は Retry-After ヘッダーで返します
+	RetryAfter time.Duration
+	// Err はログにのみ出力する内部のエラーです
+	Err error
+}
+
+// New は指定したコードとメッセージのエラーを作成します
+func New(code Code, message i18n.Message) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf は書式指定子を含むメッセージとその引数でエラーを作成します
+func Newf(code Code, message i18n.Message, args ...interface{}) *Error {
+	return &Error{Code: code, Message: message, Args: args}
+}
+
+// Internal は内部エラーを包んだサーバーエラーを作成します
+// message はクライアントに返され、err はログにのみ出力されます
+func Internal(message i18n.Message, err error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
+
+// WithRetryAfter は再試行できるまでの時間を設定したエラーを返します
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	e.RetryAfter = d
+	return e
+}
+
+// Error はログ用に既定の言語でメッセージを返します
+func (e *Error) Error() string {
+	message := i18n.T(i18n.Default, e.Message, e.Args...)
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status はエラーコードに対応するHTTPステータスを返します
+func (e *Error) Status() int {
+	if def, ok := definitions[e.Code]; ok {
+		return def.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Title はエラーコードに対応する短い説明を指定した言語で返します
+func (e *Error) Title(locale i18n.Locale) string {
+	if def, ok := definitions[e.Code]; ok {
+		return i18n.T(locale, def.title)
+	}
+	return i18n.T(locale, definitions[CodeInternal].title)
+}
+
+// Detail はメッセージを指定した言語で返します
+func (e *Error) Detail(locale i18n.Locale) string {
+	return i18n.T(locale, e.Message, e.Args...)
+}
+
+// As は err に含まれる *Error を返します
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,142 @@
+package apperror
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLのエラー番号
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlDuplicateEntry     = 1062
+	mysqlRowIsReferenced    = 1451
+	mysqlNoReferencedRow    = 1452
+	mysqlRowIsReferencedOld = 1217
+	mysqlNoReferencedRowOld = 1216
+)
+
+func init() {
+	// 検証エラーの項目名を構造体のフィールド名ではなく、クライアントが送ったJSONのキーにする
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name := strings.Split(field.Tag.Get(tag), ",")[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// From は任意のエラーをクライアントに返すエラーに変換します
+// sqlc や MySQL ドライバーのエラーは対応するコードに変換し、それ以外は内部エラーとして扱います
+func From(err error) *Error {
+	if appErr, ok := As(err); ok {
+		return appErr
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Code: CodeNotFound, Message: i18n.ResourceNotFound, Err: err}
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return &Error{Code: CodeAlreadyExists, Message: i18n.DuplicateEntry, Err: err}
+		case mysqlRowIsReferenced, mysqlRowIsReferencedOld:
+			return &Error{Code: CodeConflict, Message: i18n.RowIsReferenced, Err: err}
+		case mysqlNoReferencedRow, mysqlNoReferencedRowOld:
+			return &Error{Code: CodeConflict, Message: i18n.NoReferencedRow, Err: err}
+		}
+	}
+
+	return Internal(i18n.InternalError, err)
+}
+
+// Bind はリクエストのバインドや検証のエラーを変換します
+// 検証エラーの場合は項目ごとの詳細を含めます
+func Bind(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			message, args := validationMessage(fe)
+			fields[i] = FieldError{
+				Field:   fieldName(fe),
+				Reason:  fe.Tag(),
+				Message: message,
+				Args:    args,
+			}
+		}
+		return &Error{Code: CodeValidation, Message: i18n.ValidationFailed, Fields: fields, Err: err}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{
+			Code:    CodeValidation,
+			Message: i18n.ValidationFailed,
+			Fields:  []FieldError{{Field: typeErr.Field, Reason: "type", Message: i18n.InvalidValueType}},
+			Err:     err,
+		}
+	}
+
+	return &Error{Code: CodeInvalidRequest, Message: i18n.InvalidRequestBody, Err: err}
+}
+
+// InvalidField は1つの項目の値が不正な場合の検証エラーを作成します
+func InvalidField(field, reason string, message i18n.Message, args ...interface{}) *Error {
+	return &Error{
+		Code:    CodeValidation,
+		Message: message,
+		Args:    args,
+		Fields:  []FieldError{{Field: field, Reason: reason, Message: message, Args: args}},
+	}
+}
+
+// fieldName はネストした構造体の場合も含め、トップレベルからの項目名を返します
+func fieldName(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	// 先頭の構造体名を取り除く（例: LoginRequest.email → email）
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// validationMessage は検証ルールごとの説明のメッセージとその引数を返します
+func validationMessage(fe validator.FieldError) (i18n.Message, []interface{}) {
+	switch fe.Tag() {
+	case "required", "required_without", "required_with":
+		return i18n.FieldRequired, nil
+	case "email":
+		return i18n.FieldEmail, nil
+	case "min":
+		return i18n.FieldMin, []interface{}{fe.Param()}
+	case "max":
+		return i18n.FieldMax, []interface{}{fe.Param()}
+	case "len":
+		return i18n.FieldLen, []interface{}{fe.Param()}
+	case "numeric":
+		return i18n.FieldNumeric, nil
+	case "oneof":
+		return i18n.FieldOneOf, []interface{}{strings.Join(strings.Fields(fe.Param()), ", ")}
+	default:
+		return i18n.FieldInvalid, nil
+	}
+}
@@ -0,0 +1,92 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name           string
+		err            error
+		expectedCode   Code
+		expectedStatus int
+	}{
+		{
+			name:           "アプリケーションのエラーはそのまま返す",
+			err:            fmt.Errorf("wrapped: %w", New(CodeForbidden, i18n.Forbidden)),
+			expectedCode:   CodeForbidden,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "行が見つからない",
+			err:            sql.ErrNoRows,
+			expectedCode:   CodeNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "一意制約違反",
+			err:            &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"},
+			expectedCode:   CodeAlreadyExists,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "外部キー制約違反",
+			err:            &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row"},
+			expectedCode:   CodeConflict,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "その他のエラー",
+			err:            errors.New("connection refused"),
+			expectedCode:   CodeInternal,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr := From(tt.err)
+
+			assert.Equal(t, tt.expectedCode, appErr.Code)
+			assert.Equal(t, tt.expectedStatus, appErr.Status())
+			// 内部のエラー内容はクライアントに返すメッセージに含めない
+			assert.NotContains(t, appErr.Detail(i18n.Default), tt.err.Error())
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	type request struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8"`
+	}
+
+	t.Run("検証エラーはJSONのキーで項目ごとに返す", func(t *testing.T) {
+		err := binding.Validator.ValidateStruct(&request{Email: "invalid-email", Password: "short"})
+		appErr := Bind(err)
+
+		assert.Equal(t, CodeValidation, appErr.Code)
+		assert.Equal(t, []FieldError{
+			{Field: "email", Reason: "email", Message: i18n.FieldEmail},
+			{Field: "password", Reason: "min", Message: i18n.FieldMin, Args: []interface{}{"8"}},
+		}, appErr.Fields)
+	})
+
+	t.Run("形式の誤り", func(t *testing.T) {
+		appErr := Bind(errors.New("unexpected EOF"))
+
+		assert.Equal(t, CodeInvalidRequest, appErr.Code)
+		assert.Empty(t, appErr.Fields)
+	})
+}
@@ -1,60 +1,119 @@
 package config
 
 import (
-	"os"
+	"time"
 
 	"go-gin-sqlc/internal/util"
 )
 
+// 実行環境
+const (
+	// EnvDevelopment はローカルでの開発やテストの環境です
+	EnvDevelopment = "development"
+	// EnvProduction は本番環境です。秘密情報の設定漏れや開発用の設定を起動時に検出します
+	EnvProduction = "production"
+)
+
 // Config はアプリケーション全体の設定を保持します
 type Config struct {
-	DB      DBConfig
-	Server  *ServerConfig
-	Mail    util.MailConfig
+	// Env は実行環境です（development, production）
+	Env        string
+	DB         DBConfig
+	Server     ServerConfig
+	Mail       util.MailConfig
+	JWT        util.JWTConfig
+	LoginGuard util.LoginGuardConfig
+	Outbox     util.OutboxConfig
+	// PasswordResetTTL はパスワードリセット用のリンクの有効期間です
+	PasswordResetTTL time.Duration
+	// UserRetention は削除されたユーザーを復元できる期間です。期間を過ぎると完全に削除されます
+	UserRetention time.Duration
+	// RequireIfMatch が true の場合、ユーザーの更新・削除に If-Match ヘッダーを必須にします
+	RequireIfMatch bool
+	// BaseURL はメールに記載するリンクの基準となる、外部から見たURLです
 	BaseURL string
 }
 
 // ServerConfig はサーバーの設定を保持します
 type ServerConfig struct {
-	Port string
+	Port int
+	// TrustedProxies は X-Forwarded-For の送信元IPを信頼するプロキシのIPアドレスまたはCIDRです
+	// 空の場合はどのプロキシも信頼せず、接続元のIPアドレスをそのまま使用します
+	TrustedProxies []string
 }
 
+// DBConfig はデータベース接続と接続プールの設定を保持します
 type DBConfig struct {
 	Host     string
-	Port     string
+	Port     int
 	User     string
 	Password string
 	DBName   string
+	// MaxOpenConns は同時に開く接続の上限です。0 の場合は制限しません
+	MaxOpenConns int
+	// MaxIdleConns はプールに残すアイドル状態の接続の上限です
+	MaxIdleConns int
+	// ConnMaxLifetime は接続を再利用する期間の上限です。0 の場合は制限しません
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime はアイドル状態の接続を閉じるまでの時間です。0 の場合は制限しません
+	ConnMaxIdleTime time.Duration
+	// AutoMigrate が true の場合、サーバーの起動時に未適用のマイグレーションを適用します
+	AutoMigrate bool
 }
 
-// New は新しい設定インスタンスを作成します
-func New() *Config {
+// IsProduction は本番環境の設定かを返します
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Default は設定ファイルや環境変数で上書きする前の既定値の設定を返します
+// 接続先の認証情報や署名鍵などの秘密情報には既定値を設けず、設定漏れは Validate で検出します
+func Default() *Config {
 	return &Config{
+		Env: EnvDevelopment,
 		DB: DBConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "3306"),
-			User:     getEnv("DB_USER", "root"),
-			Password: getEnv("DB_PASSWORD", "password"),
-			DBName:   getEnv("DB_NAME", "go_gin_sqlc"),
+			Host:            "localhost",
+			Port:            3306,
+			DBName:          "go_gin_sqlc",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
 		},
-		Server: &ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+		Server: ServerConfig{
+			Port: 8080,
 		},
 		Mail: util.MailConfig{
-			Host:     getEnv("MAIL_HOST", "smtp.gmail.com"),
-			Port:     25,
-			Username: getEnv("MAIL_USERNAME", ""),
-			Password: getEnv("MAIL_PASSWORD", ""),
-			From:     getEnv("MAIL_FROM", "noreply@example.com"),
+			Transport:   util.MailTransportSMTP,
+			Host:        "smtp.gmail.com",
+			Port:        587,
+			From:        "noreply@example.com",
+			TLS:         util.MailTLSStartTLS,
+			DialTimeout: 10 * time.Second,
+			Timeout:     30 * time.Second,
+			FileDir:     "tmp/mail",
 		},
-		BaseURL: getEnv("BASE_URL", "http://localhost:8080"),
-	}
-}
-
-// getEnv は環境変数を取得し、設定されていない場合はデフォルト値を返します
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+		JWT: util.JWTConfig{
+			Algorithm: "HS256",
+		},
+		LoginGuard: util.LoginGuardConfig{
+			MaxAccountFailures: 5,
+			MaxIPFailures:      20,
+			LockDuration:       15 * time.Minute,
+			BaseDelay:          time.Second,
+			MaxDelay:           time.Minute,
+			FailureWindow:      time.Hour,
+		},
+		Outbox: util.OutboxConfig{
+			PollInterval: 5 * time.Second,
+			BatchSize:    20,
+			MaxAttempts:  8,
+			BaseDelay:    30 * time.Second,
+			MaxDelay:     time.Hour,
+			Lease:        5 * time.Minute,
+			Retention:    30 * 24 * time.Hour,
+		},
+		PasswordResetTTL: 24 * time.Hour,
+		UserRetention:    30 * 24 * time.Hour,
+		BaseURL:          "http://localhost:8080",
 	}
-	return defaultValue
 }
@@ -0,0 +1,352 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-gin-sqlc/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile はテスト用の一時ディレクトリにファイルを作成し、パスを返します
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "YAML",
+			file: "config.yaml",
+			content: `
+db:
+  host: db.internal
+  port: 3307
+  user: app
+  max_open_conns: 50
+  conn_max_lifetime: 10m
+mail:
+  transport: file
+jwt:
+  verification_keys: [old-1, old-2]
+require_if_match: true
+`,
+		},
+		{
+			name: "TOML",
+			file: "config.toml",
+			content: `
+require_if_match = true
+
+[db]
+host = "db.internal"
+port = 3307
+user = "app"
+max_open_conns = 50
+conn_max_lifetime = "10m"
+
+[mail]
+transport = "file"
+
+[jwt]
+verification_keys = ["old-1", "old-2"]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeFile(t, tt.file, tt.content))
+			require.NoError(t, err)
+
+			assert.Equal(t, "db.internal", cfg.DB.Host)
+			assert.Equal(t, 3307, cfg.DB.Port)
+			assert.Equal(t, "app", cfg.DB.User)
+			assert.Equal(t, 50, cfg.DB.MaxOpenConns)
+			assert.Equal(t, 10*time.Minute, cfg.DB.ConnMaxLifetime)
+			assert.Equal(t, util.MailTransportFile, cfg.Mail.Transport)
+			assert.Equal(t, []string{"old-1", "old-2"}, cfg.JWT.VerificationKeys)
+			assert.True(t, cfg.RequireIfMatch)
+			// 指定しなかった項目は既定値のまま
+			assert.Equal(t, "go_gin_sqlc", cfg.DB.DBName)
+			assert.Equal(t, 25, cfg.DB.MaxIdleConns)
+		})
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+db:
+  host: db.internal
+  user: app
+server:
+  port: 9090
+`)
+
+	t.Setenv("DB_HOST", "db.override")
+	t.Setenv("MAIL_DIAL_TIMEOUT", "3s")
+	// 文字列以外の項目の空の値は指定しなかったものとして扱う
+	t.Setenv("SERVER_PORT", "")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	// 環境変数は設定ファイルより優先する
+	assert.Equal(t, "db.override", cfg.DB.Host)
+	assert.Equal(t, "app", cfg.DB.User)
+	assert.Equal(t, 3*time.Second, cfg.Mail.DialTimeout)
+	assert.Equal(t, 9090, cfg.Server.Port)
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	t.Run("環境変数で指定したファイル", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "db-secret\n"))
+		t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt_secret", "jwt-secret\r\n"))
+
+		cfg, err := Load("")
+		require.NoError(t, err)
+		assert.Equal(t, "db-secret", cfg.DB.Password)
+		assert.Equal(t, "jwt-secret", cfg.JWT.Secret)
+	})
+
+	t.Run("設定ファイルで指定したファイル", func(t *testing.T) {
+		secret := writeFile(t, "mail_password", "mail-secret\n")
+		cfg, err := Load(writeFile(t, "config.yaml", "mail:\n  password_file: "+secret+"\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "mail-secret", cfg.Mail.Password)
+	})
+
+	t.Run("値とファイルを同時に指定", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "db-secret")
+		t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "db-secret\n"))
+
+		_, err := Load("")
+		assert.ErrorContains(t, err, "環境変数 DB_PASSWORD_FILE: 環境変数 DB_PASSWORD と同時に指定できません")
+	})
+
+	t.Run("ファイルが存在しない", func(t *testing.T) {
+		t.Setenv("JWT_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, err := Load("")
+		assert.ErrorContains(t, err, "環境変数 JWT_SECRET_FILE: 秘密情報のファイルを読み込めません")
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		env         map[string]string
+		expectedErr []string
+	}{
+		{
+			name:        "設定ファイルの不明な項目",
+			file:        "config.yaml",
+			content:     "db:\n  hots: db.internal\nserver:\n  prot: 8080\n",
+			expectedErr: []string{"設定ファイルの db.hots: 不明な設定項目です", "設定ファイルの server.prot: 不明な設定項目です"},
+		},
+		{
+			name:        "パスには _FILE を使用できない",
+			file:        "config.yaml",
+			content:     "jwt:\n  private_key_file_file: /run/secrets/key\n",
+			expectedErr: []string{"設定ファイルの jwt.private_key_file_file: 不明な設定項目です"},
+		},
+		{
+			name:        "整数の形式が不正",
+			env:         map[string]string{"DB_PORT": "mysql"},
+			expectedErr: []string{"環境変数 DB_PORT: 整数で指定してください"},
+		},
+		{
+			name:        "時間の形式が不正",
+			file:        "config.toml",
+			content:     "password_reset_ttl = \"1day\"\n",
+			expectedErr: []string{"設定ファイルの password_reset_ttl: 30s, 15m のような時間で指定してください"},
+		},
+		{
+			name:        "真偽値の形式が不正",
+			env:         map[string]string{"REQUIRE_IF_MATCH": "yes"},
+			expectedErr: []string{"環境変数 REQUIRE_IF_MATCH: true または false で指定してください"},
+		},
+		{
+			name:        "対応していない拡張子",
+			file:        "config.json",
+			content:     "{}",
+			expectedErr: []string{"対応していない形式です"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			var path string
+			if tt.file != "" {
+				path = writeFile(t, tt.file, tt.content)
+			}
+
+			cfg, err := Load(path)
+			assert.Nil(t, cfg)
+			for _, expected := range tt.expectedErr {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+// validConfig は検証を通過する本番環境の設定を返します
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Env = EnvProduction
+	cfg.DB.User = "app"
+	cfg.DB.Password = "db-secret"
+	cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+	cfg.BaseURL = "https://api.example.com"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name        string
+		modify      func(cfg *Config)
+		expectedErr []string
+	}{
+		{
+			name:   "有効な本番環境の設定",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name: "開発環境ではシークレットを省略できる",
+			modify: func(cfg *Config) {
+				cfg.Env = EnvDevelopment
+				cfg.DB.Password = ""
+				cfg.JWT.Secret = ""
+				cfg.Mail.Transport = util.MailTransportDevInbox
+				cfg.BaseURL = "http://localhost:8080"
+			},
+		},
+		{
+			name: "本番環境で秘密情報を省略",
+			modify: func(cfg *Config) {
+				cfg.DB.Password = ""
+				cfg.JWT.Secret = "short"
+			},
+			expectedErr: []string{
+				"本番環境では DB_PASSWORD を指定してください",
+				"本番環境では JWT_SECRET に32バイト以上のランダムな値を指定してください",
+			},
+		},
+		{
+			name: "本番環境で開発用の設定",
+			modify: func(cfg *Config) {
+				cfg.Mail.Transport = util.MailTransportDevInbox
+				cfg.BaseURL = "http://api.example.com"
+			},
+			expectedErr: []string{
+				"本番環境では MAIL_TRANSPORT に devinbox を使用できません",
+				"本番環境では BASE_URL に https のURLを指定してください",
+			},
+		},
+		{
+			name: "本番環境でも署名に秘密鍵を使う場合はシークレットが不要",
+			modify: func(cfg *Config) {
+				cfg.JWT.Algorithm = "RS256"
+				cfg.JWT.Secret = ""
+				cfg.JWT.PrivateKeyFile = "/run/secrets/jwt.pem"
+			},
+		},
+		{
+			name: "秘密鍵を省略",
+			modify: func(cfg *Config) {
+				cfg.JWT.Algorithm = "ES256"
+			},
+			expectedErr: []string{"JWT_PRIVATE_KEY または JWT_PRIVATE_KEY_FILE を指定してください"},
+		},
+		{
+			name: "値の範囲が不正",
+			modify: func(cfg *Config) {
+				cfg.Env = "staging"
+				cfg.DB.User = ""
+				cfg.DB.Port = 0
+				cfg.DB.MaxOpenConns = 10
+				cfg.DB.MaxIdleConns = 20
+				cfg.Server.Port = 70000
+				cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy.local"}
+				cfg.Mail.TLS = "ssl"
+				cfg.Outbox.BatchSize = 0
+				cfg.PasswordResetTTL = 0
+				cfg.BaseURL = "/reset"
+			},
+			expectedErr: []string{
+				"APP_ENV は development または production で指定してください",
+				"DB_USER を指定してください",
+				"DB_PORT は1から65535の範囲で指定してください",
+				"DB_MAX_IDLE_CONNS は0以上、DB_MAX_OPEN_CONNS 以下で指定してください",
+				"SERVER_PORT は1から65535の範囲で指定してください",
+				`SERVER_TRUSTED_PROXIES はIPアドレスまたはCIDRのカンマ区切りで指定してください: "proxy.local"`,
+				"MAIL_TLS は starttls, tls, none のいずれかで指定してください",
+				"MAIL_OUTBOX_BATCH_SIZE は1以上で指定してください",
+				"PASSWORD_RESET_TTL は0より大きい時間で指定してください",
+				"BASE_URL は http または https の絶対URLで指定してください",
+			},
+		},
+		{
+			name: "SMTP の認証情報の片方だけを指定",
+			modify: func(cfg *Config) {
+				cfg.Mail.Username = "mailer"
+			},
+			expectedErr: []string{"MAIL_USERNAME を指定した場合は MAIL_PASSWORD も指定してください"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.expectedErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, expected := range tt.expectedErr {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestWriteRedacted(t *testing.T) {
+	cfg := validConfig()
+	cfg.JWT.VerificationKeys = []string{"old-1", "old-2"}
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.WriteRedacted(&out))
+
+	assert.Contains(t, out.String(), "APP_ENV=production\n")
+	assert.Contains(t, out.String(), "DB_PORT=3306\n")
+	assert.Contains(t, out.String(), "DB_CONN_MAX_LIFETIME=5m0s\n")
+	assert.Contains(t, out.String(), "JWT_VERIFICATION_KEYS=old-1,old-2\n")
+	assert.Contains(t, out.String(), "DB_PASSWORD=[REDACTED]\n")
+	assert.Contains(t, out.String(), "JWT_SECRET=[REDACTED]\n")
+	// 設定されていない秘密情報は、設定漏れに気付けるよう空のまま書き出す
+	assert.Contains(t, out.String(), "MAIL_PASSWORD=\n")
+	assert.NotContains(t, out.String(), "db-secret")
+	assert.NotContains(t, out.String(), cfg.JWT.Secret)
+}
@@ -0,0 +1,390 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted は表示する設定で秘密情報の代わりに使用する文字列です
+const redacted = "[REDACTED]"
+
+// fileSuffix は秘密情報をファイルから読み込む場合に、設定項目の名前に付ける接尾辞です
+const fileSuffix = "_FILE"
+
+// binding は1つの設定項目と、値を格納する Config のフィールドの対応です
+type binding struct {
+	// key は環境変数の名前です。設定ファイルでは小文字にして、_ の位置で入れ子にできます（db.host）
+	key string
+	// target は値を格納するフィールドへのポインタです（*string, *int, *bool, *time.Duration, *[]string）
+	target interface{}
+	// secret が true の場合、表示する際に値を伏せます
+	secret bool
+	// fromFile が true の場合、KEY_FILE に指定したファイルの内容を値として読み込めます
+	fromFile bool
+}
+
+// bindings は全ての設定項目を返します。読み込みと表示はこの一覧だけを使用します
+func (c *Config) bindings() []binding {
+	return []binding{
+		{key: "APP_ENV", target: &c.Env},
+
+		{key: "DB_HOST", target: &c.DB.Host},
+		{key: "DB_PORT", target: &c.DB.Port},
+		{key: "DB_USER", target: &c.DB.User},
+		{key: "DB_PASSWORD", target: &c.DB.Password, secret: true, fromFile: true},
+		{key: "DB_NAME", target: &c.DB.DBName},
+		{key: "DB_MAX_OPEN_CONNS", target: &c.DB.MaxOpenConns},
+		{key: "DB_MAX_IDLE_CONNS", target: &c.DB.MaxIdleConns},
+		{key: "DB_CONN_MAX_LIFETIME", target: &c.DB.ConnMaxLifetime},
+		{key: "DB_CONN_MAX_IDLE_TIME", target: &c.DB.ConnMaxIdleTime},
+		{key: "DB_AUTO_MIGRATE", target: &c.DB.AutoMigrate},
+
+		{key: "SERVER_PORT", target: &c.Server.Port},
+		{key: "SERVER_TRUSTED_PROXIES", target: &c.Server.TrustedProxies},
+
+		{key: "MAIL_TRANSPORT", target: &c.Mail.Transport},
+		{key: "MAIL_HOST", target: &c.Mail.Host},
+		{key: "MAIL_PORT", target: &c.Mail.Port},
+		{key: "MAIL_USERNAME", target: &c.Mail.Username},
+		{key: "MAIL_PASSWORD", target: &c.Mail.Password, secret: true, fromFile: true},
+		{key: "MAIL_FROM", target: &c.Mail.From},
+		{key: "MAIL_TLS", target: &c.Mail.TLS},
+		{key: "MAIL_DIAL_TIMEOUT", target: &c.Mail.DialTimeout},
+		{key: "MAIL_TIMEOUT", target: &c.Mail.Timeout},
+		{key: "MAIL_FILE_DIR", target: &c.Mail.FileDir},
+		{key: "MAIL_TEMPLATE_DIR", target: &c.Mail.TemplateDir},
+
+		{key: "MAIL_OUTBOX_POLL_INTERVAL", target: &c.Outbox.PollInterval},
+		{key: "MAIL_OUTBOX_BATCH_SIZE", target: &c.Outbox.BatchSize},
+		{key: "MAIL_OUTBOX_MAX_ATTEMPTS", target: &c.Outbox.MaxAttempts},
+		{key: "MAIL_OUTBOX_BASE_DELAY", target: &c.Outbox.BaseDelay},
+		{key: "MAIL_OUTBOX_MAX_DELAY", target: &c.Outbox.MaxDelay},
+		{key: "MAIL_OUTBOX_LEASE", target: &c.Outbox.Lease},
+		{key: "MAIL_OUTBOX_RETENTION", target: &c.Outbox.Retention},
+
+		{key: "JWT_ALGORITHM", target: &c.JWT.Algorithm},
+		{key: "JWT_KEY_ID", target: &c.JWT.KeyID},
+		{key: "JWT_SECRET", target: &c.JWT.Secret, secret: true, fromFile: true},
+		// 秘密鍵のファイルは署名鍵の読み込み時に読むため、JWT_PRIVATE_KEY_FILE はパスとして扱う
+		{key: "JWT_PRIVATE_KEY", target: &c.JWT.PrivateKey, secret: true},
+		{key: "JWT_PRIVATE_KEY_FILE", target: &c.JWT.PrivateKeyFile},
+		{key: "JWT_VERIFICATION_KEYS", target: &c.JWT.VerificationKeys},
+
+		{key: "LOGIN_MAX_ACCOUNT_FAILURES", target: &c.LoginGuard.MaxAccountFailures},
+		{key: "LOGIN_MAX_IP_FAILURES", target: &c.LoginGuard.MaxIPFailures},
+		{key: "LOGIN_LOCK_DURATION", target: &c.LoginGuard.LockDuration},
+		{key: "LOGIN_BASE_DELAY", target: &c.LoginGuard.BaseDelay},
+		{key: "LOGIN_MAX_DELAY", target: &c.LoginGuard.MaxDelay},
+		{key: "LOGIN_FAILURE_WINDOW", target: &c.LoginGuard.FailureWindow},
+
+		{key: "PASSWORD_RESET_TTL", target: &c.PasswordResetTTL},
+		{key: "USER_RETENTION", target: &c.UserRetention},
+		{key: "REQUIRE_IF_MATCH", target: &c.RequireIfMatch},
+		{key: "BASE_URL", target: &c.BaseURL},
+	}
+}
+
+// Load は既定値に設定ファイル、環境変数の順で上書きした設定を返します
+// path が空の場合は設定ファイルを使用しません。値の形式の誤りや設定ファイルの不明な項目はまとめてエラーとして返します
+// 値の組み合わせや必須の項目は確認しないため、使用する前に Validate で検証してください
+func Load(path string) (*Config, error) {
+	l := &loader{}
+	if path != "" {
+		file, err := readConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("設定ファイル %s を読み込めません: %w", path, err)
+		}
+		l.file = file
+	}
+
+	cfg := Default()
+	bindings := cfg.bindings()
+	for _, b := range bindings {
+		l.apply(b)
+	}
+	l.checkUnknownKeys(bindings)
+
+	if err := errors.Join(l.errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// WriteRedacted は全ての設定項目を環境変数の形式（KEY=value）で書き出します
+// 秘密情報は値を伏せ、設定されていない場合は設定漏れに気付けるよう空のまま書き出します
+func (c *Config) WriteRedacted(w io.Writer) error {
+	for _, b := range c.bindings() {
+		value := formatValue(b.target)
+		if b.secret && value != "" {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", b.key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileValue は設定ファイルから読み込んだ1つの値です
+type fileValue struct {
+	// path は設定ファイルでのキーです（db.host）
+	path  string
+	value string
+}
+
+// loader は設定ファイルと環境変数から設定項目の値を読み込みます
+type loader struct {
+	// file は設定項目の名前ごとの設定ファイルの値です
+	file map[string]fileValue
+	errs []error
+}
+
+// apply は設定項目の値を読み込み、フィールドに格納します
+func (l *loader) apply(b binding) {
+	value, source, err := l.lookup(b)
+	if err != nil {
+		l.errs = append(l.errs, err)
+		return
+	}
+	if source == "" {
+		return
+	}
+	if err := parseValue(b.target, value); err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", source, err))
+	}
+}
+
+// lookup は設定項目の値と、値を読み込んだ場所を返します。環境変数は設定ファイルより優先します
+// 値が指定されていない場合、読み込んだ場所は空になります
+// 文字列以外の設定項目に空の値を指定した場合は、指定しなかったものとして扱います
+func (l *loader) lookup(b binding) (string, string, error) {
+	value, source, err := l.lookupFromEnv(b)
+	if err != nil {
+		return "", "", err
+	}
+	if source == "" || isBlank(b, value) {
+		value, source, err = l.lookupFile(b)
+		if err != nil || isBlank(b, value) {
+			return "", "", err
+		}
+	}
+	return value, source, nil
+}
+
+// isBlank は文字列以外の設定項目の値が空かを判定します
+func isBlank(b binding, value string) bool {
+	_, isString := b.target.(*string)
+	return !isString && strings.TrimSpace(value) == ""
+}
+
+// lookupFromEnv は環境変数 KEY または KEY_FILE から値を読み込みます
+func (l *loader) lookupFromEnv(b binding) (string, string, error) {
+	value, ok := os.LookupEnv(b.key)
+	if b.fromFile {
+		if path, fileOK := os.LookupEnv(b.key + fileSuffix); fileOK {
+			source := "環境変数 " + b.key + fileSuffix
+			if ok {
+				return "", "", fmt.Errorf("%s: 環境変数 %s と同時に指定できません", source, b.key)
+			}
+			value, err := readSecretFile(path)
+			if err != nil {
+				return "", "", fmt.Errorf("%s: %w", source, err)
+			}
+			return value, source, nil
+		}
+	}
+	if !ok {
+		return "", "", nil
+	}
+	return value, "環境変数 " + b.key, nil
+}
+
+// lookupFile は設定ファイルの項目または項目名に _file を付けた項目（db.password_file）から値を読み込みます
+func (l *loader) lookupFile(b binding) (string, string, error) {
+	v, ok := l.file[b.key]
+	if b.fromFile {
+		if fv, fileOK := l.file[b.key+fileSuffix]; fileOK {
+			source := "設定ファイルの " + fv.path
+			if ok {
+				return "", "", fmt.Errorf("%s: %s と同時に指定できません", source, v.path)
+			}
+			value, err := readSecretFile(fv.value)
+			if err != nil {
+				return "", "", fmt.Errorf("%s: %w", source, err)
+			}
+			return value, source, nil
+		}
+	}
+	if !ok {
+		return "", "", nil
+	}
+	return v.value, "設定ファイルの " + v.path, nil
+}
+
+// checkUnknownKeys は設定ファイルに、どの設定項目にも対応しないキーがないかを確認します
+// キーの綴りの誤りで既定値のまま起動しないよう、エラーとして扱います
+func (l *loader) checkUnknownKeys(bindings []binding) {
+	known := make(map[string]bool, len(bindings))
+	for _, b := range bindings {
+		known[b.key] = true
+		if b.fromFile {
+			known[b.key+fileSuffix] = true
+		}
+	}
+
+	var unknown []string
+	for key, v := range l.file {
+		if !known[key] {
+			unknown = append(unknown, v.path)
+		}
+	}
+	sort.Strings(unknown)
+	for _, path := range unknown {
+		l.errs = append(l.errs, fmt.Errorf("設定ファイルの %s: 不明な設定項目です", path))
+	}
+}
+
+// readSecretFile は秘密情報のファイルを読み込みます。ファイル末尾の改行は値に含めません
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("秘密情報のファイルを読み込めません: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readConfigFile はYAMLまたはTOMLの設定ファイルを読み込み、設定項目の名前ごとの値を返します
+// 形式は拡張子（.yaml, .yml, .toml）で判断します
+func readConfigFile(path string) (map[string]fileValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("対応していない形式です（.yaml, .yml, .toml のいずれかを使用してください）")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]fileValue)
+	if err := flattenConfig(values, "", raw); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flattenConfig は入れ子になった設定ファイルの値を、設定項目の名前（DB_HOST）ごとの値に変換します
+// 配列はカンマ区切りの文字列にします
+func flattenConfig(values map[string]fileValue, prefix string, raw map[string]interface{}) error {
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		var value string
+		switch v := raw[k].(type) {
+		case map[string]interface{}:
+			if err := flattenConfig(values, path, v); err != nil {
+				return err
+			}
+			continue
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, ",")
+		case nil:
+			value = ""
+		default:
+			value = fmt.Sprint(v)
+		}
+
+		key := strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+		if existing, ok := values[key]; ok {
+			return fmt.Errorf("%s と %s は同じ設定項目です", existing.path, path)
+		}
+		values[key] = fileValue{path: path, value: value}
+	}
+	return nil
+}
+
+// parseValue は文字列の値をフィールドの型に変換して格納します
+func parseValue(target interface{}, value string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = value
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("整数で指定してください: %q", value)
+		}
+		*t = n
+	case *bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("true または false で指定してください: %q", value)
+		}
+		*t = b
+	case *time.Duration:
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("30s, 15m のような時間で指定してください: %q", value)
+		}
+		*t = d
+	case *[]string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*t = items
+	default:
+		panic(fmt.Sprintf("config: 対応していない設定項目の型です: %T", target))
+	}
+	return nil
+}
+
+// formatValue はフィールドの値を設定項目の形式の文字列に変換します
+func formatValue(target interface{}) string {
+	switch t := target.(type) {
+	case *string:
+		return *t
+	case *int:
+		return strconv.Itoa(*t)
+	case *bool:
+		return strconv.FormatBool(*t)
+	case *time.Duration:
+		return t.String()
+	case *[]string:
+		return strings.Join(*t, ",")
+	default:
+		panic(fmt.Sprintf("config: 対応していない設定項目の型です: %T", target))
+	}
+}
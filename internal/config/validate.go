@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"go-gin-sqlc/internal/util"
+)
+
+// minProductionSecretLength は本番環境で JWT_SECRET に求めるバイト数です
+// HS256 の署名鍵はハッシュの出力と同じ32バイト以上が推奨されています
+const minProductionSecretLength = 32
+
+// Validate は設定の値と組み合わせを検証し、全ての問題をまとめたエラーを返します
+// 本番環境では秘密情報の設定漏れや、開発用の設定が残っていないかも確認します
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.check(c.Env == EnvDevelopment || c.Env == EnvProduction,
+		"APP_ENV は %s または %s で指定してください: %q", EnvDevelopment, EnvProduction, c.Env)
+
+	// データベース
+	v.check(c.DB.Host != "", "DB_HOST を指定してください")
+	v.checkPort("DB_PORT", c.DB.Port)
+	v.check(c.DB.User != "", "DB_USER を指定してください")
+	v.check(c.DB.DBName != "", "DB_NAME を指定してください")
+	v.check(c.DB.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS は0以上で指定してください: %d", c.DB.MaxOpenConns)
+	v.check(c.DB.MaxIdleConns >= 0 && (c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns),
+		"DB_MAX_IDLE_CONNS は0以上、DB_MAX_OPEN_CONNS 以下で指定してください: %d", c.DB.MaxIdleConns)
+	v.check(c.DB.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME は0以上で指定してください: %s", c.DB.ConnMaxLifetime)
+	v.check(c.DB.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME は0以上で指定してください: %s", c.DB.ConnMaxIdleTime)
+
+	v.checkPort("SERVER_PORT", c.Server.Port)
+	for _, proxy := range c.Server.TrustedProxies {
+		v.check(isIPOrCIDR(proxy), "SERVER_TRUSTED_PROXIES はIPアドレスまたはCIDRのカンマ区切りで指定してください: %q", proxy)
+	}
+
+	// メール
+	switch c.Mail.Transport {
+	case util.MailTransportSMTP:
+		v.check(c.Mail.Host != "", "MAIL_HOST を指定してください")
+		v.checkPort("MAIL_PORT", c.Mail.Port)
+		v.check(c.Mail.TLS == util.MailTLSStartTLS || c.Mail.TLS == util.MailTLSImplicit || c.Mail.TLS == util.MailTLSNone,
+			"MAIL_TLS は %s, %s, %s のいずれかで指定してください: %q", util.MailTLSStartTLS, util.MailTLSImplicit, util.MailTLSNone, c.Mail.TLS)
+		v.check(c.Mail.Username == "" || c.Mail.Password != "", "MAIL_USERNAME を指定した場合は MAIL_PASSWORD も指定してください")
+	case util.MailTransportFile:
+		v.check(c.Mail.FileDir != "", "MAIL_FILE_DIR を指定してください")
+	case util.MailTransportDevInbox:
+	default:
+		v.check(false, "MAIL_TRANSPORT は %s, %s, %s のいずれかで指定してください: %q",
+			util.MailTransportSMTP, util.MailTransportFile, util.MailTransportDevInbox, c.Mail.Transport)
+	}
+	v.check(c.Mail.From != "", "MAIL_FROM を指定してください")
+	v.checkPositive("MAIL_DIAL_TIMEOUT", c.Mail.DialTimeout)
+	v.checkPositive("MAIL_TIMEOUT", c.Mail.Timeout)
+
+	// 送信待ちのメールの配信
+	v.checkPositive("MAIL_OUTBOX_POLL_INTERVAL", c.Outbox.PollInterval)
+	v.check(c.Outbox.BatchSize > 0, "MAIL_OUTBOX_BATCH_SIZE は1以上で指定してください: %d", c.Outbox.BatchSize)
+	v.check(c.Outbox.MaxAttempts > 0, "MAIL_OUTBOX_MAX_ATTEMPTS は1以上で指定してください: %d", c.Outbox.MaxAttempts)
+	v.checkPositive("MAIL_OUTBOX_BASE_DELAY", c.Outbox.BaseDelay)
+	v.check(c.Outbox.MaxDelay >= c.Outbox.BaseDelay, "MAIL_OUTBOX_MAX_DELAY は MAIL_OUTBOX_BASE_DELAY 以上で指定してください: %s", c.Outbox.MaxDelay)
+	v.checkPositive("MAIL_OUTBOX_LEASE", c.Outbox.Lease)
+	v.checkPositive("MAIL_OUTBOX_RETENTION", c.Outbox.Retention)
+
+	// JWT署名鍵。アルゴリズムと鍵の形式は署名鍵の読み込み時に確認する
+	// HS256 のシークレットは開発環境では省略でき、起動ごとにランダムな値を使用する
+	if c.JWT.Algorithm != "HS256" {
+		v.check(c.JWT.PrivateKey != "" || c.JWT.PrivateKeyFile != "", "JWT_PRIVATE_KEY または JWT_PRIVATE_KEY_FILE を指定してください")
+	}
+
+	// ログイン試行の制限
+	v.check(c.LoginGuard.MaxAccountFailures >= 0, "LOGIN_MAX_ACCOUNT_FAILURES は0以上で指定してください: %d", c.LoginGuard.MaxAccountFailures)
+	v.check(c.LoginGuard.MaxIPFailures >= 0, "LOGIN_MAX_IP_FAILURES は0以上で指定してください: %d", c.LoginGuard.MaxIPFailures)
+	v.checkPositive("LOGIN_LOCK_DURATION", c.LoginGuard.LockDuration)
+	v.check(c.LoginGuard.BaseDelay >= 0, "LOGIN_BASE_DELAY は0以上で指定してください: %s", c.LoginGuard.BaseDelay)
+	v.check(c.LoginGuard.MaxDelay >= c.LoginGuard.BaseDelay, "LOGIN_MAX_DELAY は LOGIN_BASE_DELAY 以上で指定してください: %s", c.LoginGuard.MaxDelay)
+	v.checkPositive("LOGIN_FAILURE_WINDOW", c.LoginGuard.FailureWindow)
+
+	v.checkPositive("PASSWORD_RESET_TTL", c.PasswordResetTTL)
+	v.checkPositive("USER_RETENTION", c.UserRetention)
+
+	baseURL, err := url.Parse(c.BaseURL)
+	validBaseURL := err == nil && (baseURL.Scheme == "http" || baseURL.Scheme == "https") && baseURL.Host != ""
+	v.check(validBaseURL, "BASE_URL は http または https の絶対URLで指定してください: %q", c.BaseURL)
+
+	if c.IsProduction() {
+		v.check(c.DB.Password != "", "本番環境では DB_PASSWORD を指定してください（DB_PASSWORD_FILE でファイルからも読み込めます）")
+		if c.JWT.Algorithm == "HS256" {
+			v.check(len(c.JWT.Secret) >= minProductionSecretLength,
+				"本番環境では JWT_SECRET に%dバイト以上のランダムな値を指定してください（JWT_SECRET_FILE でファイルからも読み込めます）", minProductionSecretLength)
+		}
+		// 開発用の受信箱は、受け取ったメールを認証なしで /dev/mail に公開する
+		v.check(c.Mail.Transport != util.MailTransportDevInbox, "本番環境では MAIL_TRANSPORT に %s を使用できません", util.MailTransportDevInbox)
+		// メールに記載するパスワードリセットなどのリンクを平文で送らせない
+		v.check(!validBaseURL || baseURL.Scheme == "https", "本番環境では BASE_URL に https のURLを指定してください: %q", c.BaseURL)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// validator は検証で見つかった問題を集めます
+type validator struct {
+	errs []error
+}
+
+// check は ok が false の場合に問題として記録します
+func (v *validator) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf(format, args...))
+	}
+}
+
+// checkPort はポート番号が有効な範囲かを確認します
+func (v *validator) checkPort(key string, port int) {
+	v.check(port > 0 && port <= 65535, "%s は1から65535の範囲で指定してください: %d", key, port)
+}
+
+// checkPositive は時間が正の値かを確認します
+func (v *validator) checkPositive(key string, d time.Duration) {
+	v.check(d > 0, "%s は0より大きい時間で指定してください: %s", key, d)
+}
+
+// isIPOrCIDR は値がIPアドレスまたはCIDRかを判定します
+func isIPOrCIDR(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
@@ -1,23 +1,28 @@
 package handler
 
 import (
-	"database/sql"
+	"io"
 	"net/http"
 
 	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
 	"go-gin-sqlc/internal/util"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	queries db.Querier
+	auth        *service.AuthService
+	revocations util.RevocationStore
 }
 
-func NewAuthHandler(sqlDB *sql.DB) *AuthHandler {
+func NewAuthHandler(auth *service.AuthService, revocations util.RevocationStore) *AuthHandler {
 	return &AuthHandler{
-		queries: db.New(sqlDB),
+		auth:        auth,
+		revocations: revocations,
 	}
 }
 
@@ -26,9 +31,16 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// TokenResponse はアクセストークンとリフレッシュトークンの組です
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  struct {
+	TokenResponse
+	User struct {
 		ID        int64  `json:"id"`
 		Email     string `json:"email"`
 		FirstName string `json:"first_name"`
@@ -36,11 +48,53 @@ type LoginResponse struct {
 	} `json:"user"`
 }
 
+// MFAChallengeResponse は二要素認証が必要な場合のログインレスポンスです
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type LoginMFARequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
 type RegisterRequest struct {
 	Email     string `json:"email" binding:"required,email"`
 	Password  string `json:"password" binding:"required,min=8"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
+	// Locale はメールの言語の設定です。省略した場合はリクエストの Accept-Language に従います
+	Locale string `json:"locale" binding:"omitempty,oneof=ja en"`
+}
+
+type RegisterResponse struct {
+	Message string `json:"message"`
+	User    struct {
+		ID        int64  `json:"id"`
+		Email     string `json:"email"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		Status    string `json:"status"`
+	} `json:"user"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // RegisterRoutes は認証関連のルートを登録します
@@ -48,7 +102,14 @@ func (h *AuthHandler) RegisterRoutes(r gin.IRouter) {
 	auth := r.Group("/auth")
 	{
 		auth.POST("/login", h.Login)
+		auth.POST("/login/mfa", h.LoginMFA)
 		auth.POST("/register", h.Register)
+		auth.GET("/verify-email", h.VerifyEmail)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/verify-email/resend", h.ResendVerificationEmail)
+		auth.POST("/refresh", h.Refresh)
+		auth.POST("/logout", middleware.AuthRequired(h.revocations), h.Logout)
+		auth.POST("/logout-all", middleware.AuthRequired(h.revocations), h.LogoutAll)
 	}
 }
 
@@ -56,112 +117,199 @@ func (h *AuthHandler) RegisterRoutes(r gin.IRouter) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// メールアドレスでユーザーを検索
-	user, err := h.queries.GetUserByEmail(c, req.Email)
+	result, err := h.auth.Login(c, service.LoginInput{
+		Email:    req.Email,
+		Password: req.Password,
+		ClientIP: c.ClientIP(),
+		Locale:   middleware.RequestLocale(c),
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "メールアドレスまたはパスワードが正しくありません"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// ユーザーステータスの確認
-	if !user.Status.Valid || user.Status.UsersStatus != db.UsersStatusActive {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "このアカウントは無効です"})
+	// 二要素認証が有効な場合は、認証コードの入力を求める
+	if result.Tokens == nil {
+		c.JSON(http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			ExpiresIn:   int64(util.MFATokenExpiration.Seconds()),
+		})
 		return
 	}
 
-	// パスワードの検証
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "メールアドレスまたはパスワードが正しくありません"})
+	c.JSON(http.StatusOK, newLoginResponse(*result.Tokens, result.User))
+}
+
+// LoginMFA は二要素認証待ちのトークンと認証コード（またはリカバリーコード）を検証し、トークンを発行します
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// JWTトークンの生成
-	token, err := util.GenerateToken(user.ID)
+	user, tokens, err := h.auth.LoginMFA(c, service.LoginMFAInput{
+		MFAToken:     req.MFAToken,
+		Code:         req.Code,
+		RecoveryCode: req.RecoveryCode,
+		ClientIP:     c.ClientIP(),
+		Locale:       middleware.RequestLocale(c),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの生成に失敗しました"})
+		c.Error(err)
 		return
 	}
 
-	// レスポンスの作成
+	c.JSON(http.StatusOK, newLoginResponse(tokens, user))
+}
+
+// newTokenResponse はサービスが発行したトークンをレスポンス用の構造体に変換します
+func newTokenResponse(tokens service.Tokens) TokenResponse {
+	return TokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+	}
+}
+
+// newLoginResponse はログイン成功時のレスポンスを作成します
+func newLoginResponse(tokens service.Tokens, user db.User) LoginResponse {
 	response := LoginResponse{
-		Token: token,
+		TokenResponse: newTokenResponse(tokens),
 	}
 	response.User.ID = user.ID
 	response.User.Email = user.Email
 	response.User.FirstName = user.FirstName
 	response.User.LastName = user.LastName
-
-	c.JSON(http.StatusOK, response)
+	return response
 }
 
 // Register はユーザー登録を処理します
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// メールアドレスの重複チェック
-	_, err := h.queries.GetUserByEmail(c, req.Email)
-	if err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "このメールアドレスは既に登録されています"})
-		return
-	} else if err != sql.ErrNoRows {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	userID, err := h.auth.Register(c, service.RegisterInput{
+		Email:         req.Email,
+		Password:      req.Password,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Locale:        req.Locale,
+		RequestLocale: middleware.RequestLocale(c),
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// パスワードのハッシュ化
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// レスポンスの作成
+	response := RegisterResponse{
+		Message: localize(c, i18n.RegistrationEmailSent),
+	}
+	response.User.ID = userID
+	response.User.Email = req.Email
+	response.User.FirstName = req.FirstName
+	response.User.LastName = req.LastName
+	response.User.Status = string(db.UsersStatusPendingVerification)
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// VerifyEmail はメールアドレス確認トークンを検証し、アカウントを有効にします
+// メール内のリンクから直接開けるよう、GETのクエリパラメータとPOSTのJSONの両方を受け付けます
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	var err error
+	if c.Request.Method == http.MethodGet {
+		err = c.ShouldBindQuery(&req)
+	} else {
+		err = c.ShouldBindJSON(&req)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードのハッシュ化に失敗しました"})
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// ユーザーの作成
-	result, err := h.queries.CreateUser(c, db.CreateUserParams{
-		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
-	})
+	alreadyVerified, err := h.auth.VerifyEmail(c, req.Token)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+	if alreadyVerified {
+		c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.EmailAlreadyVerified)})
 		return
 	}
 
-	// 作成されたユーザーのIDを取得
-	userID, err := result.LastInsertId()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.EmailVerified)})
+}
+
+// ResendVerificationEmail は確認メールを再送します
+// アカウントの存在を推測されないよう、確認待ちのユーザーがいない場合も成功レスポンスを返します
+func (h *AuthHandler) ResendVerificationEmail(c *gin.Context) {
+	var req ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	if err := h.auth.ResendVerification(c, req.Email, middleware.RequestLocale(c)); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// JWTトークンの生成
-	token, err := util.GenerateToken(userID)
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.VerificationEmailSent)})
+}
+
+// Refresh はリフレッシュトークンをローテーションし、新しいトークンの組を発行します
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	tokens, err := h.auth.Refresh(c, req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの生成に失敗しました"})
+		c.Error(err)
 		return
 	}
 
-	// レスポンスの作成
-	response := LoginResponse{
-		Token: token,
+	c.JSON(http.StatusOK, newTokenResponse(tokens))
+}
+
+// Logout は現在のアクセストークンと、指定されたリフレッシュトークンのファミリーを失効させます
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.Error(apperror.Bind(err))
+		return
 	}
-	response.User.ID = userID
-	response.User.Email = req.Email
-	response.User.FirstName = req.FirstName
-	response.User.LastName = req.LastName
 
-	c.JSON(http.StatusCreated, response)
+	claims := c.MustGet("claims").(*util.Claims)
+	if err := h.auth.Logout(c, claims, req.RefreshToken); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.LoggedOut)})
+}
+
+// LogoutAll はユーザーの全てのアクセストークンとリフレッシュトークンを失効させます
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	claims := c.MustGet("claims").(*util.Claims)
+
+	if err := h.auth.LogoutAll(c, claims.UserID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.LoggedOutAll)})
 }
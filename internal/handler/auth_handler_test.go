@@ -7,10 +7,17 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +25,16 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// TestMain はテストで発行・検証するトークンの署名鍵を設定してからテストを実行します
+func TestMain(m *testing.M) {
+	keys, err := util.NewKeySet(util.JWTConfig{Algorithm: "HS256", Secret: "test-secret"})
+	if err != nil {
+		panic(err)
+	}
+	util.SetKeySet(keys)
+	os.Exit(m.Run())
+}
+
 // MockQueries はデータベースクエリのモックです
 type MockQueries struct {
 	mock.Mock
@@ -42,9 +59,9 @@ func (m *MockQueries) GetUser(ctx context.Context, id int64) (db.User, error) {
 }
 
 // DeleteUser はdb.Queriesインターフェースの実装です
-func (m *MockQueries) DeleteUser(ctx context.Context, id int64) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+func (m *MockQueries) DeleteUser(ctx context.Context, arg db.DeleteUserParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 // ListUsers はdb.Queriesインターフェースの実装です
@@ -54,9 +71,14 @@ func (m *MockQueries) ListUsers(ctx context.Context, arg db.ListUsersParams) ([]
 }
 
 // UpdateUser はdb.Queriesインターフェースの実装です
-func (m *MockQueries) UpdateUser(ctx context.Context, arg db.UpdateUserParams) error {
+func (m *MockQueries) UpdateUser(ctx context.Context, arg db.UpdateUserParams) (int64, error) {
 	args := m.Called(ctx, arg)
-	return args.Error(0)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) UpdateUserLocale(ctx context.Context, arg db.UpdateUserLocaleParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 // UpdateUserPassword はdb.Queriesインターフェースの実装です
@@ -65,18 +87,23 @@ func (m *MockQueries) UpdateUserPassword(ctx context.Context, arg db.UpdateUserP
 	return args.Error(0)
 }
 
+func (m *MockQueries) UpdateUserStatus(ctx context.Context, arg db.UpdateUserStatusParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockQueries) CreatePasswordReset(ctx context.Context, arg db.CreatePasswordResetParams) (sql.Result, error) {
 	args := m.Called(ctx, arg)
 	return args.Get(0).(sql.Result), args.Error(1)
 }
 
-func (m *MockQueries) GetPasswordResetByToken(ctx context.Context, token string) (db.GetPasswordResetByTokenRow, error) {
-	args := m.Called(ctx, token)
-	return args.Get(0).(db.GetPasswordResetByTokenRow), args.Error(1)
+func (m *MockQueries) GetPasswordResetByHash(ctx context.Context, tokenHash string) (db.PasswordReset, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(db.PasswordReset), args.Error(1)
 }
 
-func (m *MockQueries) DeletePasswordReset(ctx context.Context, token string) error {
-	args := m.Called(ctx, token)
+func (m *MockQueries) DeleteUserPasswordResets(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
 
@@ -85,6 +112,311 @@ func (m *MockQueries) SearchUsers(ctx context.Context, arg db.SearchUsersParams)
 	return args.Get(0).([]db.User), args.Error(1)
 }
 
+func (m *MockQueries) CreateRefreshToken(ctx context.Context, arg db.CreateRefreshTokenParams) (sql.Result, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(sql.Result), args.Error(1)
+}
+
+func (m *MockQueries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (db.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(db.RefreshToken), args.Error(1)
+}
+
+func (m *MockQueries) RevokeRefreshToken(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockQueries) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockQueries) CreateRevokedToken(ctx context.Context, arg db.CreateRevokedTokenParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) ListActiveRevokedTokens(ctx context.Context) ([]db.ListActiveRevokedTokensRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.ListActiveRevokedTokensRow), args.Error(1)
+}
+
+func (m *MockQueries) DeleteExpiredRevokedTokens(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockQueries) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) DeleteExpiredPasswordResets(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) UpsertUserTokenRevocation(ctx context.Context, arg db.UpsertUserTokenRevocationParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) ListActiveUserTokenRevocations(ctx context.Context) ([]db.UserTokenRevocation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.UserTokenRevocation), args.Error(1)
+}
+
+func (m *MockQueries) DeleteExpiredUserTokenRevocations(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockQueries) ActivateUser(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) UpsertEmailVerification(ctx context.Context, arg db.UpsertEmailVerificationParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetEmailVerification(ctx context.Context, userID int64) (db.EmailVerification, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(db.EmailVerification), args.Error(1)
+}
+
+func (m *MockQueries) DeleteEmailVerification(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockQueries) UpsertUserTOTP(ctx context.Context, arg db.UpsertUserTOTPParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetUserTOTP(ctx context.Context, userID int64) (db.UserTotp, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(db.UserTotp), args.Error(1)
+}
+
+func (m *MockQueries) ConfirmUserTOTP(ctx context.Context, arg db.ConfirmUserTOTPParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) UpdateTOTPLastUsedStep(ctx context.Context, arg db.UpdateTOTPLastUsedStepParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) DeleteUserTOTP(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockQueries) CreateRecoveryCode(ctx context.Context, arg db.CreateRecoveryCodeParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) UseRecoveryCode(ctx context.Context, arg db.UseRecoveryCodeParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetLoginFailure(ctx context.Context, arg db.GetLoginFailureParams) (db.LoginFailure, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.LoginFailure), args.Error(1)
+}
+
+func (m *MockQueries) RecordLoginFailure(ctx context.Context, arg db.RecordLoginFailureParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) LockLoginSubject(ctx context.Context, arg db.LockLoginSubjectParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) DeleteLoginFailure(ctx context.Context, arg db.DeleteLoginFailureParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// MockRevocationStore はトークン失効ストアのモックです
+type MockRevocationStore struct {
+	mock.Mock
+}
+
+func (m *MockRevocationStore) Revoke(ctx context.Context, claims *util.Claims) error {
+	args := m.Called(ctx, claims)
+	return args.Error(0)
+}
+
+func (m *MockRevocationStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRevocationStore) IsRevoked(claims *util.Claims) bool {
+	args := m.Called(claims)
+	return args.Bool(0)
+}
+
+func (m *MockQueries) ListUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockQueries) ListRolePermissions(ctx context.Context) ([]db.ListRolePermissionsRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]db.ListRolePermissionsRow), args.Error(1)
+}
+
+func (m *MockQueries) AssignUserRole(ctx context.Context, arg db.AssignUserRoleParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) UserEmailExists(ctx context.Context, email string) (bool, error) {
+	args := m.Called(ctx, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockQueries) ListDeletedUsers(ctx context.Context, arg db.ListDeletedUsersParams) ([]db.User, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.User), args.Error(1)
+}
+
+func (m *MockQueries) RestoreUser(ctx context.Context, arg db.RestoreUserParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) PurgeOutboxEmails(ctx context.Context, updatedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, updatedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) CountSearchUsers(ctx context.Context, arg db.CountSearchUsersParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) CountDeletedUsers(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) FilterUsers(ctx context.Context, arg db.FilterUsersParams) ([]db.User, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.User), args.Error(1)
+}
+
+func (m *MockQueries) CountFilteredUsers(ctx context.Context, filter db.UserFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ExecTx はトランザクションを使用せず、同じモックで fn を実行します
+func (m *MockQueries) ExecTx(ctx context.Context, fn func(db.Store) error) error {
+	return fn(m)
+}
+
+func (m *MockQueries) EnqueueEmail(ctx context.Context, arg db.EnqueueEmailParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) ListDueEmails(ctx context.Context, arg db.ListDueEmailsParams) ([]db.EmailOutbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.EmailOutbox), args.Error(1)
+}
+
+func (m *MockQueries) LeaseEmail(ctx context.Context, arg db.LeaseEmailParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) MarkEmailSent(ctx context.Context, arg db.MarkEmailSentParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) MarkEmailFailed(ctx context.Context, arg db.MarkEmailFailedParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetOutboxEmail(ctx context.Context, id int64) (db.EmailOutbox, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.EmailOutbox), args.Error(1)
+}
+
+func (m *MockQueries) ListOutboxEmails(ctx context.Context, arg db.ListOutboxEmailsParams) ([]db.EmailOutbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.EmailOutbox), args.Error(1)
+}
+
+func (m *MockQueries) CountOutboxEmails(ctx context.Context, status db.EmailOutboxStatus) (int64, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) RequeueEmail(ctx context.Context, arg db.RequeueEmailParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockLoginGuard はログイン試行制限のモックです
+type MockLoginGuard struct {
+	mock.Mock
+}
+
+func (m *MockLoginGuard) Check(ctx context.Context, email, ip string) (time.Duration, bool, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Bool(1), args.Error(2)
+}
+
+func (m *MockLoginGuard) RecordFailure(ctx context.Context, email, ip string) (time.Time, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockLoginGuard) Reset(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+// newTestAuthHandler はモックを使用する AuthHandler を作成します
+// 使用しない revocations と loginGuard には nil を指定します
+func newTestAuthHandler(queries db.Store, revocations util.RevocationStore, loginGuard util.LoginGuard) *AuthHandler {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	return &AuthHandler{
+		auth:        service.NewAuthService(queries, revocations, loginGuard, service.SystemClock{}, cfg),
+		revocations: revocations,
+	}
+}
+
 func TestLogin(t *testing.T) {
 	// Ginのテストモード設定
 	gin.SetMode(gin.TestMode)
@@ -122,6 +454,9 @@ func TestLogin(t *testing.T) {
 					CreatedAt:    now,
 					UpdatedAt:    now,
 				}, nil)
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{}, sql.ErrNoRows)
+				m.On("ListUserRoles", mock.Anything, int64(1)).Return([]string{util.RoleUser}, nil)
+				m.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("db.CreateRefreshTokenParams")).Return(new(MockSQLResult), nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -133,7 +468,7 @@ func TestLogin(t *testing.T) {
 			},
 			setupMock:      func(m *MockQueries) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'LoginRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag",
+			expectedError:  "入力内容に誤りがあります",
 		},
 		{
 			name: "パスワードなし",
@@ -142,7 +477,7 @@ func TestLogin(t *testing.T) {
 			},
 			setupMock:      func(m *MockQueries) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'LoginRequest.Password' Error:Field validation for 'Password' failed on the 'required' tag",
+			expectedError:  "入力内容に誤りがあります",
 		},
 	}
 
@@ -153,9 +488,7 @@ func TestLogin(t *testing.T) {
 			tt.setupMock(mockQueries)
 
 			// ハンドラーの準備
-			handler := &AuthHandler{
-				queries: mockQueries,
-			}
+			handler := newTestAuthHandler(mockQueries, nil, nil)
 
 			// HTTPリクエストの準備
 			w := httptest.NewRecorder()
@@ -168,15 +501,16 @@ func TestLogin(t *testing.T) {
 
 			// ハンドラーの実行
 			handler.Login(c)
+			middleware.RenderErrors(c)
 
 			// アサーション
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			if tt.expectedError != "" {
-				var response map[string]string
+				var response middleware.Problem
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Contains(t, response["error"], tt.expectedError)
+				assert.Contains(t, response.Detail, tt.expectedError)
 			}
 
 			// モックの検証
@@ -189,9 +523,6 @@ func TestRegister(t *testing.T) {
 	// Ginのテストモード設定
 	gin.SetMode(gin.TestMode)
 
-	// テスト用の時間を設定
-	now := time.Now()
-
 	tests := []struct {
 		name           string
 		requestBody    RegisterRequest
@@ -208,11 +539,21 @@ func TestRegister(t *testing.T) {
 				LastName:  "User",
 			},
 			setupMock: func(m *MockQueries) {
-				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(db.User{}, sql.ErrNoRows)
+				m.On("UserEmailExists", mock.Anything, "test@example.com").Return(false, nil)
 
 				mockResult := new(MockSQLResult)
 				mockResult.On("LastInsertId").Return(int64(1), nil)
-				m.On("CreateUser", mock.Anything, mock.AnythingOfType("db.CreateUserParams")).Return(mockResult, nil)
+				m.On("CreateUser", mock.Anything, mock.MatchedBy(func(arg db.CreateUserParams) bool {
+					return arg.Status.UsersStatus == db.UsersStatusPendingVerification
+				})).Return(mockResult, nil)
+				m.On("AssignUserRole", mock.Anything, db.AssignUserRoleParams{UserID: 1, RoleName: util.RoleUser}).Return(nil)
+				m.On("UpsertEmailVerification", mock.Anything, mock.AnythingOfType("db.UpsertEmailVerificationParams")).Return(nil)
+
+				m.On("EnqueueEmail", mock.Anything, enqueuedMail("test@example.com", util.MailTemplateEmailVerification, i18n.Japanese, func(data json.RawMessage) bool {
+					var mail util.EmailVerificationMail
+					return json.Unmarshal(data, &mail) == nil &&
+						strings.HasPrefix(mail.VerifyURL, "http://localhost:8080/verify-email?token=")
+				})).Return(nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
@@ -226,7 +567,7 @@ func TestRegister(t *testing.T) {
 			},
 			setupMock:      func(m *MockQueries) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'RegisterRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag",
+			expectedError:  "入力内容に誤りがあります",
 		},
 		{
 			name: "パスワードが短すぎる",
@@ -238,7 +579,7 @@ func TestRegister(t *testing.T) {
 			},
 			setupMock:      func(m *MockQueries) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'RegisterRequest.Password' Error:Field validation for 'Password' failed on the 'min' tag",
+			expectedError:  "入力内容に誤りがあります",
 		},
 		{
 			name: "既存のメールアドレス",
@@ -249,13 +590,9 @@ func TestRegister(t *testing.T) {
 				LastName:  "User",
 			},
 			setupMock: func(m *MockQueries) {
-				m.On("GetUserByEmail", mock.Anything, "existing@example.com").Return(db.User{
-					ID:        1,
-					Email:     "existing@example.com",
-					CreatedAt: now,
-				}, nil)
+				m.On("UserEmailExists", mock.Anything, "existing@example.com").Return(true, nil)
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusConflict,
 			expectedError:  "このメールアドレスは既に登録されています",
 		},
 	}
@@ -267,9 +604,7 @@ func TestRegister(t *testing.T) {
 			tt.setupMock(mockQueries)
 
 			// ハンドラーの準備
-			handler := &AuthHandler{
-				queries: mockQueries,
-			}
+			handler := newTestAuthHandler(mockQueries, nil, nil)
 
 			// HTTPリクエストの準備
 			w := httptest.NewRecorder()
@@ -282,15 +617,16 @@ func TestRegister(t *testing.T) {
 
 			// ハンドラーの実行
 			handler.Register(c)
+			middleware.RenderErrors(c)
 
 			// アサーション
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			if tt.expectedError != "" {
-				var response map[string]string
+				var response middleware.Problem
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Contains(t, response["error"], tt.expectedError)
+				assert.Contains(t, response.Detail, tt.expectedError)
 			}
 
 			// モックの検証
@@ -373,6 +709,27 @@ func TestLoginWithInvalidCredentials(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedError:  "このアカウントは無効です",
 		},
+		{
+			name: "メールアドレス未確認",
+			requestBody: LoginRequest{
+				Email:    "pending@example.com",
+				Password: "password123",
+			},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserByEmail", mock.Anything, "pending@example.com").Return(db.User{
+					ID:           3,
+					Email:        "pending@example.com",
+					PasswordHash: string(hashedPassword),
+					FirstName:    "Pending",
+					LastName:     "User",
+					Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusPendingVerification, Valid: true},
+					CreatedAt:    now,
+					UpdatedAt:    now,
+				}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "メールアドレスの確認が完了していません",
+		},
 	}
 
 	for _, tt := range tests {
@@ -382,10 +739,841 @@ func TestLoginWithInvalidCredentials(t *testing.T) {
 			tt.setupMock(mockQueries)
 
 			// ハンドラーの準備
-			handler := &AuthHandler{
-				queries: mockQueries,
+			handler := newTestAuthHandler(mockQueries, nil, nil)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			// リクエストボディの準備
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.Login(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
 			}
 
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用の時間を設定
+	now := time.Now()
+
+	const refreshToken = "refresh-token"
+	tokenHash := util.HashToken(refreshToken)
+
+	activeUser := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    RefreshRequest
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "正常なローテーション",
+			requestBody: RefreshRequest{RefreshToken: refreshToken},
+			setupMock: func(m *MockQueries) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(db.RefreshToken{
+					ID:        10,
+					UserID:    1,
+					TokenHash: tokenHash,
+					FamilyID:  "family",
+					ExpiresAt: now.Add(time.Hour),
+				}, nil)
+				m.On("RevokeRefreshToken", mock.Anything, int64(10)).Return(int64(1), nil)
+				m.On("GetUser", mock.Anything, int64(1)).Return(activeUser, nil)
+				m.On("ListUserRoles", mock.Anything, int64(1)).Return([]string{util.RoleUser}, nil)
+				m.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(arg db.CreateRefreshTokenParams) bool {
+					return arg.UserID == 1 && arg.FamilyID == "family" && arg.TokenHash != tokenHash
+				})).Return(new(MockSQLResult), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "存在しないトークン",
+			requestBody: RefreshRequest{RefreshToken: refreshToken},
+			setupMock: func(m *MockQueries) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(db.RefreshToken{}, sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "無効なリフレッシュトークンです",
+		},
+		{
+			name:        "ローテーション済みトークンの再利用",
+			requestBody: RefreshRequest{RefreshToken: refreshToken},
+			setupMock: func(m *MockQueries) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(db.RefreshToken{
+					ID:        10,
+					UserID:    1,
+					TokenHash: tokenHash,
+					FamilyID:  "family",
+					ExpiresAt: now.Add(time.Hour),
+					RevokedAt: sql.NullTime{Time: now, Valid: true},
+				}, nil)
+				m.On("RevokeRefreshTokenFamily", mock.Anything, "family").Return(nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "リフレッシュトークンが再利用されました",
+		},
+		{
+			name:        "同時使用による再利用",
+			requestBody: RefreshRequest{RefreshToken: refreshToken},
+			setupMock: func(m *MockQueries) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(db.RefreshToken{
+					ID:        10,
+					UserID:    1,
+					TokenHash: tokenHash,
+					FamilyID:  "family",
+					ExpiresAt: now.Add(time.Hour),
+				}, nil)
+				m.On("RevokeRefreshToken", mock.Anything, int64(10)).Return(int64(0), nil)
+				m.On("RevokeRefreshTokenFamily", mock.Anything, "family").Return(nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "リフレッシュトークンが再利用されました",
+		},
+		{
+			name:        "有効期限切れ",
+			requestBody: RefreshRequest{RefreshToken: refreshToken},
+			setupMock: func(m *MockQueries) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(db.RefreshToken{
+					ID:        10,
+					UserID:    1,
+					TokenHash: tokenHash,
+					FamilyID:  "family",
+					ExpiresAt: now.Add(-time.Hour),
+				}, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "リフレッシュトークンの有効期限が切れています",
+		},
+		{
+			name:           "トークンなし",
+			requestBody:    RefreshRequest{},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "入力内容に誤りがあります",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestAuthHandler(mockQueries, nil, nil)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			// リクエストボディの準備
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.Refresh(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+			} else {
+				var response TokenResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, response.Token)
+				assert.NotEqual(t, refreshToken, response.RefreshToken)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	const refreshToken = "refresh-token"
+	tokenHash := util.HashToken(refreshToken)
+
+	tests := []struct {
+		name           string
+		requestBody    *LogoutRequest
+		setupMock      func(*MockQueries, *MockRevocationStore)
+		expectedStatus int
+	}{
+		{
+			name:        "リフレッシュトークンを含むログアウト",
+			requestBody: &LogoutRequest{RefreshToken: refreshToken},
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(db.RefreshToken{
+					ID:       10,
+					UserID:   1,
+					FamilyID: "family",
+				}, nil)
+				m.On("RevokeRefreshTokenFamily", mock.Anything, "family").Return(nil)
+				r.On("Revoke", mock.Anything, mock.AnythingOfType("*util.Claims")).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "他人のリフレッシュトークンは失効させない",
+			requestBody: &LogoutRequest{RefreshToken: refreshToken},
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(db.RefreshToken{
+					ID:       11,
+					UserID:   2,
+					FamilyID: "other-family",
+				}, nil)
+				r.On("Revoke", mock.Anything, mock.AnythingOfType("*util.Claims")).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "リクエストボディなし",
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				r.On("Revoke", mock.Anything, mock.AnythingOfType("*util.Claims")).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			mockRevocations := new(MockRevocationStore)
+			tt.setupMock(mockQueries, mockRevocations)
+
+			// ハンドラーの準備
+			handler := newTestAuthHandler(mockQueries, mockRevocations, nil)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("claims", &util.Claims{UserID: 1})
+
+			// リクエストボディの準備
+			var body bytes.Buffer
+			if tt.requestBody != nil {
+				_ = json.NewEncoder(&body).Encode(tt.requestBody)
+			}
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/logout", &body)
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.Logout(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// モックの準備
+	mockQueries := new(MockQueries)
+	mockRevocations := new(MockRevocationStore)
+	mockQueries.On("RevokeUserRefreshTokens", mock.Anything, int64(1)).Return(nil)
+	mockRevocations.On("RevokeAllForUser", mock.Anything, int64(1)).Return(nil)
+
+	// ハンドラーの準備
+	handler := newTestAuthHandler(mockQueries, mockRevocations, nil)
+
+	// HTTPリクエストの準備
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("claims", &util.Claims{UserID: 1})
+	c.Request = httptest.NewRequest(http.MethodPost, "/auth/logout-all", nil)
+
+	// ハンドラーの実行
+	handler.LogoutAll(c)
+	middleware.RenderErrors(c)
+
+	// アサーション
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+	mockRevocations.AssertExpectations(t)
+}
+
+func TestLoginWithMFA(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用の時間を設定
+	now := time.Now()
+
+	// テスト用のパスワードハッシュを生成
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal("パスワードのハッシュ化に失敗しました:", err)
+	}
+
+	// モックの準備
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetUserByEmail", mock.Anything, "test@example.com").Return(db.User{
+		ID:           1,
+		Email:        "test@example.com",
+		PasswordHash: string(hashedPassword),
+		Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil)
+	mockQueries.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{
+		UserID:      1,
+		Secret:      "JBSWY3DPEHPK3PXP",
+		ConfirmedAt: sql.NullTime{Time: now, Valid: true},
+	}, nil)
+
+	// ハンドラーの準備
+	handler := newTestAuthHandler(mockQueries, nil, nil)
+
+	// HTTPリクエストの準備
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	jsonData, _ := json.Marshal(LoginRequest{Email: "test@example.com", Password: "password123"})
+	c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(jsonData))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	// ハンドラーの実行
+	handler.Login(c)
+	middleware.RenderErrors(c)
+
+	// アクセストークンではなく、二要素認証待ちのトークンが返される
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response MFAChallengeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.MFARequired)
+
+	claims, err := util.ValidatePurposeToken(response.MFAToken, util.PurposeMFAPending)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+
+	_, err = util.ValidateToken(response.MFAToken)
+	assert.Error(t, err)
+
+	// CreateRefreshTokenは呼ばれていない
+	mockQueries.AssertExpectations(t)
+}
+
+func TestLoginMFA(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用の時間を設定
+	now := time.Now()
+
+	const secret = "JBSWY3DPEHPK3PXP"
+	validCode, err := util.GenerateTOTPCode(secret, now)
+	if err != nil {
+		t.Fatal("認証コードの生成に失敗しました:", err)
+	}
+
+	mfaToken, err := util.GeneratePurposeToken(1, util.PurposeMFAPending, util.MFATokenExpiration)
+	if err != nil {
+		t.Fatal("トークンの生成に失敗しました:", err)
+	}
+	accessToken, err := util.GenerateToken(1, nil)
+	if err != nil {
+		t.Fatal("トークンの生成に失敗しました:", err)
+	}
+
+	activeUser := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	confirmedTOTP := db.UserTotp{
+		UserID:      1,
+		Secret:      secret,
+		ConfirmedAt: sql.NullTime{Time: now, Valid: true},
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    LoginMFARequest
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "正しい認証コード",
+			requestBody: LoginMFARequest{MFAToken: mfaToken, Code: validCode},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(activeUser, nil)
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(confirmedTOTP, nil)
+				m.On("UpdateTOTPLastUsedStep", mock.Anything, mock.AnythingOfType("db.UpdateTOTPLastUsedStepParams")).Return(int64(1), nil)
+				m.On("ListUserRoles", mock.Anything, int64(1)).Return([]string{util.RoleUser}, nil)
+				m.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("db.CreateRefreshTokenParams")).Return(new(MockSQLResult), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "使用済みの認証コード",
+			requestBody: LoginMFARequest{MFAToken: mfaToken, Code: validCode},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(activeUser, nil)
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(confirmedTOTP, nil)
+				m.On("UpdateTOTPLastUsedStep", mock.Anything, mock.AnythingOfType("db.UpdateTOTPLastUsedStepParams")).Return(int64(0), nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "認証コードが正しくありません",
+		},
+		{
+			name:        "誤った認証コード",
+			requestBody: LoginMFARequest{MFAToken: mfaToken, Code: "000000"},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(activeUser, nil)
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(confirmedTOTP, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "認証コードが正しくありません",
+		},
+		{
+			name:        "リカバリーコード",
+			requestBody: LoginMFARequest{MFAToken: mfaToken, RecoveryCode: " ABCDE-12345 "},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(activeUser, nil)
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(confirmedTOTP, nil)
+				m.On("UseRecoveryCode", mock.Anything, db.UseRecoveryCodeParams{
+					UserID:   1,
+					CodeHash: util.HashToken("abcde-12345"),
+				}).Return(int64(1), nil)
+				m.On("ListUserRoles", mock.Anything, int64(1)).Return([]string{util.RoleUser}, nil)
+				m.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("db.CreateRefreshTokenParams")).Return(new(MockSQLResult), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "アクセストークンは使用できない",
+			requestBody:    LoginMFARequest{MFAToken: accessToken, Code: validCode},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "無効なトークンです",
+		},
+		{
+			name:           "コードなし",
+			requestBody:    LoginMFARequest{MFAToken: mfaToken},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "入力内容に誤りがあります",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestAuthHandler(mockQueries, nil, nil)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			// リクエストボディの準備
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/login/mfa", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.LoginMFA(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+			} else {
+				var response LoginResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, response.Token)
+				assert.Equal(t, int64(1), response.User.ID)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用の時間を設定
+	now := time.Now()
+
+	verifyToken, err := util.GeneratePurposeToken(1, util.PurposeEmailVerification, util.EmailVerificationExpiration)
+	if err != nil {
+		t.Fatal("トークンの生成に失敗しました:", err)
+	}
+	accessToken, err := util.GenerateToken(1, nil)
+	if err != nil {
+		t.Fatal("トークンの生成に失敗しました:", err)
+	}
+
+	userWithStatus := func(status db.UsersStatus) db.User {
+		return db.User{
+			ID:        1,
+			Email:     "test@example.com",
+			Status:    db.NullUsersStatus{UsersStatus: status, Valid: true},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		token          string
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:   "GETでの確認",
+			method: http.MethodGet,
+			token:  verifyToken,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(userWithStatus(db.UsersStatusPendingVerification), nil)
+				m.On("ActivateUser", mock.Anything, int64(1)).Return(int64(1), nil)
+				m.On("DeleteEmailVerification", mock.Anything, int64(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "POSTでの確認",
+			method: http.MethodPost,
+			token:  verifyToken,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(userWithStatus(db.UsersStatusPendingVerification), nil)
+				m.On("ActivateUser", mock.Anything, int64(1)).Return(int64(1), nil)
+				m.On("DeleteEmailVerification", mock.Anything, int64(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "確認済み",
+			method: http.MethodGet,
+			token:  verifyToken,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(userWithStatus(db.UsersStatusActive), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "停止中のアカウントは有効にしない",
+			method: http.MethodGet,
+			token:  verifyToken,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(userWithStatus(db.UsersStatusSuspended), nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "このアカウントは無効です",
+		},
+		{
+			name:           "アクセストークンは使用できない",
+			method:         http.MethodGet,
+			token:          accessToken,
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効または期限切れの確認リンクです",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestAuthHandler(mockQueries, nil, nil)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			if tt.method == http.MethodGet {
+				c.Request = httptest.NewRequest(http.MethodGet, "/auth/verify-email?token="+tt.token, nil)
+			} else {
+				jsonData, _ := json.Marshal(VerifyEmailRequest{Token: tt.token})
+				c.Request = httptest.NewRequest(http.MethodPost, "/auth/verify-email", bytes.NewBuffer(jsonData))
+				c.Request.Header.Set("Content-Type", "application/json")
+			}
+
+			// ハンドラーの実行
+			handler.VerifyEmail(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用の時間を設定
+	now := time.Now()
+
+	pendingUser := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusPendingVerification, Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "再送",
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(pendingUser, nil)
+				m.On("GetEmailVerification", mock.Anything, int64(1)).Return(db.EmailVerification{
+					UserID:     1,
+					LastSentAt: now.Add(-2 * time.Minute),
+				}, nil)
+				m.On("UpsertEmailVerification", mock.Anything, mock.AnythingOfType("db.UpsertEmailVerificationParams")).Return(nil)
+				m.On("EnqueueEmail", mock.Anything, enqueuedMail("test@example.com", util.MailTemplateEmailVerification, i18n.Japanese, nil)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "送信間隔が短すぎる",
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(pendingUser, nil)
+				m.On("GetEmailVerification", mock.Anything, int64(1)).Return(db.EmailVerification{
+					UserID:     1,
+					LastSentAt: now,
+				}, nil)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedError:  "しばらく時間をおいてから再度お試しください",
+		},
+		{
+			name: "存在しないユーザー",
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(db.User{}, sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusOK, // セキュリティのため、成功レスポンスを返す
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestAuthHandler(mockQueries, nil, nil)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			// リクエストボディの準備
+			jsonData, _ := json.Marshal(ResendVerificationRequest{Email: "test@example.com"})
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/verify-email/resend", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.ResendVerificationEmail(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginWithLoginGuard(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用のパスワードハッシュを生成
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal("パスワードのハッシュ化に失敗しました:", err)
+	}
+
+	user := db.User{
+		ID:           1,
+		Email:        "test@example.com",
+		PasswordHash: string(hashedPassword),
+		FirstName:    "Test",
+		LastName:     "User",
+		Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+	}
+	clientIP := "192.0.2.1"
+	lockedUntil := time.Now().Add(15 * time.Minute)
+
+	tests := []struct {
+		name               string
+		requestBody        LoginRequest
+		setupMock          func(*MockQueries, *MockLoginGuard)
+		expectedStatus     int
+		expectedError      string
+		expectedCode       string
+		expectedRetryAfter string
+	}{
+		{
+			name:        "ロック中のアカウント",
+			requestBody: LoginRequest{Email: "test@example.com", Password: "password123"},
+			setupMock: func(m *MockQueries, g *MockLoginGuard) {
+				g.On("Check", mock.Anything, "test@example.com", clientIP).Return(10*time.Minute, true, nil)
+			},
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedError:      "アカウントを一時的にロックしています",
+			expectedCode:       "account_locked",
+			expectedRetryAfter: "600",
+		},
+		{
+			name:        "失敗直後の再試行",
+			requestBody: LoginRequest{Email: "test@example.com", Password: "password123"},
+			setupMock: func(m *MockQueries, g *MockLoginGuard) {
+				g.On("Check", mock.Anything, "test@example.com", clientIP).Return(1500*time.Millisecond, false, nil)
+			},
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedError:      "しばらく時間をおいてから再度お試しください",
+			expectedCode:       "login_throttled",
+			expectedRetryAfter: "2",
+		},
+		{
+			name:        "存在しないユーザーの失敗も記録する",
+			requestBody: LoginRequest{Email: "nonexistent@example.com", Password: "password123"},
+			setupMock: func(m *MockQueries, g *MockLoginGuard) {
+				g.On("Check", mock.Anything, "nonexistent@example.com", clientIP).Return(time.Duration(0), false, nil)
+				m.On("GetUserByEmail", mock.Anything, "nonexistent@example.com").Return(db.User{}, sql.ErrNoRows)
+				g.On("RecordFailure", mock.Anything, "nonexistent@example.com", clientIP).Return(time.Time{}, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "メールアドレスまたはパスワードが正しくありません",
+		},
+		{
+			name:        "閾値に達した場合はロックを通知する",
+			requestBody: LoginRequest{Email: "test@example.com", Password: "wrongpassword"},
+			setupMock: func(m *MockQueries, g *MockLoginGuard) {
+				g.On("Check", mock.Anything, "test@example.com", clientIP).Return(time.Duration(0), false, nil)
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				g.On("RecordFailure", mock.Anything, "test@example.com", clientIP).Return(lockedUntil, nil)
+				m.On("EnqueueEmail", mock.Anything, enqueuedMail("test@example.com", util.MailTemplateAccountLocked, i18n.Japanese, func(data json.RawMessage) bool {
+					var mail util.AccountLockedMail
+					return json.Unmarshal(data, &mail) == nil && mail.LockedUntil.Equal(lockedUntil)
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "メールアドレスまたはパスワードが正しくありません",
+		},
+		{
+			name:        "ログイン成功時は失敗の記録を消去する",
+			requestBody: LoginRequest{Email: "test@example.com", Password: "password123"},
+			setupMock: func(m *MockQueries, g *MockLoginGuard) {
+				g.On("Check", mock.Anything, "test@example.com", clientIP).Return(time.Duration(0), false, nil)
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(user, nil)
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{}, sql.ErrNoRows)
+				m.On("ListUserRoles", mock.Anything, int64(1)).Return([]string{util.RoleUser}, nil)
+				m.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("db.CreateRefreshTokenParams")).Return(new(MockSQLResult), nil)
+				g.On("Reset", mock.Anything, "test@example.com").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			mockGuard := new(MockLoginGuard)
+			tt.setupMock(mockQueries, mockGuard)
+
+			// ハンドラーの準備
+			handler := newTestAuthHandler(mockQueries, nil, mockGuard)
+
 			// HTTPリクエストの準備
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
@@ -394,22 +1582,29 @@ func TestLoginWithInvalidCredentials(t *testing.T) {
 			jsonData, _ := json.Marshal(tt.requestBody)
 			c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(jsonData))
 			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.RemoteAddr = clientIP + ":12345"
 
 			// ハンドラーの実行
 			handler.Login(c)
+			middleware.RenderErrors(c)
 
 			// アサーション
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedRetryAfter, w.Header().Get("Retry-After"))
 
 			if tt.expectedError != "" {
-				var response map[string]string
+				var response middleware.Problem
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Contains(t, response["error"], tt.expectedError)
+				assert.Contains(t, response.Detail, tt.expectedError)
+				if tt.expectedCode != "" {
+					assert.Equal(t, tt.expectedCode, string(response.Code))
+				}
 			}
 
 			// モックの検証
 			mockQueries.AssertExpectations(t)
+			mockGuard.AssertExpectations(t)
 		})
 	}
 }
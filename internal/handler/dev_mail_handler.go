@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/handler/dto"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DevMailHandler は開発用の受信箱が受け取ったメールを返します
+// メールの送信方法が devinbox の場合のみ登録され、認証は不要です
+type DevMailHandler struct {
+	inbox *util.DevInbox
+}
+
+func NewDevMailHandler(inbox *util.DevInbox) *DevMailHandler {
+	return &DevMailHandler{
+		inbox: inbox,
+	}
+}
+
+// RegisterRoutes は開発用の受信箱のルートを登録します
+func (h *DevMailHandler) RegisterRoutes(r gin.IRouter) {
+	mail := r.Group("/dev/mail")
+	{
+		mail.GET("", h.ListMails)
+		mail.DELETE("", h.ClearMails)
+		mail.GET("/:id", h.GetMail)
+		mail.GET("/:id/html", h.GetMailHTML)
+		mail.GET("/:id/raw", h.GetMailRaw)
+	}
+}
+
+// ListMails は受け取ったメールを新しい順に返します
+func (h *DevMailHandler) ListMails(c *gin.Context) {
+	mails := h.inbox.Mails()
+	response := dto.DevMailsResponse{
+		Mails: make([]dto.DevMailResponse, len(mails)),
+	}
+	for i, mail := range mails {
+		response.Mails[i] = toDevMailResponse(mail)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ClearMails は受け取ったメールをすべて削除します
+func (h *DevMailHandler) ClearMails(c *gin.Context) {
+	h.inbox.Clear()
+	c.Status(http.StatusNoContent)
+}
+
+// GetMail は指定したIDのメールを返します
+func (h *DevMailHandler) GetMail(c *gin.Context) {
+	mail, ok := h.findMail(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, toDevMailResponse(mail))
+}
+
+// GetMailHTML は指定したIDのメールのHTML版をブラウザで表示できるように返します
+// HTML版がない場合はテキスト版を返します
+func (h *DevMailHandler) GetMailHTML(c *gin.Context) {
+	mail, ok := h.findMail(c)
+	if !ok {
+		return
+	}
+
+	if mail.HTML == "" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(mail.Text))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(mail.HTML))
+}
+
+// GetMailRaw は指定したIDのメールを送信される MIME メッセージのまま返します
+func (h *DevMailHandler) GetMailRaw(c *gin.Context) {
+	mail, ok := h.findMail(c)
+	if !ok {
+		return
+	}
+
+	c.Data(http.StatusOK, "message/rfc822", mail.Raw)
+}
+
+// findMail はパスのIDのメールを探します。見つからない場合はエラーを設定して false を返します
+func (h *DevMailHandler) findMail(c *gin.Context) (util.DevMail, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidEmailID))
+		return util.DevMail{}, false
+	}
+
+	mail, ok := h.inbox.Mail(id)
+	if !ok {
+		c.Error(apperror.New(apperror.CodeNotFound, i18n.OutboxEmailNotFound))
+		return util.DevMail{}, false
+	}
+	return mail, true
+}
+
+// toDevMailResponse は受け取ったメールをレスポンスに変換します
+func toDevMailResponse(mail util.DevMail) dto.DevMailResponse {
+	return dto.DevMailResponse{
+		ID:      mail.ID,
+		From:    mail.From,
+		To:      mail.To,
+		Subject: mail.Subject,
+		Text:    mail.Text,
+		HTML:    mail.HTML,
+		SentAt:  mail.SentAt,
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-gin-sqlc/internal/handler/dto"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevMailHandler(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テストケースの定義
+	tests := []struct {
+		name                string
+		method              string
+		path                string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+		expectedError       string
+		expectedMails       int
+	}{
+		{
+			name:           "受け取ったメールの一覧",
+			method:         http.MethodGet,
+			path:           "/dev/mail",
+			expectedStatus: http.StatusOK,
+			expectedMails:  2,
+		},
+		{
+			name:                "メールの取得",
+			method:              http.MethodGet,
+			path:                "/dev/mail/2",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        "http://localhost:8080/reset-password?token=abc",
+		},
+		{
+			name:                "HTML版の表示",
+			method:              http.MethodGet,
+			path:                "/dev/mail/2/html",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        `<a href="http://localhost:8080/reset-password?token=abc">`,
+		},
+		{
+			name:                "HTML版がない場合はテキスト版を表示する",
+			method:              http.MethodGet,
+			path:                "/dev/mail/1/html",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "本文",
+		},
+		{
+			name:                "MIMEメッセージの取得",
+			method:              http.MethodGet,
+			path:                "/dev/mail/1/raw",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "message/rfc822",
+			expectedBody:        "To: first@example.com",
+		},
+		{
+			name:           "存在しないメール",
+			method:         http.MethodGet,
+			path:           "/dev/mail/99",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "メールが見つかりません",
+		},
+		{
+			name:           "無効なID",
+			method:         http.MethodGet,
+			path:           "/dev/mail/abc/raw",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なメールID",
+		},
+		{
+			name:           "すべて削除",
+			method:         http.MethodDelete,
+			path:           "/dev/mail",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 受信箱の準備
+			inbox := util.NewDevInbox(util.MailConfig{From: "noreply@example.com"})
+			require.NoError(t, inbox.SendMail("first@example.com", "件名", "本文"))
+			require.NoError(t, inbox.SendTemplate("second@example.com", util.MailTemplatePasswordReset, i18n.Japanese, util.PasswordResetMail{
+				ResetURL: "http://localhost:8080/reset-password?token=abc",
+			}))
+
+			// ハンドラーの準備
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(middleware.ErrorHandler())
+			NewDevMailHandler(inbox).RegisterRoutes(r)
+
+			// リクエストの実行
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			}
+
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			if tt.expectedMails > 0 {
+				var response dto.DevMailsResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				require.Len(t, response.Mails, tt.expectedMails)
+				// 新しい順に返す
+				assert.Equal(t, "second@example.com", response.Mails[0].To)
+			}
+
+			if tt.method == http.MethodDelete {
+				assert.Empty(t, inbox.Mails())
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// EmailResponse は送信待ちのメールのレスポンス構造体です
+// テンプレートに渡すデータには認証用のリンクが含まれるため返しません
+type EmailResponse struct {
+	ID        int64  `json:"id"`
+	Recipient string `json:"recipient"`
+	Template  string `json:"template"`
+	Locale    string `json:"locale"`
+	Status    string `json:"status"`
+	Attempts  int32  `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+	// NextAttemptAt は次に配信を試みる日時です（送信待ちの場合のみ）
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// EmailsResponse は送信待ちのメール一覧のレスポンス構造体です
+type EmailsResponse struct {
+	Emails []EmailResponse `json:"emails"`
+	Total  int64           `json:"total"`
+}
+
+// DevMailResponse は開発用の受信箱が受け取ったメールのレスポンス構造体です
+type DevMailResponse struct {
+	ID      int64     `json:"id"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	HTML    string    `json:"html,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// DevMailsResponse は開発用の受信箱が受け取ったメール一覧のレスポンス構造体です
+type DevMailsResponse struct {
+	Mails []DevMailResponse `json:"mails"`
+}
@@ -15,22 +15,50 @@ type UpdateUserRequest struct {
 	Email     string `json:"email" binding:"omitempty,email"`
 	FirstName string `json:"first_name" binding:"omitempty"`
 	LastName  string `json:"last_name" binding:"omitempty"`
-	Status    string `json:"status" binding:"omitempty,oneof=active inactive suspended"`
+	Status    string `json:"status" binding:"omitempty,oneof=active inactive suspended pending_verification"`
+}
+
+// UpdateMeRequest は自分自身のプロフィール更新リクエストの構造体です
+// ステータスは管理者のみ変更できるため含みません
+type UpdateMeRequest struct {
+	Email     string `json:"email" binding:"omitempty,email"`
+	FirstName string `json:"first_name" binding:"omitempty"`
+	LastName  string `json:"last_name" binding:"omitempty"`
+	// Locale はメールの言語の設定です
+	Locale string `json:"locale" binding:"omitempty,oneof=ja en"`
+}
+
+// ChangePasswordRequest はログイン中のパスワード変更リクエストの構造体です
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// DeleteMeRequest は退会リクエストの構造体です
+type DeleteMeRequest struct {
+	Password string `json:"password" binding:"required"`
 }
 
 // UserResponse はユーザー情報レスポンスの構造体です
 type UserResponse struct {
-	ID        int64     `json:"id"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int64      `json:"id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Status    string     `json:"status"`
+	Locale    string     `json:"locale,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // UsersResponse は複数ユーザー情報のレスポンス構造体です
 type UsersResponse struct {
 	Users []UserResponse `json:"users"`
-	Total int            `json:"total"`
+	// Total は条件に一致する全件数です（include_total=false の場合は省略されます）
+	Total *int64 `json:"total,omitempty"`
+	// NextCursor は次のページを取得するためのカーソルです
+	NextCursor string `json:"next_cursor,omitempty"`
+	// PrevCursor は前のページを取得するためのカーソルです
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/handler/dto"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailHandler は送信待ちのメールと配信を停止したメールを管理します
+type EmailHandler struct {
+	emails *service.EmailService
+}
+
+func NewEmailHandler(emails *service.EmailService) *EmailHandler {
+	return &EmailHandler{
+		emails: emails,
+	}
+}
+
+// RegisterRoutes は送信待ちのメール関連のルートを登録します
+func (h *EmailHandler) RegisterRoutes(r gin.IRouter) {
+	emails := r.Group("/emails")
+	{
+		emails.GET("", middleware.RequirePermission(util.PermissionEmailsRead), h.ListEmails)
+		emails.GET("/:id", middleware.RequirePermission(util.PermissionEmailsRead), h.GetEmail)
+		emails.POST("/:id/requeue", middleware.RequirePermission(util.PermissionEmailsRequeue), h.RequeueEmail)
+	}
+}
+
+// ListEmails は指定したステータスのメール一覧を新しい順に取得します
+// ステータスを省略した場合は配信を停止したメールを返します
+func (h *EmailHandler) ListEmails(c *gin.Context) {
+	status := db.EmailOutboxStatus(c.DefaultQuery("status", string(db.EmailOutboxStatusDead)))
+	if !isValidEmailStatus(status) {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidStatus))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 0 || limit > maxPageLimit {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidLimit))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 || offset > math.MaxInt32 {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidOffset))
+		return
+	}
+
+	emails, total, err := h.emails.List(c, status, int32(limit), int32(offset))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := dto.EmailsResponse{
+		Emails: make([]dto.EmailResponse, len(emails)),
+		Total:  total,
+	}
+	for i, email := range emails {
+		response.Emails[i] = toEmailResponse(email)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetEmail は指定したIDのメールを取得します
+func (h *EmailHandler) GetEmail(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidEmailID))
+		return
+	}
+
+	email, err := h.emails.Get(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toEmailResponse(email))
+}
+
+// RequeueEmail は配信を停止したメールの試行回数をリセットし、送信待ちに戻します
+func (h *EmailHandler) RequeueEmail(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidEmailID))
+		return
+	}
+
+	if err := h.emails.Requeue(c, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.EmailRequeued)})
+}
+
+func isValidEmailStatus(status db.EmailOutboxStatus) bool {
+	switch status {
+	case db.EmailOutboxStatusPending, db.EmailOutboxStatusSent, db.EmailOutboxStatusDead:
+		return true
+	}
+	return false
+}
+
+func toEmailResponse(email db.EmailOutbox) dto.EmailResponse {
+	response := dto.EmailResponse{
+		ID:        email.ID,
+		Recipient: email.Recipient,
+		Template:  email.Template,
+		Locale:    email.Locale,
+		Status:    string(email.Status),
+		Attempts:  email.Attempts,
+		LastError: email.LastError.String,
+		CreatedAt: email.CreatedAt,
+		UpdatedAt: email.UpdatedAt,
+	}
+	if email.Status == db.EmailOutboxStatusPending {
+		response.NextAttemptAt = &email.NextAttemptAt
+	}
+	if email.SentAt.Valid {
+		response.SentAt = &email.SentAt.Time
+	}
+	return response
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/handler/dto"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEmailHandler(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now()
+	dead := db.EmailOutbox{
+		ID:            1,
+		Recipient:     "test@example.com",
+		Template:      util.MailTemplateAccountLocked,
+		Locale:        "ja",
+		Data:          json.RawMessage(`{"LockedUntil":"2024-01-23T12:34:00Z"}`),
+		Status:        db.EmailOutboxStatusDead,
+		Attempts:      8,
+		LastError:     sql.NullString{String: "421 service not available", Valid: true},
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	adminPermissions := util.PermissionSet{
+		util.PermissionEmailsRead:    {},
+		util.PermissionEmailsRequeue: {},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		permissions    util.PermissionSet
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+		expectedEmails int
+	}{
+		{
+			name:        "省略時は配信を停止したメールの一覧",
+			method:      http.MethodGet,
+			path:        "/api/emails",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				m.On("ListOutboxEmails", mock.Anything, db.ListOutboxEmailsParams{Status: db.EmailOutboxStatusDead, Limit: 10}).Return([]db.EmailOutbox{dead}, nil)
+				m.On("CountOutboxEmails", mock.Anything, db.EmailOutboxStatusDead).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedEmails: 1,
+		},
+		{
+			name:        "ステータスを指定した一覧",
+			method:      http.MethodGet,
+			path:        "/api/emails?status=pending&limit=5&offset=10",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				m.On("ListOutboxEmails", mock.Anything, db.ListOutboxEmailsParams{Status: db.EmailOutboxStatusPending, Limit: 5, Offset: 10}).Return([]db.EmailOutbox{}, nil)
+				m.On("CountOutboxEmails", mock.Anything, db.EmailOutboxStatusPending).Return(int64(10), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "無効なステータス",
+			method:         http.MethodGet,
+			path:           "/api/emails?status=failed",
+			permissions:    adminPermissions,
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なstatusパラメータ",
+		},
+		{
+			name:           "上限を超える件数",
+			method:         http.MethodGet,
+			path:           "/api/emails?limit=1001",
+			permissions:    adminPermissions,
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なlimitパラメータ",
+		},
+		{
+			name:           "権限のないユーザーは参照できない",
+			method:         http.MethodGet,
+			path:           "/api/emails",
+			permissions:    util.PermissionSet{},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:        "存在しないメールの取得",
+			method:      http.MethodGet,
+			path:        "/api/emails/99",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				m.On("GetOutboxEmail", mock.Anything, int64(99)).Return(db.EmailOutbox{}, sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "メールが見つかりません",
+		},
+		{
+			name:        "配信を停止したメールの再送",
+			method:      http.MethodPost,
+			path:        "/api/emails/1/requeue",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(dead, nil)
+				m.On("RequeueEmail", mock.Anything, mock.MatchedBy(func(arg db.RequeueEmailParams) bool {
+					return arg.ID == 1 && time.Since(arg.NextAttemptAt) < time.Minute
+				})).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "リンクを破棄したメールは再送できない",
+			method:      http.MethodPost,
+			path:        "/api/emails/1/requeue",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				reset := dead
+				reset.Template = util.MailTemplatePasswordReset
+				reset.Data = json.RawMessage(`{"ResetURL":"","ExpiresAt":"2024-01-23T12:34:00Z"}`)
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(reset, nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "リンクを破棄したメールは再送できません。利用者に操作をやり直すよう案内してください",
+		},
+		{
+			name:        "配信待ちのメールは再送できない",
+			method:      http.MethodPost,
+			path:        "/api/emails/1/requeue",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				pending := dead
+				pending.Status = db.EmailOutboxStatusPending
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(pending, nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "配信を停止したメールのみ再送できます",
+		},
+		{
+			name:        "存在しないメールの再送",
+			method:      http.MethodPost,
+			path:        "/api/emails/99/requeue",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				m.On("GetOutboxEmail", mock.Anything, int64(99)).Return(db.EmailOutbox{}, sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "メールが見つかりません",
+		},
+		{
+			name:           "参照の権限だけでは再送できない",
+			method:         http.MethodPost,
+			path:           "/api/emails/1/requeue",
+			permissions:    util.PermissionSet{util.PermissionEmailsRead: {}},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := NewEmailHandler(service.NewEmailService(mockQueries, service.SystemClock{}))
+
+			// AuthRequired と LoadPermissions の代わりにユーザーIDと権限を設定する
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(middleware.ErrorHandler())
+			api := r.Group("/api", func(c *gin.Context) {
+				c.Set("userID", int64(1))
+				c.Set("permissions", tt.permissions)
+			})
+			handler.RegisterRoutes(api)
+
+			// リクエストの実行
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			if tt.expectedEmails > 0 {
+				var response dto.EmailsResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response.Emails, tt.expectedEmails)
+				// テンプレートのデータには認証用のリンクが含まれるため返さない
+				assert.NotContains(t, w.Body.String(), "secret")
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userETag はユーザーのバージョンから強いETagを生成します
+// ユーザーの行を更新するクエリは全てバージョンを加算するため、レスポンスの内容が変われば ETag も変わります
+func userETag(user db.User) string {
+	return `"` + strconv.FormatInt(int64(user.Version), 10) + `"`
+}
+
+// writeUser は ETag ヘッダーを付けてユーザー情報を返します
+func writeUser(c *gin.Context, status int, user db.User) {
+	c.Header("ETag", userETag(user))
+	c.JSON(status, toUserResponse(user))
+}
+
+// notModified は If-None-Match が現在の ETag と一致する場合に 304 を返して true を返します
+// If-None-Match は弱い比較で判定します（RFC 9110 13.1.2）
+func notModified(c *gin.Context, user db.User) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !etagListContains(header, userETag(user), false) {
+		return false
+	}
+	c.Header("ETag", userETag(user))
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch は If-Match ヘッダーを現在のユーザーと比較し、更新時に指定するバージョンを返します
+// 一致しない場合は 412、必須の設定でヘッダーがない場合は 428 を返して ok=false を返します
+// ヘッダーがなく必須でもない場合は、バージョンを指定しない（無条件で更新する）値を返します
+func checkIfMatch(c *gin.Context, user db.User, required bool) (expected sql.NullInt32, ok bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if required {
+			c.Error(apperror.New(apperror.CodePreconditionRequired, i18n.IfMatchRequired))
+			return expected, false
+		}
+		return expected, true
+	}
+	// If-Match は強い比較で判定します（RFC 9110 13.1.1）
+	if !etagListContains(header, userETag(user), true) {
+		c.Header("ETag", userETag(user))
+		c.Error(apperror.New(apperror.CodePreconditionFailed, i18n.UserModified))
+		return expected, false
+	}
+	return sql.NullInt32{Int32: user.Version, Valid: true}, true
+}
+
+// etagListContains はカンマ区切りの ETag の一覧に etag が含まれるかを返します
+// strong が true の場合、弱いETag（W/ で始まるもの）は一致とみなしません
+func etagListContains(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keys *util.KeySet
+}
+
+func NewJWKSHandler(keys *util.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// RegisterRoutes は公開鍵配布用のルートを登録します
+func (h *JWKSHandler) RegisterRoutes(r gin.IRouter) {
+	r.GET("/.well-known/jwks.json", h.GetJWKS)
+}
+
+// GetJWKS はトークン検証用の公開鍵をJWKS形式で返します
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
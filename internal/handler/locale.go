@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localize はメッセージをリクエストの言語で返します
+func localize(c *gin.Context, msg i18n.Message, args ...interface{}) string {
+	return i18n.T(middleware.RequestLocale(c), msg, args...)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/handler/dto"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MeHandler は認証済みユーザー自身のプロフィールを扱うハンドラーです
+// 対象のユーザーは AuthRequired がコンテキストに設定した userID で決まります
+type MeHandler struct {
+	users     *service.UserService
+	passwords *service.PasswordService
+}
+
+func NewMeHandler(users *service.UserService, passwords *service.PasswordService) *MeHandler {
+	return &MeHandler{
+		users:     users,
+		passwords: passwords,
+	}
+}
+
+// RegisterRoutes は自分自身のプロフィール関連のルートを登録します
+func (h *MeHandler) RegisterRoutes(r gin.IRouter) {
+	me := r.Group("/me")
+	{
+		me.GET("", h.GetMe)
+		me.PATCH("", h.UpdateMe)
+		me.POST("/password", h.ChangePassword)
+		me.DELETE("", h.DeleteMe)
+	}
+}
+
+// GetMe は自分自身のユーザー情報を取得します
+func (h *MeHandler) GetMe(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+
+	user, err := h.users.Get(c, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// UpdateMe は自分自身のプロフィールを部分的に更新します
+func (h *MeHandler) UpdateMe(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+
+	var req dto.UpdateMeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	user, err := h.users.UpdateProfile(c, userID, service.ProfileChanges{
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Locale:    req.Locale,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// ChangePassword は現在のパスワードを確認した上でパスワードを変更します
+// 変更後は全てのトークンを失効させ、再度のログインを求めます
+func (h *MeHandler) ChangePassword(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	if err := h.passwords.Change(c, userID, req.CurrentPassword, req.NewPassword); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.PasswordChanged)})
+}
+
+// DeleteMe はパスワードを確認した上で自分自身のアカウントを削除します
+// アカウントは論理削除され、保持期間内であれば管理者が復元できます
+func (h *MeHandler) DeleteMe(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+
+	var req dto.DeleteMeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	if err := h.users.DeleteAccount(c, userID, req.Password); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.AccountDeleted)})
+}
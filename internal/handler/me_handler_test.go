@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/handler/dto"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestMeHandler はモックを使用する MeHandler を作成します
+// 使用しない revocations には nil を指定します
+func newTestMeHandler(queries db.Store, revocations util.RevocationStore) *MeHandler {
+	cfg := &config.Config{}
+	return &MeHandler{
+		users:     service.NewUserService(queries, revocations, nil, service.SystemClock{}, cfg),
+		passwords: service.NewPasswordService(queries, revocations, service.SystemClock{}, cfg),
+	}
+}
+
+func TestUpdateMe(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	user := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		FirstName: "Test",
+		LastName:  "User",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusSuspended, Valid: true},
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    dto.UpdateMeRequest
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "名前のみの更新ではステータスとメールアドレスを維持する",
+			requestBody: dto.UpdateMeRequest{FirstName: "Updated"},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UpdateUser", mock.Anything, db.UpdateUserParams{
+					ID:        1,
+					Email:     "test@example.com",
+					FirstName: "Updated",
+					LastName:  "User",
+					Status:    user.Status,
+				}).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "確認していないメールアドレスへの変更",
+			requestBody: dto.UpdateMeRequest{Email: "other@example.com"},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "メールアドレスは変更できません。変更が必要な場合は管理者に依頼してください",
+		},
+		{
+			name:        "現在と同じメールアドレスはそのまま受け付ける",
+			requestBody: dto.UpdateMeRequest{Email: "test@example.com", LastName: "Updated"},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UpdateUser", mock.Anything, db.UpdateUserParams{
+					ID:        1,
+					Email:     "test@example.com",
+					FirstName: "Test",
+					LastName:  "Updated",
+					Status:    user.Status,
+				}).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "ユーザーが存在しない",
+			requestBody: dto.UpdateMeRequest{FirstName: "Updated"},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(db.User{}, sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "ユーザーが見つかりません",
+		},
+		{
+			name:        "言語の設定を変更する",
+			requestBody: dto.UpdateMeRequest{Locale: "en"},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UpdateUser", mock.Anything, mock.AnythingOfType("db.UpdateUserParams")).Return(int64(1), nil)
+				m.On("UpdateUserLocale", mock.Anything, db.UpdateUserLocaleParams{
+					ID:     1,
+					Locale: sql.NullString{String: "en", Valid: true},
+				}).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "対応していない言語",
+			requestBody:    dto.UpdateMeRequest{Locale: "fr"},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "入力内容に誤りがあります",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestMeHandler(mockQueries, nil)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("userID", int64(1))
+
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/api/me", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.UpdateMe(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用のパスワードハッシュを生成
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal("パスワードのハッシュ化に失敗しました:", err)
+	}
+
+	user := db.User{
+		ID:           1,
+		Email:        "test@example.com",
+		PasswordHash: string(hashedPassword),
+		Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    dto.ChangePasswordRequest
+		setupMock      func(*MockQueries, *MockRevocationStore)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "正常なパスワード変更",
+			requestBody: dto.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword123"},
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UpdateUserPassword", mock.Anything, mock.MatchedBy(func(arg db.UpdateUserPasswordParams) bool {
+					return arg.ID == 1 && bcrypt.CompareHashAndPassword([]byte(arg.PasswordHash), []byte("newpassword123")) == nil
+				})).Return(nil)
+				// 発行済みのパスワードリセット用のリンクは使用できなくする
+				m.On("DeleteUserPasswordResets", mock.Anything, int64(1)).Return(nil)
+				m.On("RevokeUserRefreshTokens", mock.Anything, int64(1)).Return(nil)
+				r.On("RevokeAllForUser", mock.Anything, int64(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "現在のパスワードが正しくない",
+			requestBody: dto.ChangePasswordRequest{CurrentPassword: "wrongpassword", NewPassword: "newpassword123"},
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "現在のパスワードが正しくありません",
+		},
+		{
+			name:           "新しいパスワードが短すぎる",
+			requestBody:    dto.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "short"},
+			setupMock:      func(m *MockQueries, r *MockRevocationStore) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "入力内容に誤りがあります",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			mockRevocations := new(MockRevocationStore)
+			tt.setupMock(mockQueries, mockRevocations)
+
+			// ハンドラーの準備
+			handler := newTestMeHandler(mockQueries, mockRevocations)
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("userID", int64(1))
+
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/me/password", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.ChangePassword(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfa *service.MFAService
+}
+
+func NewMFAHandler(mfa *service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfa: mfa,
+	}
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// RegisterRoutes は二要素認証関連のルートを登録します
+func (h *MFAHandler) RegisterRoutes(r gin.IRouter) {
+	mfa := r.Group("/mfa")
+	{
+		mfa.POST("/totp/setup", h.SetupTOTP)
+		mfa.POST("/totp/confirm", h.ConfirmTOTP)
+		mfa.DELETE("/totp", h.DisableTOTP)
+		mfa.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+}
+
+// SetupTOTP はTOTPシークレットを生成し、認証アプリ登録用のURIを返します
+// 確認コードで ConfirmTOTP を呼び出すまで二要素認証は有効になりません
+func (h *MFAHandler) SetupTOTP(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+
+	setup, err := h.mfa.SetupTOTP(c, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPSetupResponse{
+		Secret:     setup.Secret,
+		OTPAuthURI: setup.OTPAuthURI,
+	})
+}
+
+// ConfirmTOTP は認証アプリが生成したコードを確認して二要素認証を有効にし、リカバリーコードを発行します
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	codes, err := h.mfa.ConfirmTOTP(c, c.MustGet("userID").(int64), req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP はパスワードを確認して二要素認証を無効にします
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	var req DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	if err := h.mfa.DisableTOTP(c, c.MustGet("userID").(int64), req.Password); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.MFADisabled)})
+}
+
+// RegenerateRecoveryCodes は認証コードを確認してリカバリーコードを再発行します
+// 以前のリカバリーコードは全て無効になります
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	codes, err := h.mfa.RegenerateRecoveryCodes(c, c.MustGet("userID").(int64), req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
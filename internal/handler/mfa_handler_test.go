@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConfirmTOTP(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	// テスト用の時間を設定
+	now := time.Now()
+
+	const secret = "JBSWY3DPEHPK3PXP"
+	validCode, err := util.GenerateTOTPCode(secret, now)
+	if err != nil {
+		t.Fatal("認証コードの生成に失敗しました:", err)
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    TOTPCodeRequest
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "正しい確認コード",
+			requestBody: TOTPCodeRequest{Code: validCode},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{UserID: 1, Secret: secret}, nil)
+				m.On("ConfirmUserTOTP", mock.Anything, mock.AnythingOfType("db.ConfirmUserTOTPParams")).Return(nil)
+				m.On("DeleteRecoveryCodes", mock.Anything, int64(1)).Return(nil)
+				m.On("CreateRecoveryCode", mock.Anything, mock.AnythingOfType("db.CreateRecoveryCodeParams")).Return(nil).Times(10)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "誤った確認コード",
+			requestBody: TOTPCodeRequest{Code: "000000"},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{UserID: 1, Secret: secret}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "認証コードが正しくありません",
+		},
+		{
+			name:        "セットアップ前",
+			requestBody: TOTPCodeRequest{Code: validCode},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{}, sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "二要素認証のセットアップが開始されていません",
+		},
+		{
+			name:        "既に有効",
+			requestBody: TOTPCodeRequest{Code: validCode},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{
+					UserID:      1,
+					Secret:      secret,
+					ConfirmedAt: sql.NullTime{Time: now, Valid: true},
+				}, nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "二要素認証は既に有効です",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := NewMFAHandler(service.NewMFAService(mockQueries, service.SystemClock{}))
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("userID", int64(1))
+
+			// リクエストボディの準備
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/mfa/totp/confirm", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			// ハンドラーの実行
+			handler.ConfirmTOTP(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+			} else {
+				var response RecoveryCodesResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response.RecoveryCodes, 10)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageLimit は一覧取得で一度に返す最大件数です
+const maxPageLimit = 1000
+
+// pageCursor はキーセットページネーションの位置を表します
+// クライアントには base64url でエンコードした不透明な文字列として渡します
+type pageCursor struct {
+	// Sort はカーソルを発行したときの sort パラメータです
+	Sort string `json:"sort,omitempty"`
+	// Keys はページの境界となるユーザーの並び替えキーです
+	Keys []string `json:"keys"`
+	// Before が true の場合は境界より前のページ、false の場合は後のページを指します
+	Before bool `json:"before,omitempty"`
+}
+
+// encodeCursor はカーソルを不透明な文字列に変換します
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor は encodeCursor で生成された文字列をカーソルに戻します
+func decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	if len(cursor.Keys) == 0 {
+		return cursor, errors.New("cursor has no keys")
+	}
+	return cursor, nil
+}
+
+// setPaginationLinks は RFC 8288 の Link ヘッダーに next/prev のリンクを設定します
+// リンクはリクエストのURLを元に、offset を除いて cursor を差し替えたものです
+func setPaginationLinks(c *gin.Context, nextCursor, prevCursor string) {
+	var links []string
+	for _, link := range []struct{ rel, cursor string }{
+		{"next", nextCursor},
+		{"prev", prevCursor},
+	} {
+		if link.cursor == "" {
+			continue
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, cursorURL(c.Request.URL, link.cursor), link.rel))
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+// cursorURL はリクエストのURLの cursor パラメータを差し替えたURLを返します
+func cursorURL(base *url.URL, cursor string) string {
+	query := base.Query()
+	query.Del("offset")
+	query.Set("cursor", cursor)
+	u := url.URL{Path: base.Path, RawQuery: query.Encode()}
+	return u.String()
+}
@@ -1,31 +1,23 @@
 package handler
 
 import (
-	"crypto/rand"
-	"database/sql"
-	"encoding/hex"
 	"net/http"
-	"time"
 
-	db "go-gin-sqlc/db/sqlc"
-	"go-gin-sqlc/internal/config"
-	"go-gin-sqlc/internal/util"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type PasswordHandler struct {
-	queries db.Querier
-	config  *config.Config
-	mailer  util.Mailer
+	passwords *service.PasswordService
 }
 
-func NewPasswordHandler(sqlDB *sql.DB, cfg *config.Config) *PasswordHandler {
+func NewPasswordHandler(passwords *service.PasswordService) *PasswordHandler {
 	return &PasswordHandler{
-		queries: db.New(sqlDB),
-		config:  cfg,
-		mailer:  util.NewSMTPMailer(cfg.Mail),
+		passwords: passwords,
 	}
 }
 
@@ -48,101 +40,35 @@ func (h *PasswordHandler) RegisterRoutes(r gin.IRouter) {
 }
 
 // RequestPasswordReset はパスワードリセットのリクエストを処理します
+// セキュリティのため、ユーザーが存在しない場合でも成功レスポンスを返します
 func (h *PasswordHandler) RequestPasswordReset(c *gin.Context) {
 	var req RequestPasswordResetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// ユーザーの存在確認
-	user, err := h.queries.GetUserByEmail(c, req.Email)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			// セキュリティのため、ユーザーが存在しない場合でも成功レスポンスを返す
-			c.JSON(http.StatusOK, gin.H{"message": "パスワードリセットメールを送信しました"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.passwords.RequestReset(c, req.Email, middleware.RequestLocale(c)); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// トークンの生成
-	token := make([]byte, 32)
-	if _, err := rand.Read(token); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの生成に失敗しました"})
-		return
-	}
-	tokenStr := hex.EncodeToString(token)
-
-	// パスワードリセットレコードの作成
-	expiresAt := time.Now().Add(24 * time.Hour)
-	_, err = h.queries.CreatePasswordReset(c, db.CreatePasswordResetParams{
-		UserID:    user.ID,
-		Token:     tokenStr,
-		ExpiresAt: expiresAt,
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// リセットURLの生成
-	resetURL := h.config.BaseURL + "/reset-password?token=" + tokenStr
-
-	// メールの送信
-	mailBody := util.GeneratePasswordResetEmail(resetURL)
-	err = h.mailer.SendMail(user.Email, "パスワードリセットのリクエスト", mailBody)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "メールの送信に失敗しました"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "パスワードリセットメールを送信しました"})
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.PasswordResetEmailSent)})
 }
 
 // ResetPassword はパスワードのリセットを処理します
+// トークンは1回だけ使用でき、リセット後は全てのトークンを失効させて再度のログインを求めます
 func (h *PasswordHandler) ResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// トークンの検証
-	reset, err := h.queries.GetPasswordResetByToken(c, req.Token)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "無効なトークンです"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// パスワードのハッシュ化
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードのハッシュ化に失敗しました"})
-		return
-	}
-
-	// パスワードの更新
-	err = h.queries.UpdateUserPassword(c, db.UpdateUserPasswordParams{
-		ID:           reset.UserID,
-		PasswordHash: string(hashedPassword),
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// 使用済みトークンの削除
-	err = h.queries.DeletePasswordReset(c, req.Token)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.passwords.Reset(c, req.Token, req.Password); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "パスワードを更新しました"})
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.PasswordUpdated)})
 }
@@ -6,11 +6,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	db "go-gin-sqlc/db/sqlc"
 	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
 	"go-gin-sqlc/internal/util"
 
 	"github.com/gin-gonic/gin"
@@ -33,14 +37,15 @@ func (m *MockSQLResult) RowsAffected() (int64, error) {
 	return args.Get(0).(int64), args.Error(1)
 }
 
-// MockMailer はメール送信のモックです
-type MockMailer struct {
-	mock.Mock
-}
-
-func (m *MockMailer) SendMail(to, subject, body string) error {
-	args := m.Called(to, subject, body)
-	return args.Error(0)
+// enqueuedMail は送信待ちに追加するメールの宛先・テンプレート・言語が一致するかを判定するマッチャーです
+// match を指定した場合は、JSONで保存されたテンプレートのデータも判定します
+func enqueuedMail(to, template string, locale i18n.Locale, match func(data json.RawMessage) bool) interface{} {
+	return mock.MatchedBy(func(arg db.EnqueueEmailParams) bool {
+		if arg.Recipient != to || arg.Template != template || arg.Locale != string(locale) {
+			return false
+		}
+		return match == nil || match(arg.Data)
+	})
 }
 
 func TestRequestPasswordReset(t *testing.T) {
@@ -51,79 +56,141 @@ func TestRequestPasswordReset(t *testing.T) {
 	now := time.Now()
 
 	tests := []struct {
-		name           string
-		requestBody    RequestPasswordResetRequest
-		setupMock      func(*MockQueries, *MockMailer)
-		expectedStatus int
-		expectedError  string
+		name            string
+		requestBody     RequestPasswordResetRequest
+		acceptLanguage  string
+		setupMock       func(*MockQueries)
+		expectedStatus  int
+		expectedError   string
+		expectedMessage string
 	}{
 		{
 			name: "正常なリクエスト",
 			requestBody: RequestPasswordResetRequest{
 				Email: "test@example.com",
 			},
-			setupMock: func(m *MockQueries, mailer *MockMailer) {
+			setupMock: func(m *MockQueries) {
 				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(db.User{
 					ID:        1,
 					Email:     "test@example.com",
 					CreatedAt: now,
 				}, nil)
 
-				mockResult := new(MockSQLResult)
-				mockResult.On("LastInsertId").Return(int64(1), nil)
-				m.On("CreatePasswordReset", mock.Anything, mock.AnythingOfType("db.CreatePasswordResetParams")).Return(mockResult, nil)
-
-				mailer.On("SendMail",
-					"test@example.com",
-					"パスワードリセットのリクエスト",
-					mock.AnythingOfType("string"),
-				).Return(nil)
+				// 以前に発行したトークンは使用できなくする
+				m.On("DeleteUserPasswordResets", mock.Anything, int64(1)).Return(nil)
+
+				// データベースにはメールで送るトークンのハッシュだけを保存する
+				var tokenHash string
+				m.On("CreatePasswordReset", mock.Anything, mock.MatchedBy(func(arg db.CreatePasswordResetParams) bool {
+					tokenHash = arg.TokenHash
+					return arg.UserID == 1 && len(arg.TokenHash) == 64 &&
+						arg.ExpiresAt.Sub(now) > 119*time.Minute && arg.ExpiresAt.Sub(now) <= 121*time.Minute
+				})).Return(new(MockSQLResult), nil)
+
+				m.On("EnqueueEmail", mock.Anything, enqueuedMail("test@example.com", util.MailTemplatePasswordReset, i18n.Japanese, func(data json.RawMessage) bool {
+					var mail util.PasswordResetMail
+					if json.Unmarshal(data, &mail) != nil {
+						return false
+					}
+					token := strings.TrimPrefix(mail.ResetURL, "http://localhost:8080/reset-password?token=")
+					return util.HashToken(token) == tokenHash && token != tokenHash && !mail.ExpiresAt.IsZero()
+				})).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "送信待ちへの追加に失敗した場合はエラー",
+			requestBody: RequestPasswordResetRequest{
+				Email: "test@example.com",
+			},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(db.User{
+					ID:        1,
+					Email:     "test@example.com",
+					CreatedAt: now,
+				}, nil)
+				m.On("DeleteUserPasswordResets", mock.Anything, int64(1)).Return(nil)
+				m.On("CreatePasswordReset", mock.Anything, mock.AnythingOfType("db.CreatePasswordResetParams")).Return(new(MockSQLResult), nil)
+				m.On("EnqueueEmail", mock.Anything, mock.AnythingOfType("db.EnqueueEmailParams")).Return(sql.ErrConnDone)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
 		{
 			name: "無効なメールアドレス",
 			requestBody: RequestPasswordResetRequest{
 				Email: "invalid-email",
 			},
-			setupMock:      func(m *MockQueries, mailer *MockMailer) {},
+			setupMock:      func(m *MockQueries) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'RequestPasswordResetRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag",
+			expectedError:  "入力内容に誤りがあります",
 		},
 		{
 			name: "存在しないユーザー",
 			requestBody: RequestPasswordResetRequest{
 				Email: "nonexistent@example.com",
 			},
-			setupMock: func(m *MockQueries, mailer *MockMailer) {
+			setupMock: func(m *MockQueries) {
 				m.On("GetUserByEmail", mock.Anything, "nonexistent@example.com").Return(db.User{}, sql.ErrNoRows)
 			},
 			expectedStatus: http.StatusOK, // セキュリティのため、成功レスポンスを返す
 		},
+		{
+			name: "ユーザーの言語設定でメールを送信する",
+			requestBody: RequestPasswordResetRequest{
+				Email: "test@example.com",
+			},
+			acceptLanguage: "ja",
+			setupMock: func(m *MockQueries) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(db.User{
+					ID:        1,
+					Email:     "test@example.com",
+					Locale:    sql.NullString{String: "en", Valid: true},
+					CreatedAt: now,
+				}, nil)
+
+				m.On("DeleteUserPasswordResets", mock.Anything, int64(1)).Return(nil)
+				m.On("CreatePasswordReset", mock.Anything, mock.AnythingOfType("db.CreatePasswordResetParams")).Return(new(MockSQLResult), nil)
+
+				m.On("EnqueueEmail", mock.Anything, enqueuedMail("test@example.com", util.MailTemplatePasswordReset, i18n.English, func(data json.RawMessage) bool {
+					var mail util.PasswordResetMail
+					return json.Unmarshal(data, &mail) == nil &&
+						strings.HasPrefix(mail.ResetURL, "http://localhost:8080/reset-password?token=")
+				})).Return(nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedMessage: "パスワードリセットメールを送信しました",
+		},
+		{
+			name: "Accept-Languageの言語でメッセージを返す",
+			requestBody: RequestPasswordResetRequest{
+				Email: "invalid-email",
+			},
+			acceptLanguage: "en-US,en;q=0.9,ja;q=0.8",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "The input is invalid",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// モックの準備
 			mockQueries := new(MockQueries)
-			mockMailer := new(MockMailer)
-			tt.setupMock(mockQueries, mockMailer)
+			tt.setupMock(mockQueries)
 
 			// ハンドラーの準備
-			handler := &PasswordHandler{
-				queries: mockQueries,
-				config: &config.Config{
-					BaseURL: "http://localhost:8080",
-					Mail: util.MailConfig{
-						Host:     "smtp.example.com",
-						Port:     25,
-						Username: "test",
-						Password: "test",
-						From:     "noreply@example.com",
-					},
+			cfg := &config.Config{
+				BaseURL:          "http://localhost:8080",
+				PasswordResetTTL: 2 * time.Hour,
+				Mail: util.MailConfig{
+					Host:     "smtp.example.com",
+					Port:     25,
+					Username: "test",
+					Password: "test",
+					From:     "noreply@example.com",
 				},
-				mailer: mockMailer,
 			}
+			handler := NewPasswordHandler(service.NewPasswordService(mockQueries, nil, service.SystemClock{}, cfg))
 
 			// HTTPリクエストの準備
 			w := httptest.NewRecorder()
@@ -133,23 +200,33 @@ func TestRequestPasswordReset(t *testing.T) {
 			jsonData, _ := json.Marshal(tt.requestBody)
 			c.Request = httptest.NewRequest(http.MethodPost, "/passwords/reset-request", bytes.NewBuffer(jsonData))
 			c.Request.Header.Set("Content-Type", "application/json")
+			if tt.acceptLanguage != "" {
+				c.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
 
 			// ハンドラーの実行
 			handler.RequestPasswordReset(c)
+			middleware.RenderErrors(c)
 
 			// アサーション
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Contains(t, response.Detail, tt.expectedError)
+			}
+
+			if tt.expectedMessage != "" {
 				var response map[string]string
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Contains(t, response["error"], tt.expectedError)
+				assert.Equal(t, tt.expectedMessage, response["message"])
 			}
 
 			// モックの検証
 			mockQueries.AssertExpectations(t)
-			mockMailer.AssertExpectations(t)
 		})
 	}
 }
@@ -165,7 +242,7 @@ func TestResetPassword(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    ResetPasswordRequest
-		setupMock      func(*MockQueries)
+		setupMock      func(*MockQueries, *MockRevocationStore)
 		expectedStatus int
 		expectedError  string
 	}{
@@ -175,15 +252,21 @@ func TestResetPassword(t *testing.T) {
 				Token:    "valid-token",
 				Password: "newpassword123",
 			},
-			setupMock: func(m *MockQueries) {
-				m.On("GetPasswordResetByToken", mock.Anything, "valid-token").Return(db.GetPasswordResetByTokenRow{
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				// トークンはハッシュで検索する
+				m.On("GetPasswordResetByHash", mock.Anything, util.HashToken("valid-token")).Return(db.PasswordReset{
+					ID:        1,
 					UserID:    1,
-					Token:     "valid-token",
+					TokenHash: util.HashToken("valid-token"),
 					ExpiresAt: expiresAt,
 				}, nil)
 
 				m.On("UpdateUserPassword", mock.Anything, mock.AnythingOfType("db.UpdateUserPasswordParams")).Return(nil)
-				m.On("DeletePasswordReset", mock.Anything, "valid-token").Return(nil)
+				// 使用したトークンを含め、ユーザーのトークンをすべて削除する
+				m.On("DeleteUserPasswordResets", mock.Anything, int64(1)).Return(nil)
+				// 既存のセッションはすべて失効させる
+				m.On("RevokeUserRefreshTokens", mock.Anything, int64(1)).Return(nil)
+				r.On("RevokeAllForUser", mock.Anything, int64(1)).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -193,21 +276,37 @@ func TestResetPassword(t *testing.T) {
 				Token:    "invalid-token",
 				Password: "newpassword123",
 			},
-			setupMock: func(m *MockQueries) {
-				m.On("GetPasswordResetByToken", mock.Anything, "invalid-token").Return(db.GetPasswordResetByTokenRow{}, sql.ErrNoRows)
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				m.On("GetPasswordResetByHash", mock.Anything, util.HashToken("invalid-token")).Return(db.PasswordReset{}, sql.ErrNoRows)
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  "無効なトークンです",
 		},
+		{
+			name: "パスワードの更新に失敗した場合はトークンを残す",
+			requestBody: ResetPasswordRequest{
+				Token:    "valid-token",
+				Password: "newpassword123",
+			},
+			setupMock: func(m *MockQueries, r *MockRevocationStore) {
+				m.On("GetPasswordResetByHash", mock.Anything, util.HashToken("valid-token")).Return(db.PasswordReset{
+					ID:        1,
+					UserID:    1,
+					ExpiresAt: expiresAt,
+				}, nil)
+				m.On("UpdateUserPassword", mock.Anything, mock.AnythingOfType("db.UpdateUserPasswordParams")).Return(sql.ErrConnDone)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
 		{
 			name: "パスワードが短すぎる",
 			requestBody: ResetPasswordRequest{
 				Token:    "valid-token",
 				Password: "short",
 			},
-			setupMock:      func(m *MockQueries) {},
+			setupMock:      func(m *MockQueries, r *MockRevocationStore) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'ResetPasswordRequest.Password' Error:Field validation for 'Password' failed on the 'min' tag",
+			expectedError:  "入力内容に誤りがあります",
 		},
 	}
 
@@ -215,15 +314,11 @@ func TestResetPassword(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// モックの準備
 			mockQueries := new(MockQueries)
-			mockMailer := new(MockMailer)
-			tt.setupMock(mockQueries)
+			mockRevocations := new(MockRevocationStore)
+			tt.setupMock(mockQueries, mockRevocations)
 
 			// ハンドラーの準備
-			handler := &PasswordHandler{
-				queries: mockQueries,
-				config:  &config.Config{},
-				mailer:  mockMailer,
-			}
+			handler := NewPasswordHandler(service.NewPasswordService(mockQueries, mockRevocations, service.SystemClock{}, &config.Config{}))
 
 			// HTTPリクエストの準備
 			w := httptest.NewRecorder()
@@ -236,19 +331,21 @@ func TestResetPassword(t *testing.T) {
 
 			// ハンドラーの実行
 			handler.ResetPassword(c)
+			middleware.RenderErrors(c)
 
 			// アサーション
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			if tt.expectedError != "" {
-				var response map[string]string
+				var response middleware.Problem
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Contains(t, response["error"], tt.expectedError)
+				assert.Contains(t, response.Detail, tt.expectedError)
 			}
 
 			// モックの検証
 			mockQueries.AssertExpectations(t)
+			mockRevocations.AssertExpectations(t)
 		})
 	}
 }
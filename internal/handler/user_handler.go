@@ -1,27 +1,49 @@
 package handler
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/config"
 	"go-gin-sqlc/internal/handler/dto"
-
-	"golang.org/x/crypto/bcrypt"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// mergePatchContentType は JSON Merge Patch (RFC 7396) のメディアタイプです
+	mergePatchContentType = "application/merge-patch+json"
+	// jsonPatchContentType は JSON Patch (RFC 6902) のメディアタイプです
+	jsonPatchContentType = "application/json-patch+json"
 )
 
+// minSearchQueryLength は検索キーワードの最小文字数です（ngramのトークンサイズに合わせる）
+const minSearchQueryLength = 2
+
 type UserHandler struct {
-	queries db.Querier
+	users  *service.UserService
+	config *config.Config
 }
 
-func NewUserHandler(sqlDB *sql.DB) *UserHandler {
+func NewUserHandler(users *service.UserService, cfg *config.Config) *UserHandler {
 	return &UserHandler{
-		queries: db.New(sqlDB),
+		users:  users,
+		config: cfg,
 	}
 }
 
@@ -29,12 +51,17 @@ func NewUserHandler(sqlDB *sql.DB) *UserHandler {
 func (h *UserHandler) RegisterRoutes(r gin.IRouter) {
 	users := r.Group("/users")
 	{
-		users.POST("", h.CreateUser)
-		users.GET("", h.ListUsers)
+		users.POST("", middleware.RequirePermission(util.PermissionUsersCreate), h.CreateUser)
+		users.GET("", middleware.RequirePermission(util.PermissionUsersRead), h.ListUsers)
+		// 自分自身のレコードは権限がなくても参照・編集できるため、ハンドラー内で判定する
 		users.GET("/:id", h.GetUser)
 		users.PUT("/:id", h.UpdateUser)
-		users.DELETE("/:id", h.DeleteUser)
-		users.GET("/search", h.SearchUsers)
+		users.PATCH("/:id", h.PatchUser)
+		users.DELETE("/:id", middleware.RequirePermission(util.PermissionUsersDelete), h.DeleteUser)
+		users.POST("/:id/unlock", middleware.RequirePermission(util.PermissionUsersUnlock), h.UnlockUser)
+		users.GET("/search", middleware.RequirePermission(util.PermissionUsersRead), h.SearchUsers)
+		users.GET("/deleted", middleware.RequirePermission(util.PermissionUsersDelete), h.ListDeletedUsers)
+		users.POST("/:id/restore", middleware.RequirePermission(util.PermissionUsersDelete), h.RestoreUser)
 	}
 }
 
@@ -42,79 +69,142 @@ func (h *UserHandler) RegisterRoutes(r gin.IRouter) {
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req dto.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// パスワードのハッシュ化
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	user, err := h.users.Create(c, service.CreateUserInput{
+		Email:     req.Email,
+		Password:  req.Password,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードのハッシュ化に失敗しました"})
+		c.Error(err)
 		return
 	}
 
-	// ユーザーの作成
-	result, err := h.queries.CreateUser(c, db.CreateUserParams{
-		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	writeUser(c, http.StatusCreated, user)
+}
+
+// ListUsers は絞り込み条件と並び順を指定してユーザー一覧を取得します
+// cursor が指定された場合はキーセット方式、それ以外は従来どおり offset 方式でページングします
+// どちらの方式でも次ページ・前ページのカーソルを next_cursor/prev_cursor と Link ヘッダーで返します
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 0 || limit > maxPageLimit {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidLimit))
 		return
 	}
 
-	// 作成されたユーザーIDの取得
-	id, err := result.LastInsertId()
+	includeTotal, err := strconv.ParseBool(c.DefaultQuery("include_total", "true"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーIDの取得に失敗しました"})
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidIncludeTotal))
 		return
 	}
 
-	// 作成されたユーザーの取得
-	user, err := h.queries.GetUser(c, id)
+	sortParam := c.Query("sort")
+	sorts, err := parseUserSort(sortParam)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー情報の取得に失敗しました"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, toUserResponse(user))
-}
-
-// ListUsers はユーザー一覧を取得します
-func (h *UserHandler) ListUsers(c *gin.Context) {
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 0 || limit > math.MaxInt32 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なlimitパラメータ"})
+	filter, err := parseUserFilter(c.QueryMap("filter"))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	if err != nil || offset < 0 || offset > math.MaxInt32 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なoffsetパラメータ"})
-		return
+	// 次のページの有無を判定するため、1件多く取得する
+	params := db.FilterUsersParams{
+		Filter: filter,
+		Sort:   sorts,
+		Limit:  int32(limit + 1),
+	}
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		// 並び順が変わるとカーソルの位置が意味を持たなくなるため、発行時と同じ sort のみ受け付ける
+		if err != nil || cursor.Sort != sortParam {
+			c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidCursor))
+			return
+		}
+		params.Keyset = &db.UserKeyset{Values: cursor.Keys, Before: cursor.Before}
+	} else {
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 || offset > math.MaxInt32 {
+			c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidOffset))
+			return
+		}
+		params.Offset = int32(offset)
 	}
 
-	users, err := h.queries.ListUsers(c, db.ListUsersParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	users, err := h.users.List(c, params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	// 取得方向の先にまだユーザーがいるかどうか。反対方向は境界やoffsetの位置から判断する
+	hasMore := len(users) > limit
+	var hasNext, hasPrev bool
+	switch {
+	case params.Keyset == nil:
+		if hasMore {
+			users = users[:limit]
+		}
+		hasNext, hasPrev = hasMore, params.Offset > 0
+	case params.Keyset.Before:
+		// 境界に近い側（末尾）を残す
+		if hasMore {
+			users = users[len(users)-limit:]
+		}
+		hasNext, hasPrev = true, hasMore
+	default:
+		if hasMore {
+			users = users[:limit]
+		}
+		hasNext, hasPrev = hasMore, true
+	}
+
 	response := dto.UsersResponse{
 		Users: make([]dto.UserResponse, len(users)),
-		Total: len(users),
 	}
 
 	for i, user := range users {
 		response.Users[i] = toUserResponse(user)
 	}
 
+	if len(users) > 0 {
+		if hasNext {
+			keys, err := db.UserSortKey(users[len(users)-1], sorts)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			response.NextCursor = encodeCursor(pageCursor{Sort: sortParam, Keys: keys})
+		}
+		if hasPrev {
+			keys, err := db.UserSortKey(users[0], sorts)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			response.PrevCursor = encodeCursor(pageCursor{Sort: sortParam, Keys: keys, Before: true})
+		}
+	}
+	setPaginationLinks(c, response.NextCursor, response.PrevCursor)
+
+	// 件数の集計は大きなテーブルでは重いため、不要な場合は省略できる
+	if includeTotal {
+		total, err := h.users.Count(c, filter)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		response.Total = &total
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -122,165 +212,445 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なユーザーID"})
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidUserID))
 		return
 	}
 
-	user, err := h.queries.GetUser(c, id)
+	if !canAccessUser(c, id, util.PermissionUsersRead) {
+		c.Error(apperror.New(apperror.CodeForbidden, i18n.Forbidden))
+		return
+	}
+
+	user, err := h.users.Get(c, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	if notModified(c, user) {
 		return
 	}
 
-	c.JSON(http.StatusOK, toUserResponse(user))
+	writeUser(c, http.StatusOK, user)
 }
 
 // UpdateUser は指定されたIDのユーザー情報を更新します
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なユーザーID"})
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidUserID))
+		return
+	}
+
+	if !canAccessUser(c, id, util.PermissionUsersUpdate) {
+		c.Error(apperror.New(apperror.CodeForbidden, i18n.Forbidden))
 		return
 	}
 
 	var req dto.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.Bind(err))
+		return
+	}
+
+	// ステータスの変更は管理者のみ許可する
+	if req.Status != "" && !middleware.HasPermission(c, util.PermissionUsersUpdate) {
+		c.Error(apperror.New(apperror.CodeForbidden, i18n.StatusChangeForbidden))
 		return
 	}
 
 	// 現在のユーザー情報を取得
-	currentUser, err := h.queries.GetUser(c, id)
+	currentUser, err := h.users.Get(c, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	expectedVersion, ok := checkIfMatch(c, currentUser, h.requireIfMatch())
+	if !ok {
 		return
 	}
 
-	// 更新パラメータの準備
-	params := db.UpdateUserParams{
-		ID:        id,
+	// 空の値は現在の値を使用
+	changes := service.UserChanges{
 		Email:     req.Email,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatus(req.Status), Valid: req.Status != ""},
 	}
+	if !changes.Status.Valid {
+		changes.Status = currentUser.Status
+	}
+	if changes.Email == "" {
+		changes.Email = currentUser.Email
+	}
+	if changes.FirstName == "" {
+		changes.FirstName = currentUser.FirstName
+	}
+	if changes.LastName == "" {
+		changes.LastName = currentUser.LastName
+	}
 
-	// 空の値は現在の値を使用
-	if params.Email == "" {
-		params.Email = currentUser.Email
+	updatedUser, err := h.users.Update(c, currentUser, changes, expectedVersion)
+	if err != nil {
+		c.Error(err)
+		return
 	}
-	if params.FirstName == "" {
-		params.FirstName = currentUser.FirstName
+
+	writeUser(c, http.StatusOK, updatedUser)
+}
+
+// PatchUser は JSON Merge Patch (RFC 7396) または JSON Patch (RFC 6902) でユーザー情報を部分更新します
+// 現在のユーザー情報にパッチを適用した結果を UpdateUserRequest と同じ規則で検証してから保存します
+// PUT と異なり空文字は「変更なし」ではなくそのまま保存されるため、名前を空にすることもできます
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidUserID))
+		return
 	}
-	if params.LastName == "" {
-		params.LastName = currentUser.LastName
+
+	if !canAccessUser(c, id, util.PermissionUsersUpdate) {
+		c.Error(apperror.New(apperror.CodeForbidden, i18n.Forbidden))
+		return
 	}
 
-	// ユーザー情報の更新
-	err = h.queries.UpdateUser(c, params)
+	var applyPatch func(doc, patch []byte) ([]byte, error)
+	switch c.ContentType() {
+	case mergePatchContentType:
+		applyPatch = util.MergePatch
+	case jsonPatchContentType:
+		applyPatch = util.ApplyJSONPatch
+	default:
+		c.Header("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		c.Error(apperror.New(apperror.CodeUnsupportedMediaType, i18n.UnsupportedContentType))
+		return
+	}
+
+	patch, err := c.GetRawData()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apperror.Bind(err))
 		return
 	}
 
-	// 更新後のユーザー情報を取得
-	updatedUser, err := h.queries.GetUser(c, id)
+	currentUser, err := h.users.Get(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新後のユーザー情報の取得に失敗しました"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, toUserResponse(updatedUser))
+	expectedVersion, ok := checkIfMatch(c, currentUser, h.requireIfMatch())
+	if !ok {
+		return
+	}
+
+	// パッチは更新可能な項目だけのドキュメントに適用する
+	current := dto.UpdateUserRequest{
+		Email:     currentUser.Email,
+		FirstName: currentUser.FirstName,
+		LastName:  currentUser.LastName,
+		Status:    string(currentUser.Status.UsersStatus),
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	patched, err := applyPatch(doc, patch)
+	if err != nil {
+		if errors.Is(err, util.ErrPatchConflict) {
+			c.Error(apperror.Newf(apperror.CodeConflict, i18n.PatchConflict, err))
+			return
+		}
+		c.Error(apperror.Newf(apperror.CodeInvalidRequest, i18n.InvalidPatch, err))
+		return
+	}
+
+	// 更新できない項目（パスワードなど）が追加された場合はエラーにする
+	var req dto.UpdateUserRequest
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		c.Error(apperror.Bind(err))
+		return
+	}
+	// メールアドレスとステータスは削除できない
+	if req.Email == "" {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.EmailNotRemovable))
+		return
+	}
+	if req.Status == "" && current.Status != "" {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.StatusNotRemovable))
+		return
+	}
+
+	// ステータスの変更は管理者のみ許可する
+	if req.Status != current.Status && !middleware.HasPermission(c, util.PermissionUsersUpdate) {
+		c.Error(apperror.New(apperror.CodeForbidden, i18n.StatusChangeForbidden))
+		return
+	}
+
+	updatedUser, err := h.users.Update(c, currentUser, service.UserChanges{
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatus(req.Status), Valid: req.Status != ""},
+	}, expectedVersion)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	writeUser(c, http.StatusOK, updatedUser)
 }
 
-// DeleteUser は指定されたIDのユーザーを削除します
+// DeleteUser は指定されたIDのユーザーを論理削除します
+// 削除されたユーザーは保持期間内であれば RestoreUser で復元できます
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なユーザーID"})
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidUserID))
 		return
 	}
 
-	err = h.queries.DeleteUser(c, id)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+	// If-Match が指定された場合は、現在のバージョンと一致するときだけ削除する
+	var expectedVersion sql.NullInt32
+	if c.GetHeader("If-Match") != "" || h.requireIfMatch() {
+		user, err := h.users.Get(c, id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		var ok bool
+		if expectedVersion, ok = checkIfMatch(c, user, h.requireIfMatch()); !ok {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+
+	if err := h.users.Delete(c, id, expectedVersion); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ユーザーを削除しました"})
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.UserDeleted)})
 }
 
-// SearchUsers はユーザーを検索します
+// ListDeletedUsers は削除済みのユーザー一覧を削除日時の新しい順に取得します
+func (h *UserHandler) ListDeletedUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 0 || limit > maxPageLimit {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidLimit))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 || offset > math.MaxInt32 {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidOffset))
+		return
+	}
+
+	users, total, err := h.users.ListDeleted(c, int32(limit), int32(offset))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := dto.UsersResponse{
+		Users: make([]dto.UserResponse, len(users)),
+		Total: &total,
+	}
+
+	for i, user := range users {
+		response.Users[i] = toUserResponse(user)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreUser は保持期間内に削除されたユーザーを復元します
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidUserID))
+		return
+	}
+
+	user, err := h.users.Restore(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	writeUser(c, http.StatusOK, user)
+}
+
+// UnlockUser はログイン失敗によるアカウントのロックを解除します
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidUserID))
+		return
+	}
+
+	if err := h.users.Unlock(c, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": localize(c, i18n.AccountUnlocked)})
+}
+
+// SearchUsers はメールアドレスと氏名の全文検索でユーザーを検索します
+// 結果は関連度の高い順に並び、totalには条件に一致する全件数が入ります
 func (h *UserHandler) SearchUsers(c *gin.Context) {
-	query := c.Query("q")
+	query := strings.TrimSpace(c.Query("q"))
+	if query != "" && utf8.RuneCountInString(query) < minSearchQueryLength {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.SearchQueryTooShort))
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && !isValidUserStatus(status) {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidStatus))
+		return
+	}
+
 	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 0 || limit > math.MaxInt32 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なlimitパラメータ"})
+	if err != nil || limit < 0 || limit > maxPageLimit {
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidLimit))
 		return
 	}
 
 	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	if err != nil || offset < 0 || offset > math.MaxInt32 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なoffsetパラメータ"})
+		c.Error(apperror.New(apperror.CodeInvalidRequest, i18n.InvalidOffset))
 		return
 	}
 
-	users, err := h.queries.ListUsers(c, db.ListUsersParams{
+	users, total, err := h.users.Search(c, service.SearchUsersInput{
+		Query:  query,
+		Status: db.NullUsersStatus{UsersStatus: db.UsersStatus(status), Valid: status != ""},
 		Limit:  int32(limit),
 		Offset: int32(offset),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	// クライアントサイドでフィルタリング
-	filteredUsers := users
-	if query != "" {
-		filteredUsers = make([]db.User, 0)
-		queryLower := strings.ToLower(query)
-		for _, user := range users {
-			if strings.Contains(strings.ToLower(user.Email), queryLower) ||
-				strings.Contains(strings.ToLower(user.FirstName), queryLower) ||
-				strings.Contains(strings.ToLower(user.LastName), queryLower) {
-				filteredUsers = append(filteredUsers, user)
-			}
-		}
-	}
-
 	response := dto.UsersResponse{
-		Users: make([]dto.UserResponse, len(filteredUsers)),
-		Total: len(filteredUsers),
+		Users: make([]dto.UserResponse, len(users)),
+		Total: &total,
 	}
 
-	for i, user := range filteredUsers {
+	for i, user := range users {
 		response.Users[i] = toUserResponse(user)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// parseUserSort は sort パラメータを並び順に変換します
+// 列名をカンマ区切りで指定し、先頭に - を付けると降順になります（例: -created_at,email）
+func parseUserSort(param string) ([]db.UserSort, error) {
+	if param == "" {
+		return nil, nil
+	}
+	var sorts []db.UserSort
+	seen := make(map[db.UserSortField]bool)
+	for _, item := range strings.Split(param, ",") {
+		item = strings.TrimSpace(item)
+		sort := db.UserSort{Field: db.UserSortField(strings.TrimPrefix(item, "-")), Desc: strings.HasPrefix(item, "-")}
+		if !sort.Field.Valid() || seen[sort.Field] {
+			return nil, apperror.InvalidField("sort", "invalid", i18n.InvalidSort, item)
+		}
+		seen[sort.Field] = true
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// parseUserFilter は filter[項目名] 形式のパラメータを絞り込み条件に変換します
+func parseUserFilter(params map[string]string) (db.UserFilter, error) {
+	var filter db.UserFilter
+	for key, value := range params {
+		value = strings.TrimSpace(value)
+		invalid := apperror.InvalidField("filter["+key+"]", "invalid", i18n.InvalidFilter, key)
+		switch key {
+		case "status":
+			if !isValidUserStatus(value) {
+				return filter, invalid
+			}
+			filter.Status = db.NullUsersStatus{UsersStatus: db.UsersStatus(value), Valid: true}
+		case "created_after", "created_before":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, invalid
+			}
+			if key == "created_after" {
+				filter.CreatedAfter = sql.NullTime{Time: t, Valid: true}
+			} else {
+				filter.CreatedBefore = sql.NullTime{Time: t, Valid: true}
+			}
+		case "email_domain":
+			if value == "" || strings.Contains(value, "@") {
+				return filter, invalid
+			}
+			filter.EmailDomain = value
+		case "name_prefix":
+			if value == "" {
+				return filter, invalid
+			}
+			filter.NamePrefix = value
+		default:
+			return filter, invalid
+		}
+	}
+	return filter, nil
+}
+
+// isValidUserStatus はユーザーステータスとして有効な値かを返します
+func isValidUserStatus(status string) bool {
+	switch db.UsersStatus(status) {
+	case db.UsersStatusActive, db.UsersStatusInactive, db.UsersStatusSuspended, db.UsersStatusPendingVerification:
+		return true
+	}
+	return false
+}
+
+// requireIfMatch は更新・削除時に If-Match ヘッダーを必須とするかを返します
+func (h *UserHandler) requireIfMatch() bool {
+	return h.config != nil && h.config.RequireIfMatch
+}
+
+// canAccessUser は自分自身のレコード、または指定された権限を持つ場合にアクセスを許可します
+func canAccessUser(c *gin.Context, id int64, permission string) bool {
+	if userID, ok := c.Get("userID"); ok && userID.(int64) == id {
+		return true
+	}
+	return middleware.HasPermission(c, permission)
+}
+
 // toUserResponse はデータベースのユーザーモデルをレスポンス用の構造体に変換します
 func toUserResponse(user db.User) dto.UserResponse {
-	return dto.UserResponse{
+	response := dto.UserResponse{
 		ID:        user.ID,
 		Email:     user.Email,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
 		Status:    string(user.Status.UsersStatus),
+		Locale:    user.Locale.String,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
+	if user.DeletedAt.Valid {
+		response.DeletedAt = &user.DeletedAt.Time
+	}
+	return response
 }
@@ -0,0 +1,927 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/handler/dto"
+	"go-gin-sqlc/internal/middleware"
+	"go-gin-sqlc/internal/service"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestUserHandler はモックを使用する UserHandler を作成します
+// アクセストークンの失効は常に成功します
+func newTestUserHandler(queries db.Store, cfg *config.Config) *UserHandler {
+	revocations := new(MockRevocationStore)
+	revocations.On("RevokeAllForUser", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return &UserHandler{
+		users:  service.NewUserService(queries, revocations, nil, service.SystemClock{}, cfg),
+		config: cfg,
+	}
+}
+
+func TestUserHandlerAuthorization(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now()
+	adminPermissions := util.PermissionSet{
+		util.PermissionUsersRead:   {},
+		util.PermissionUsersCreate: {},
+		util.PermissionUsersUpdate: {},
+		util.PermissionUsersDelete: {},
+		util.PermissionUsersUnlock: {},
+	}
+	user := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		FirstName: "Test",
+		LastName:  "User",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	other := user
+	other.ID = 2
+	other.Email = "other@example.com"
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           interface{}
+		permissions    util.PermissionSet
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "一般ユーザーが自分のレコードを参照",
+			method:      http.MethodGet,
+			path:        "/api/users/1",
+			permissions: util.PermissionSet{},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "一般ユーザーが他のユーザーを参照",
+			method:         http.MethodGet,
+			path:           "/api/users/2",
+			permissions:    util.PermissionSet{},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "この操作を行う権限がありません",
+		},
+		{
+			name:        "管理者が他のユーザーを参照",
+			method:      http.MethodGet,
+			path:        "/api/users/2",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(2)).Return(other, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "一般ユーザーがユーザー一覧を取得",
+			method:         http.MethodGet,
+			path:           "/api/users",
+			permissions:    util.PermissionSet{},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "この操作を行う権限がありません",
+		},
+		{
+			name:        "一般ユーザーが自分の名前を変更",
+			method:      http.MethodPut,
+			path:        "/api/users/1",
+			body:        map[string]string{"first_name": "Updated"},
+			permissions: util.PermissionSet{},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UpdateUser", mock.Anything, mock.MatchedBy(func(arg db.UpdateUserParams) bool {
+					// 指定されていないステータスは現在の値のまま更新する
+					return arg.ID == 1 && arg.FirstName == "Updated" && arg.Status == user.Status
+				})).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "一般ユーザーが自分のステータスを変更",
+			method:         http.MethodPut,
+			path:           "/api/users/1",
+			body:           map[string]string{"status": "active"},
+			permissions:    util.PermissionSet{},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "ステータスを変更する権限がありません",
+		},
+		{
+			name:           "一般ユーザーが他のユーザーを更新",
+			method:         http.MethodPut,
+			path:           "/api/users/2",
+			body:           map[string]string{"first_name": "Updated"},
+			permissions:    util.PermissionSet{},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "この操作を行う権限がありません",
+		},
+		{
+			name:           "一般ユーザーがユーザーを削除",
+			method:         http.MethodDelete,
+			path:           "/api/users/1",
+			permissions:    util.PermissionSet{},
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "この操作を行う権限がありません",
+		},
+		{
+			name:        "管理者がユーザーを削除",
+			method:      http.MethodDelete,
+			path:        "/api/users/2",
+			permissions: adminPermissions,
+			setupMock: func(m *MockQueries) {
+				m.On("DeleteUser", mock.Anything, db.DeleteUserParams{ID: 2}).Return(int64(1), nil)
+				m.On("RevokeUserRefreshTokens", mock.Anything, int64(2)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestUserHandler(mockQueries, &config.Config{})
+
+			// AuthRequired と LoadPermissions の代わりにユーザーIDと権限を設定する
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(middleware.ErrorHandler())
+			api := r.Group("/api", func(c *gin.Context) {
+				c.Set("userID", int64(1))
+				c.Set("permissions", tt.permissions)
+			})
+			handler.RegisterRoutes(api)
+
+			// HTTPリクエストの準備
+			var body bytes.Buffer
+			if tt.body != nil {
+				json.NewEncoder(&body).Encode(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, &body)
+			req.Header.Set("Content-Type", "application/json")
+
+			// リクエストの実行
+			r.ServeHTTP(w, req)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	retention := 30 * 24 * time.Hour
+	user := db.User{
+		ID:     2,
+		Email:  "other@example.com",
+		Status: db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+	}
+
+	// 保持期間の起点が現在時刻から計算されていることを確認する
+	withinRetention := mock.MatchedBy(func(arg db.RestoreUserParams) bool {
+		cutoff := time.Now().Add(-retention)
+		return arg.ID == 2 && arg.DeletedAfter.Valid && cutoff.Sub(arg.DeletedAfter.Time) < time.Minute
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:   "削除済みのユーザーの削除",
+			method: http.MethodDelete,
+			path:   "/api/users/2",
+			setupMock: func(m *MockQueries) {
+				m.On("DeleteUser", mock.Anything, db.DeleteUserParams{ID: 2}).Return(int64(0), nil)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "ユーザーが見つかりません",
+		},
+		{
+			name:   "保持期間内のユーザーの復元",
+			method: http.MethodPost,
+			path:   "/api/users/2/restore",
+			setupMock: func(m *MockQueries) {
+				m.On("RestoreUser", mock.Anything, withinRetention).Return(int64(1), nil)
+				m.On("GetUser", mock.Anything, int64(2)).Return(user, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "保持期間を過ぎたユーザーの復元",
+			method: http.MethodPost,
+			path:   "/api/users/2/restore",
+			setupMock: func(m *MockQueries) {
+				m.On("RestoreUser", mock.Anything, withinRetention).Return(int64(0), nil)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "復元できる削除済みのユーザーが見つかりません",
+		},
+		{
+			name:   "削除済みのユーザー一覧",
+			method: http.MethodGet,
+			path:   "/api/users/deleted?limit=5",
+			setupMock: func(m *MockQueries) {
+				deleted := user
+				deleted.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				m.On("ListDeletedUsers", mock.Anything, db.ListDeletedUsersParams{Limit: 5, Offset: 0}).Return([]db.User{deleted}, nil)
+				m.On("CountDeletedUsers", mock.Anything).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "上限を超える件数の削除済みのユーザー一覧",
+			method:         http.MethodGet,
+			path:           "/api/users/deleted?limit=1001",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なlimitパラメータ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestUserHandler(mockQueries, &config.Config{UserRetention: retention})
+
+			// 管理者としてリクエストする
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(middleware.ErrorHandler())
+			api := r.Group("/api", func(c *gin.Context) {
+				c.Set("userID", int64(1))
+				c.Set("permissions", util.PermissionSet{util.PermissionUsersDelete: {}})
+			})
+			handler.RegisterRoutes(api)
+
+			// リクエストの実行
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	createdAt := time.Date(2024, 1, 23, 12, 0, 0, 0, time.UTC)
+	users := []db.User{
+		{ID: 1, Email: "a@example.com", CreatedAt: createdAt},
+		{ID: 2, Email: "b@example.com", CreatedAt: createdAt},
+		{ID: 3, Email: "c@example.com", CreatedAt: createdAt},
+	}
+	byCreated := []db.UserSort{{Field: db.UserSortCreatedAt, Desc: true}, {Field: db.UserSortEmail}}
+	total := int64(3)
+
+	nextByID := encodeCursor(pageCursor{Keys: []string{"2"}})
+	prevByID := encodeCursor(pageCursor{Keys: []string{"3"}, Before: true})
+	nextByCreated := encodeCursor(pageCursor{Sort: "-created_at,email", Keys: []string{"2024-01-23T12:00:00Z", "b@example.com", "2"}})
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedIDs    []int64
+		expectedTotal  *int64
+		expectedNext   string
+		expectedPrev   string
+		expectedLink   string
+		expectedError  string
+	}{
+		{
+			name:  "オフセット方式の先頭ページ",
+			query: "?limit=2",
+			setupMock: func(m *MockQueries) {
+				m.On("FilterUsers", mock.Anything, db.FilterUsersParams{Limit: 3}).Return(users, nil)
+				m.On("CountFilteredUsers", mock.Anything, db.UserFilter{}).Return(total, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{1, 2},
+			expectedTotal:  &total,
+			expectedNext:   nextByID,
+			expectedLink:   `</api/users?cursor=` + nextByID + `&limit=2>; rel="next"`,
+		},
+		{
+			name:  "カーソルで次のページを取得",
+			query: "?limit=2&include_total=false&cursor=" + nextByID,
+			setupMock: func(m *MockQueries) {
+				m.On("FilterUsers", mock.Anything, db.FilterUsersParams{
+					Keyset: &db.UserKeyset{Values: []string{"2"}},
+					Limit:  3,
+				}).Return(users[2:], nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{3},
+			expectedPrev:   prevByID,
+			expectedLink:   `</api/users?cursor=` + prevByID + `&include_total=false&limit=2>; rel="prev"`,
+		},
+		{
+			name:  "カーソルで前のページを取得",
+			query: "?limit=1&include_total=false&cursor=" + prevByID,
+			setupMock: func(m *MockQueries) {
+				m.On("FilterUsers", mock.Anything, db.FilterUsersParams{
+					Keyset: &db.UserKeyset{Values: []string{"3"}, Before: true},
+					Limit:  2,
+				}).Return(users[:2], nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{2},
+			expectedNext:   nextByID,
+			expectedPrev:   encodeCursor(pageCursor{Keys: []string{"2"}, Before: true}),
+		},
+		{
+			name:  "並び順と絞り込み条件を指定",
+			query: "?limit=2&sort=-created_at,email&filter[status]=active&filter[email_domain]=example.com",
+			setupMock: func(m *MockQueries) {
+				filter := db.UserFilter{
+					Status:      db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+					EmailDomain: "example.com",
+				}
+				m.On("FilterUsers", mock.Anything, db.FilterUsersParams{Filter: filter, Sort: byCreated, Limit: 3}).Return(users, nil)
+				m.On("CountFilteredUsers", mock.Anything, filter).Return(total, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{1, 2},
+			expectedTotal:  &total,
+			expectedNext:   nextByCreated,
+		},
+		{
+			name:           "並び順が異なるカーソル",
+			query:          "?sort=email&cursor=" + nextByCreated,
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なcursorパラメータ",
+		},
+		{
+			name:           "無効なカーソル",
+			query:          "?cursor=invalid",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なcursorパラメータ",
+		},
+		{
+			name:           "並び替えできない項目",
+			query:          "?sort=-created_at,password_hash",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なsortパラメータ: password_hash",
+		},
+		{
+			name:           "絞り込みできない項目",
+			query:          "?filter[password_hash]=x",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なfilter[password_hash]パラメータ",
+		},
+		{
+			name:           "日時の形式が不正な絞り込み条件",
+			query:          "?filter[created_after]=2024-01-01",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なfilter[created_after]パラメータ",
+		},
+		{
+			name:           "上限を超えるlimit",
+			query:          "?limit=1001",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なlimitパラメータ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestUserHandler(mockQueries, &config.Config{})
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/users"+tt.query, nil)
+
+			// ハンドラーの実行
+			handler.ListUsers(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			} else {
+				var response dto.UsersResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+
+				ids := make([]int64, len(response.Users))
+				for i, user := range response.Users {
+					ids[i] = user.ID
+				}
+				assert.Equal(t, tt.expectedIDs, ids)
+				assert.Equal(t, tt.expectedTotal, response.Total)
+				assert.Equal(t, tt.expectedNext, response.NextCursor)
+				assert.Equal(t, tt.expectedPrev, response.PrevCursor)
+				if tt.expectedLink != "" {
+					assert.Equal(t, tt.expectedLink, w.Header().Get("Link"))
+				}
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	user := db.User{
+		ID:     1,
+		Email:  "tanaka@example.com",
+		Status: db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+	}
+	active := db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedTotal  int64
+		expectedError  string
+	}{
+		{
+			name:  "キーワードとステータスで検索",
+			query: "?q=tanaka&status=active&limit=1",
+			setupMock: func(m *MockQueries) {
+				m.On("SearchUsers", mock.Anything, db.SearchUsersParams{Query: `"tanaka"`, Status: active, Limit: 1}).Return([]db.User{user}, nil)
+				m.On("CountSearchUsers", mock.Anything, db.CountSearchUsersParams{Query: `"tanaka"`, Status: active}).Return(int64(3), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedTotal:  3,
+		},
+		{
+			name:  "引用符は演算子として解釈させない",
+			query: `?q=` + "%22tanaka%22+-yamada",
+			setupMock: func(m *MockQueries) {
+				m.On("SearchUsers", mock.Anything, db.SearchUsersParams{Query: `"tanaka  -yamada"`, Limit: 10}).Return([]db.User{}, nil)
+				m.On("CountSearchUsers", mock.Anything, db.CountSearchUsersParams{Query: `"tanaka  -yamada"`}).Return(int64(0), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedTotal:  0,
+		},
+		{
+			name:  "キーワードなしは全件を対象にする",
+			query: "",
+			setupMock: func(m *MockQueries) {
+				m.On("SearchUsers", mock.Anything, db.SearchUsersParams{Limit: 10}).Return([]db.User{user}, nil)
+				m.On("CountSearchUsers", mock.Anything, db.CountSearchUsersParams{}).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedTotal:  1,
+		},
+		{
+			name:           "1文字のキーワード",
+			query:          "?q=a",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "検索キーワードは2文字以上で指定してください",
+		},
+		{
+			name:           "無効なステータス",
+			query:          "?q=tanaka&status=unknown",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なstatusパラメータ",
+		},
+		{
+			name:           "上限を超える件数",
+			query:          "?q=tanaka&limit=1001",
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "無効なlimitパラメータ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestUserHandler(mockQueries, &config.Config{})
+
+			// HTTPリクエストの準備
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/users/search"+tt.query, nil)
+
+			// ハンドラーの実行
+			handler.SearchUsers(c)
+			middleware.RenderErrors(c)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			} else {
+				var response dto.UsersResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				if assert.NotNil(t, response.Total) {
+					assert.Equal(t, tt.expectedTotal, *response.Total)
+				}
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	active := db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true}
+	user := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		FirstName: "Test",
+		LastName:  "User",
+		Status:    active,
+	}
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:        "Merge Patchで名を空にする",
+			contentType: "application/merge-patch+json",
+			body:        `{"first_name": ""}`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UpdateUser", mock.Anything, db.UpdateUserParams{
+					ID:        1,
+					Email:     "test@example.com",
+					FirstName: "",
+					LastName:  "User",
+					Status:    active,
+				}).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "JSON Patchでメールアドレスを変更",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "test", "path": "/email", "value": "test@example.com"}, {"op": "replace", "path": "/email", "value": "new@example.com"}]`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UserEmailExists", mock.Anything, "new@example.com").Return(false, nil)
+				m.On("UpdateUser", mock.Anything, db.UpdateUserParams{
+					ID:        1,
+					Email:     "new@example.com",
+					FirstName: "Test",
+					LastName:  "User",
+					Status:    active,
+				}).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "JSON Patchのtestが一致しない",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "test", "path": "/email", "value": "old@example.com"}]`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "ステータスを削除",
+			contentType: "application/merge-patch+json",
+			body:        `{"status": null}`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "ステータスは削除できません",
+		},
+		{
+			name:        "更新できない項目を追加",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "add", "path": "/password_hash", "value": "x"}]`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "無効なメールアドレス",
+			contentType: "application/merge-patch+json",
+			body:        `{"email": "invalid"}`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "サポートされていないContent-Type",
+			contentType:    "application/json",
+			body:           `{"first_name": ""}`,
+			setupMock:      func(m *MockQueries) {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedError:  "サポートされていないContent-Typeです",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestUserHandler(mockQueries, &config.Config{})
+
+			// 管理者としてリクエストする
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(middleware.ErrorHandler())
+			api := r.Group("/api", func(c *gin.Context) {
+				c.Set("userID", int64(99))
+				c.Set("permissions", util.PermissionSet{util.PermissionUsersUpdate: {}})
+			})
+			handler.RegisterRoutes(api)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/users/1", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			r.ServeHTTP(w, req)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserConditionalRequests(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	user := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		FirstName: "Test",
+		LastName:  "User",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+		Version:   3,
+	}
+	updatedUser := user
+	updatedUser.FirstName = "Updated"
+	updatedUser.Version = 4
+	expected := sql.NullInt32{Int32: 3, Valid: true}
+
+	tests := []struct {
+		name           string
+		method         string
+		headers        map[string]string
+		body           string
+		requireIfMatch bool
+		setupMock      func(*MockQueries)
+		expectedStatus int
+		expectedETag   string
+		expectedError  string
+	}{
+		{
+			name:   "取得時にETagを返す",
+			method: http.MethodGet,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"3"`,
+		},
+		{
+			name:    "If-None-Matchが一致すれば304",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `W/"3"`},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   `"3"`,
+		},
+		{
+			name:    "If-Matchが一致すればバージョンを指定して更新",
+			method:  http.MethodPut,
+			headers: map[string]string{"If-Match": `"3"`},
+			body:    `{"first_name": "Updated"}`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil).Once()
+				m.On("UpdateUser", mock.Anything, mock.MatchedBy(func(arg db.UpdateUserParams) bool {
+					return arg.ExpectedVersion == expected
+				})).Return(int64(1), nil)
+				m.On("GetUser", mock.Anything, int64(1)).Return(updatedUser, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			name:    "If-Matchが古ければ412",
+			method:  http.MethodPut,
+			headers: map[string]string{"If-Match": `"2"`},
+			body:    `{"first_name": "Updated"}`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedETag:   `"3"`,
+			expectedError:  "ユーザー情報が他の操作で更新されています",
+		},
+		{
+			name:    "弱いETagはIf-Matchで一致とみなさない",
+			method:  http.MethodPut,
+			headers: map[string]string{"If-Match": `W/"3"`},
+			body:    `{"first_name": "Updated"}`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError:  "ユーザー情報が他の操作で更新されています",
+		},
+		{
+			name:    "取得後に他の操作で更新された場合は412",
+			method:  http.MethodPut,
+			headers: map[string]string{"If-Match": `"3"`},
+			body:    `{"first_name": "Updated"}`,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("UpdateUser", mock.Anything, mock.Anything).Return(int64(0), nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError:  "ユーザー情報が他の操作で更新されています",
+		},
+		{
+			name:           "必須の設定でIf-Matchがなければ428",
+			method:         http.MethodPut,
+			body:           `{"first_name": "Updated"}`,
+			requireIfMatch: true,
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+			},
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedError:  "If-Matchヘッダーが必要です",
+		},
+		{
+			name:    "If-Matchを指定して削除",
+			method:  http.MethodDelete,
+			headers: map[string]string{"If-Match": `"3"`},
+			setupMock: func(m *MockQueries) {
+				m.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+				m.On("DeleteUser", mock.Anything, db.DeleteUserParams{ID: 1, ExpectedVersion: expected}).Return(int64(1), nil)
+				m.On("RevokeUserRefreshTokens", mock.Anything, int64(1)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			mockQueries := new(MockQueries)
+			tt.setupMock(mockQueries)
+
+			// ハンドラーの準備
+			handler := newTestUserHandler(mockQueries, &config.Config{RequireIfMatch: tt.requireIfMatch})
+
+			// 管理者としてリクエストする
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(middleware.ErrorHandler())
+			api := r.Group("/api", func(c *gin.Context) {
+				c.Set("userID", int64(99))
+				c.Set("permissions", util.PermissionSet{
+					util.PermissionUsersRead:   {},
+					util.PermissionUsersUpdate: {},
+					util.PermissionUsersDelete: {},
+				})
+			})
+			handler.RegisterRoutes(api)
+
+			req := httptest.NewRequest(tt.method, "/api/users/1", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			r.ServeHTTP(w, req)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedETag != "" {
+				assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			}
+
+			if tt.expectedError != "" {
+				var response middleware.Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+
+			// モックの検証
+			mockQueries.AssertExpectations(t)
+		})
+	}
+}
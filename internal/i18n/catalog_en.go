@@ -0,0 +1,110 @@
+package i18n
+
+// en は英語のメッセージカタログです
+var en = map[Message]string{
+	// エラーメッセージ
+	InvalidRequestBody:           "The request is malformed",
+	ValidationFailed:             "The input is invalid",
+	InvalidValueType:             "The value has an invalid type",
+	ResourceNotFound:             "The requested resource was not found",
+	DuplicateEntry:               "A record with the same value already exists",
+	RowIsReferenced:              "The request cannot be processed because related data exists",
+	NoReferencedRow:              "The request cannot be processed because related data was not found",
+	InternalError:                "An error occurred on the server",
+	MissingAuthHeader:            "The Authorization header is missing",
+	InvalidAuthScheme:            "Invalid authorization scheme",
+	InvalidToken:                 "Invalid token",
+	InvalidTokenLoginAgain:       "Invalid token. Please log in again",
+	TokenRevoked:                 "This token has been revoked",
+	Forbidden:                    "You do not have permission to perform this operation",
+	StatusChangeForbidden:        "You do not have permission to change the status",
+	InvalidCredentials:           "Incorrect email address or password",
+	AccountDisabled:              "This account is disabled",
+	EmailNotVerified:             "The email address has not been verified",
+	AccountLocked:                "The account is temporarily locked due to repeated failed login attempts",
+	LoginThrottled:               "Please wait a while and try again",
+	InvalidRefreshToken:          "Invalid refresh token",
+	RefreshTokenExpired:          "The refresh token has expired",
+	RefreshTokenReused:           "The refresh token was reused. Please log in again",
+	InvalidVerificationLink:      "The verification link is invalid or has expired",
+	TokenGenerationFailed:        "Failed to generate a token",
+	TokenRevocationFailed:        "Failed to revoke the tokens",
+	IncorrectPassword:            "Incorrect password",
+	IncorrectCurrentPassword:     "The current password is incorrect",
+	PasswordHashFailed:           "Failed to hash the password",
+	InvalidMFACode:               "The authentication code is incorrect",
+	MFAAlreadyEnabled:            "Two-factor authentication is already enabled",
+	MFANotEnabled:                "Two-factor authentication is not enabled",
+	MFASetupNotStarted:           "Two-factor authentication setup has not been started",
+	SecretGenerationFailed:       "Failed to generate a secret",
+	RecoveryCodeGenerationFailed: "Failed to generate recovery codes",
+	UserNotFound:                 "User not found",
+	RestorableUserNotFound:       "No restorable deleted user was found",
+	InvalidUserID:                "Invalid user ID",
+	EmailAlreadyRegistered:       "This email address is already registered",
+	EmailChangeNotAllowed:        "The email address cannot be changed. Ask an administrator if it needs to be changed",
+	EmailNotRemovable:            "The email address cannot be removed",
+	StatusNotRemovable:           "The status cannot be removed",
+	UserModified:                 "The user has been modified by another request",
+	IfMatchRequired:              "The If-Match header is required",
+	UserIDFetchFailed:            "Failed to get the user ID",
+	UserFetchFailed:              "Failed to fetch the user",
+	UpdatedUserFetchFailed:       "Failed to fetch the updated user",
+	InvalidEmailID:               "Invalid email ID",
+	OutboxEmailNotFound:          "Email not found",
+	EmailNotRequeueable:          "Only emails whose delivery has been stopped can be requeued",
+	EmailContentDiscarded:        "This email contained a link that has been discarded and cannot be requeued. Ask the user to repeat the action",
+	UnsupportedContentType:       "Unsupported Content-Type",
+	InvalidPatch:                 "Invalid patch: %s",
+	PatchConflict:                "The patch cannot be applied: %s",
+	InvalidLimit:                 "Invalid limit parameter",
+	InvalidOffset:                "Invalid offset parameter",
+	InvalidCursor:                "Invalid cursor parameter",
+	InvalidIncludeTotal:          "Invalid include_total parameter",
+	InvalidStatus:                "Invalid status parameter",
+	InvalidSort:                  "Invalid sort parameter: %s",
+	InvalidFilter:                "Invalid filter[%s] parameter",
+	SearchQueryTooShort:          "The search query must be at least 2 characters",
+
+	// 入力項目ごとの検証エラー
+	FieldRequired: "This field is required",
+	FieldEmail:    "Must be a valid email address",
+	FieldMin:      "Must be at least %s characters",
+	FieldMax:      "Must be at most %s characters",
+	FieldLen:      "Must be exactly %s characters",
+	FieldNumeric:  "Must be numeric",
+	FieldOneOf:    "Must be one of: %s",
+	FieldInvalid:  "Invalid value",
+
+	// エラーコードごとのタイトル
+	TitleInvalidRequest:       "Bad request",
+	TitleValidation:           "Validation failed",
+	TitleUnauthorized:         "Unauthorized",
+	TitleEmailNotVerified:     "Email not verified",
+	TitleForbidden:            "Forbidden",
+	TitleNotFound:             "Not found",
+	TitleAlreadyExists:        "Already exists",
+	TitleConflict:             "Conflict",
+	TitlePreconditionFailed:   "Precondition failed",
+	TitleUnsupportedMediaType: "Unsupported media type",
+	TitlePreconditionRequired: "Precondition required",
+	TitleAccountLocked:        "Account locked",
+	TitleLoginThrottled:       "Too many attempts",
+	TitleInternal:             "Internal server error",
+
+	// 処理結果のメッセージ
+	RegistrationEmailSent:  "A verification email has been sent. Follow the link in the email to complete your registration",
+	VerificationEmailSent:  "A verification email has been sent",
+	EmailAlreadyVerified:   "The email address has already been verified",
+	EmailVerified:          "The email address has been verified",
+	LoggedOut:              "Logged out",
+	LoggedOutAll:           "Logged out from all devices",
+	PasswordChanged:        "The password has been changed. Please log in again",
+	PasswordResetEmailSent: "A password reset email has been sent",
+	PasswordUpdated:        "The password has been updated",
+	AccountDeleted:         "The account has been deleted",
+	MFADisabled:            "Two-factor authentication has been disabled",
+	UserDeleted:            "The user has been deleted",
+	AccountUnlocked:        "The account has been unlocked",
+	EmailRequeued:          "The email has been queued for delivery",
+}
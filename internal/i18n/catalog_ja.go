@@ -0,0 +1,110 @@
+package i18n
+
+// ja は日本語のメッセージカタログです
+var ja = map[Message]string{
+	// エラーメッセージ
+	InvalidRequestBody:           "リクエストの形式が正しくありません",
+	ValidationFailed:             "入力内容に誤りがあります",
+	InvalidValueType:             "値の型が正しくありません",
+	ResourceNotFound:             "指定されたリソースが見つかりません",
+	DuplicateEntry:               "同じ値のデータが既に登録されています",
+	RowIsReferenced:              "関連するデータが存在するため処理できません",
+	NoReferencedRow:              "関連するデータが見つからないため処理できません",
+	InternalError:                "サーバーでエラーが発生しました",
+	MissingAuthHeader:            "認証ヘッダーがありません",
+	InvalidAuthScheme:            "無効な認証形式です",
+	InvalidToken:                 "無効なトークンです",
+	InvalidTokenLoginAgain:       "無効なトークンです。再度ログインしてください",
+	TokenRevoked:                 "このトークンは失効しています",
+	Forbidden:                    "この操作を行う権限がありません",
+	StatusChangeForbidden:        "ステータスを変更する権限がありません",
+	InvalidCredentials:           "メールアドレスまたはパスワードが正しくありません",
+	AccountDisabled:              "このアカウントは無効です",
+	EmailNotVerified:             "メールアドレスの確認が完了していません",
+	AccountLocked:                "ログインの失敗が続いたため、アカウントを一時的にロックしています",
+	LoginThrottled:               "しばらく時間をおいてから再度お試しください",
+	InvalidRefreshToken:          "無効なリフレッシュトークンです",
+	RefreshTokenExpired:          "リフレッシュトークンの有効期限が切れています",
+	RefreshTokenReused:           "リフレッシュトークンが再利用されました。再度ログインしてください",
+	InvalidVerificationLink:      "無効または期限切れの確認リンクです",
+	TokenGenerationFailed:        "トークンの生成に失敗しました",
+	TokenRevocationFailed:        "トークンの失効に失敗しました",
+	IncorrectPassword:            "パスワードが正しくありません",
+	IncorrectCurrentPassword:     "現在のパスワードが正しくありません",
+	PasswordHashFailed:           "パスワードのハッシュ化に失敗しました",
+	InvalidMFACode:               "認証コードが正しくありません",
+	MFAAlreadyEnabled:            "二要素認証は既に有効です",
+	MFANotEnabled:                "二要素認証が有効になっていません",
+	MFASetupNotStarted:           "二要素認証のセットアップが開始されていません",
+	SecretGenerationFailed:       "シークレットの生成に失敗しました",
+	RecoveryCodeGenerationFailed: "リカバリーコードの生成に失敗しました",
+	UserNotFound:                 "ユーザーが見つかりません",
+	RestorableUserNotFound:       "復元できる削除済みのユーザーが見つかりません",
+	InvalidUserID:                "無効なユーザーID",
+	EmailAlreadyRegistered:       "このメールアドレスは既に登録されています",
+	EmailChangeNotAllowed:        "メールアドレスは変更できません。変更が必要な場合は管理者に依頼してください",
+	EmailNotRemovable:            "メールアドレスは削除できません",
+	StatusNotRemovable:           "ステータスは削除できません",
+	UserModified:                 "ユーザー情報が他の操作で更新されています",
+	IfMatchRequired:              "If-Matchヘッダーが必要です",
+	UserIDFetchFailed:            "ユーザーIDの取得に失敗しました",
+	UserFetchFailed:              "ユーザー情報の取得に失敗しました",
+	UpdatedUserFetchFailed:       "更新後のユーザー情報の取得に失敗しました",
+	InvalidEmailID:               "無効なメールID",
+	OutboxEmailNotFound:          "メールが見つかりません",
+	EmailNotRequeueable:          "配信を停止したメールのみ再送できます",
+	EmailContentDiscarded:        "リンクを破棄したメールは再送できません。利用者に操作をやり直すよう案内してください",
+	UnsupportedContentType:       "サポートされていないContent-Typeです",
+	InvalidPatch:                 "無効なパッチです: %s",
+	PatchConflict:                "パッチを適用できません: %s",
+	InvalidLimit:                 "無効なlimitパラメータ",
+	InvalidOffset:                "無効なoffsetパラメータ",
+	InvalidCursor:                "無効なcursorパラメータ",
+	InvalidIncludeTotal:          "無効なinclude_totalパラメータ",
+	InvalidStatus:                "無効なstatusパラメータ",
+	InvalidSort:                  "無効なsortパラメータ: %s",
+	InvalidFilter:                "無効なfilter[%s]パラメータ",
+	SearchQueryTooShort:          "検索キーワードは2文字以上で指定してください",
+
+	// 入力項目ごとの検証エラー
+	FieldRequired: "必須項目です",
+	FieldEmail:    "メールアドレスの形式が正しくありません",
+	FieldMin:      "%s文字以上で入力してください",
+	FieldMax:      "%s文字以内で入力してください",
+	FieldLen:      "%s文字で入力してください",
+	FieldNumeric:  "数字で入力してください",
+	FieldOneOf:    "%s のいずれかを指定してください",
+	FieldInvalid:  "値が正しくありません",
+
+	// エラーコードごとのタイトル
+	TitleInvalidRequest:       "リクエストが正しくありません",
+	TitleValidation:           "入力内容に誤りがあります",
+	TitleUnauthorized:         "認証に失敗しました",
+	TitleEmailNotVerified:     "メールアドレスが確認されていません",
+	TitleForbidden:            "権限がありません",
+	TitleNotFound:             "見つかりません",
+	TitleAlreadyExists:        "既に存在します",
+	TitleConflict:             "現在の状態では処理できません",
+	TitlePreconditionFailed:   "前提条件を満たしていません",
+	TitleUnsupportedMediaType: "サポートされていない形式です",
+	TitlePreconditionRequired: "前提条件の指定が必要です",
+	TitleAccountLocked:        "アカウントがロックされています",
+	TitleLoginThrottled:       "試行回数が多すぎます",
+	TitleInternal:             "サーバーエラーが発生しました",
+
+	// 処理結果のメッセージ
+	RegistrationEmailSent:  "確認メールを送信しました。メール内のリンクから登録を完了してください",
+	VerificationEmailSent:  "確認メールを送信しました",
+	EmailAlreadyVerified:   "メールアドレスは既に確認済みです",
+	EmailVerified:          "メールアドレスの確認が完了しました",
+	LoggedOut:              "ログアウトしました",
+	LoggedOutAll:           "全てのデバイスからログアウトしました",
+	PasswordChanged:        "パスワードを変更しました。再度ログインしてください",
+	PasswordResetEmailSent: "パスワードリセットメールを送信しました",
+	PasswordUpdated:        "パスワードを更新しました",
+	AccountDeleted:         "アカウントを削除しました",
+	MFADisabled:            "二要素認証を無効にしました",
+	UserDeleted:            "ユーザーを削除しました",
+	AccountUnlocked:        "アカウントのロックを解除しました",
+	EmailRequeued:          "メールを再送します",
+}
@@ -0,0 +1,105 @@
+// Package i18n はクライアントに返すメッセージを言語ごとに管理します
+// メッセージはIDで参照し、言語ごとのカタログ（catalog_*.go）から文面を取得します
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale は対応している言語です（BCP 47 の言語サブタグ）
+type Locale string
+
+const (
+	Japanese Locale = "ja"
+	English  Locale = "en"
+
+	// Default はリクエストやユーザーの設定から言語を決められない場合に使用する言語です
+	Default = Japanese
+)
+
+var catalogs = map[Locale]map[Message]string{
+	Japanese: ja,
+	English:  en,
+}
+
+// Supported は対応している言語の一覧を返します
+func Supported() []Locale {
+	return []Locale{Japanese, English}
+}
+
+// Parse は言語タグを対応している言語に変換します
+// en-US のように地域を含むタグは言語サブタグで判定します
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	locale := Locale(tag)
+	if _, ok := catalogs[locale]; !ok {
+		return "", false
+	}
+	return locale, true
+}
+
+// Negotiate は Accept-Language ヘッダーの値から最も優先度の高い対応言語を返します
+// 対応している言語がない場合は Default を返します
+func Negotiate(acceptLanguage string) Locale {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		// q=0 は「受け入れない」の意味
+		if quality <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag: tag, quality: quality})
+	}
+
+	// 同じ優先度の場合はヘッダーに記載された順を維持する
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return Default
+		}
+		if locale, ok := Parse(c.tag); ok {
+			return locale
+		}
+	}
+	return Default
+}
+
+// T は指定した言語のメッセージを返します
+// 指定した言語のカタログにない場合は Default の言語、それにもない場合はメッセージIDを返します
+func T(locale Locale, msg Message, args ...interface{}) string {
+	text, ok := catalogs[locale][msg]
+	if !ok {
+		text, ok = catalogs[Default][msg]
+	}
+	if !ok {
+		text = string(msg)
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
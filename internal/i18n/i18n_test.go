@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogs(t *testing.T) {
+	// 全ての言語のカタログが同じメッセージを、同じ数の書式指定子で持つこと
+	for _, locale := range Supported() {
+		catalog := catalogs[locale]
+		assert.Len(t, catalog, len(catalogs[Default]), "%s のメッセージ数", locale)
+		for msg, text := range catalogs[Default] {
+			translated, ok := catalog[msg]
+			if !assert.True(t, ok, "%s に %s がありません", locale, msg) {
+				continue
+			}
+			assert.Equal(t, strings.Count(text, "%"), strings.Count(translated, "%"), "%s の %s の書式指定子", locale, msg)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expected       Locale
+	}{
+		{
+			name:           "ヘッダーなし",
+			acceptLanguage: "",
+			expected:       Default,
+		},
+		{
+			name:           "地域を含むタグ",
+			acceptLanguage: "en-US",
+			expected:       English,
+		},
+		{
+			name:           "優先度の高い対応言語",
+			acceptLanguage: "ja;q=0.5, en-GB;q=0.8, fr",
+			expected:       English,
+		},
+		{
+			name:           "同じ優先度の場合は記載順",
+			acceptLanguage: "ja, en",
+			expected:       Japanese,
+		},
+		{
+			name:           "q=0 の言語は使用しない",
+			acceptLanguage: "en;q=0, ja;q=0.1",
+			expected:       Japanese,
+		},
+		{
+			name:           "対応言語なし",
+			acceptLanguage: "fr, de;q=0.9",
+			expected:       Default,
+		},
+		{
+			name:           "ワイルドカード",
+			acceptLanguage: "fr, *;q=0.5",
+			expected:       Default,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Negotiate(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "User not found", T(English, UserNotFound))
+	assert.Equal(t, "Invalid sort parameter: name", T(English, InvalidSort, "name"))
+	// 対応していない言語は既定の言語で返す
+	assert.Equal(t, "ユーザーが見つかりません", T(Locale("fr"), UserNotFound))
+	// カタログにないメッセージはIDを返す
+	assert.Equal(t, "unknown.message", T(English, Message("unknown.message")))
+}
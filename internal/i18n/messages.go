@@ -0,0 +1,119 @@
+package i18n
+
+// Message はカタログのメッセージIDです
+// 書式指定子（%s など）を含むメッセージは、T に同じ数の引数を渡してください
+type Message string
+
+// エラーメッセージ
+const (
+	InvalidRequestBody           Message = "request.malformed"
+	ValidationFailed             Message = "request.validation_failed"
+	InvalidValueType             Message = "request.invalid_type"
+	ResourceNotFound             Message = "resource.not_found"
+	DuplicateEntry               Message = "resource.duplicate"
+	RowIsReferenced              Message = "resource.referenced"
+	NoReferencedRow              Message = "resource.reference_missing"
+	InternalError                Message = "server.internal_error"
+	MissingAuthHeader            Message = "auth.missing_header"
+	InvalidAuthScheme            Message = "auth.invalid_scheme"
+	InvalidToken                 Message = "auth.invalid_token"
+	InvalidTokenLoginAgain       Message = "auth.invalid_token_login_again"
+	TokenRevoked                 Message = "auth.token_revoked"
+	Forbidden                    Message = "auth.forbidden"
+	StatusChangeForbidden        Message = "auth.status_change_forbidden"
+	InvalidCredentials           Message = "auth.invalid_credentials"
+	AccountDisabled              Message = "auth.account_disabled"
+	EmailNotVerified             Message = "auth.email_not_verified"
+	AccountLocked                Message = "auth.account_locked"
+	LoginThrottled               Message = "auth.login_throttled"
+	InvalidRefreshToken          Message = "auth.invalid_refresh_token"
+	RefreshTokenExpired          Message = "auth.refresh_token_expired"
+	RefreshTokenReused           Message = "auth.refresh_token_reused"
+	InvalidVerificationLink      Message = "auth.invalid_verification_link"
+	TokenGenerationFailed        Message = "auth.token_generation_failed"
+	TokenRevocationFailed        Message = "auth.token_revocation_failed"
+	IncorrectPassword            Message = "password.incorrect"
+	IncorrectCurrentPassword     Message = "password.incorrect_current"
+	PasswordHashFailed           Message = "password.hash_failed"
+	InvalidMFACode               Message = "mfa.invalid_code"
+	MFAAlreadyEnabled            Message = "mfa.already_enabled"
+	MFANotEnabled                Message = "mfa.not_enabled"
+	MFASetupNotStarted           Message = "mfa.setup_not_started"
+	SecretGenerationFailed       Message = "mfa.secret_generation_failed"
+	RecoveryCodeGenerationFailed Message = "mfa.recovery_code_generation_failed"
+	UserNotFound                 Message = "user.not_found"
+	RestorableUserNotFound       Message = "user.restorable_not_found"
+	InvalidUserID                Message = "user.invalid_id"
+	EmailAlreadyRegistered       Message = "user.email_already_registered"
+	EmailChangeNotAllowed        Message = "user.email_change_not_allowed"
+	EmailNotRemovable            Message = "user.email_not_removable"
+	StatusNotRemovable           Message = "user.status_not_removable"
+	UserModified                 Message = "user.modified"
+	IfMatchRequired              Message = "user.if_match_required"
+	UserIDFetchFailed            Message = "user.id_fetch_failed"
+	UserFetchFailed              Message = "user.fetch_failed"
+	UpdatedUserFetchFailed       Message = "user.updated_fetch_failed"
+	InvalidEmailID               Message = "email.invalid_id"
+	OutboxEmailNotFound          Message = "email.not_found"
+	EmailNotRequeueable          Message = "email.not_requeueable"
+	EmailContentDiscarded        Message = "email.content_discarded"
+	UnsupportedContentType       Message = "patch.unsupported_content_type"
+	InvalidPatch                 Message = "patch.invalid"
+	PatchConflict                Message = "patch.conflict"
+	InvalidLimit                 Message = "param.invalid_limit"
+	InvalidOffset                Message = "param.invalid_offset"
+	InvalidCursor                Message = "param.invalid_cursor"
+	InvalidIncludeTotal          Message = "param.invalid_include_total"
+	InvalidStatus                Message = "param.invalid_status"
+	InvalidSort                  Message = "param.invalid_sort"
+	InvalidFilter                Message = "param.invalid_filter"
+	SearchQueryTooShort          Message = "param.search_query_too_short"
+)
+
+// 入力項目ごとの検証エラー
+const (
+	FieldRequired Message = "field.required"
+	FieldEmail    Message = "field.email"
+	FieldMin      Message = "field.min"
+	FieldMax      Message = "field.max"
+	FieldLen      Message = "field.len"
+	FieldNumeric  Message = "field.numeric"
+	FieldOneOf    Message = "field.oneof"
+	FieldInvalid  Message = "field.invalid"
+)
+
+// エラーコードごとのタイトル
+const (
+	TitleInvalidRequest       Message = "title.invalid_request"
+	TitleValidation           Message = "title.validation_failed"
+	TitleUnauthorized         Message = "title.unauthorized"
+	TitleEmailNotVerified     Message = "title.email_not_verified"
+	TitleForbidden            Message = "title.forbidden"
+	TitleNotFound             Message = "title.not_found"
+	TitleAlreadyExists        Message = "title.already_exists"
+	TitleConflict             Message = "title.conflict"
+	TitlePreconditionFailed   Message = "title.precondition_failed"
+	TitleUnsupportedMediaType Message = "title.unsupported_media_type"
+	TitlePreconditionRequired Message = "title.precondition_required"
+	TitleAccountLocked        Message = "title.account_locked"
+	TitleLoginThrottled       Message = "title.login_throttled"
+	TitleInternal             Message = "title.internal_error"
+)
+
+// 処理結果のメッセージ
+const (
+	RegistrationEmailSent  Message = "register.email_sent"
+	VerificationEmailSent  Message = "verification.email_sent"
+	EmailAlreadyVerified   Message = "verification.already_verified"
+	EmailVerified          Message = "verification.verified"
+	LoggedOut              Message = "logout.done"
+	LoggedOutAll           Message = "logout.all_done"
+	PasswordChanged        Message = "password.changed"
+	PasswordResetEmailSent Message = "password.reset_email_sent"
+	PasswordUpdated        Message = "password.updated"
+	AccountDeleted         Message = "account.deleted"
+	MFADisabled            Message = "mfa.disabled"
+	UserDeleted            Message = "user.deleted"
+	AccountUnlocked        Message = "user.unlocked"
+	EmailRequeued          Message = "email.requeued"
+)
@@ -3,28 +3,38 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"net"
+	"strconv"
 
 	"go-gin-sqlc/internal/config"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
-// Connect はデータベース接続を確立します
+// Connect はデータベース接続を確立し、接続プールを設定します
 func Connect(cfg config.DBConfig) (*sql.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-	)
-
-	db, err := sql.Open("mysql", dsn)
+	// パスワードに記号が含まれていても正しく解釈されるよう、DSNはドライバーで組み立てる
+	dsn := mysql.NewConfig()
+	dsn.User = cfg.User
+	dsn.Passwd = cfg.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dsn.DBName = cfg.DBName
+	dsn.ParseTime = true
+
+	db, err := sql.Open("mysql", dsn.FormatDSN())
 	if err != nil {
 		return nil, fmt.Errorf("データベース接続のオープンに失敗しました: %w", err)
 	}
 
+	// 接続プールの設定
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("データベース接続の確認に失敗しました: %w", err)
 	}
 
@@ -0,0 +1,383 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationLockTimeout は他のプロセスがマイグレーションを実行中の場合に、完了を待つ秒数です
+const migrationLockTimeout = 60
+
+// ErrDirtyMigration は前回のマイグレーションが途中で失敗し、データベースの状態が不明であることを表します
+// データベースを確認して修正した後、Force でバージョンを記録し直してください
+var ErrDirtyMigration = errors.New("前回のマイグレーションが途中で失敗しています")
+
+// migrationFilePattern はマイグレーションファイルの名前の形式です
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration は1つのバージョンのマイグレーションです
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus はマイグレーションの適用状況です
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Migrator は埋め込んだマイグレーションファイルをデータベースに適用します
+// 適用済みのバージョンは golang-migrate と同じ schema_migrations テーブルに記録するため、
+// migrate コマンドで適用したデータベースにもそのまま使用できます
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator はマイグレーションファイルを読み込み、新しいMigratorを作成します
+func NewMigrator(db *sql.DB, files fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// loadMigrations はマイグレーションファイルを読み込み、バージョンの古い順に返します
+// バージョンは連番である必要はありません
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("マイグレーションファイルの名前が不正です: %s", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("マイグレーションファイルのバージョンが不正です: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("バージョン %d のマイグレーションが重複しています", version)
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.up) == "" {
+			return nil, fmt.Errorf("バージョン %d の up マイグレーションがありません", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Version は適用済みの最新のバージョンと、そのマイグレーションが途中で失敗したかを返します
+// 1件も適用されていない場合は 0 を返します
+func (m *Migrator) Version(ctx context.Context) (version int64, dirty bool, err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return 0, false, err
+	}
+	return readVersion(ctx, conn)
+}
+
+// Status は全てのマイグレーションの適用状況を返します
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: migration, Applied: migration.Version <= version}
+	}
+	return statuses, nil
+}
+
+// Up は未適用のマイグレーションを古い順に全て適用し、適用したマイグレーションを返します
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version=%d)", ErrDirtyMigration, version)
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			// 実行中に失敗した場合に分かるよう、完了するまでは dirty として記録する
+			if err := writeVersion(ctx, conn, migration.Version, true); err != nil {
+				return err
+			}
+			if err := execMigration(ctx, conn, migration, migration.up); err != nil {
+				return err
+			}
+			if err := writeVersion(ctx, conn, migration.Version, false); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down は適用済みのマイグレーションを新しい順に steps 件取り消し、取り消したマイグレーションを返します
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version=%d)", ErrDirtyMigration, version)
+		}
+
+		i := len(m.migrations) - 1
+		for i >= 0 && m.migrations[i].Version > version {
+			i--
+		}
+		if i >= 0 && m.migrations[i].Version != version {
+			return fmt.Errorf("適用済みのバージョン %d のマイグレーションファイルがありません", version)
+		}
+
+		for ; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if strings.TrimSpace(migration.down) == "" {
+				return fmt.Errorf("バージョン %d の down マイグレーションがありません", migration.Version)
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := writeVersion(ctx, conn, previous, true); err != nil {
+				return err
+			}
+			if err := execMigration(ctx, conn, migration, migration.down); err != nil {
+				return err
+			}
+			if err := writeVersion(ctx, conn, previous, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force はマイグレーションを実行せずにバージョンを記録し、途中で失敗した状態を解除します
+// 0 を指定した場合は、1件も適用されていない状態にします
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && !m.hasVersion(version) {
+		return fmt.Errorf("バージョン %d のマイグレーションファイルがありません", version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		return writeVersion(ctx, conn, version, false)
+	})
+}
+
+// hasVersion は指定したバージョンのマイグレーションファイルがあるかを返します
+func (m *Migrator) hasVersion(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// withLock はアドバイザリーロックを取得した接続で fn を実行します
+// 複数のレプリカが同時に起動しても、マイグレーションを実行するのは1つのプロセスだけになります
+// ロックは接続に結び付くため、ロックの取得から解放まで同じ接続を使用します
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// ロックはサーバー全体で共有されるため、データベースごとに名前を分ける
+	var acquired sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(CONCAT(DATABASE(), ':migrate'), ?)", migrationLockTimeout).Scan(&acquired)
+	if err != nil {
+		return err
+	}
+	if acquired.Int64 != 1 {
+		return errors.New("他のプロセスがマイグレーションを実行中のため、ロックを取得できませんでした")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(CONCAT(DATABASE(), ':migrate'))")
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureVersionTable は適用済みのバージョンを記録するテーブルを作成します
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+	return err
+}
+
+// readVersion は記録されているバージョンを返します
+func readVersion(ctx context.Context, conn *sql.Conn) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// writeVersion はバージョンを記録します。0 の場合は記録を削除します
+func writeVersion(ctx context.Context, conn *sql.Conn, version int64, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)", version, dirty); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// execMigration はマイグレーションファイルの文を1つずつ実行します
+// MySQLのDDLは暗黙的にコミットされるため、トランザクションは使用しません
+func execMigration(ctx context.Context, conn *sql.Conn, migration Migration, content string) error {
+	for _, stmt := range splitStatements(content) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("マイグレーション %d_%s の実行に失敗しました: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// splitStatements はSQLを ; で区切って文ごとに分割します
+// 接続で複数の文の実行を許可しなくても済むよう、文字列や識別子の中の ; では区切らず、コメントは取り除きます
+func splitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			// 閉じる引用符までをそのまま書き出す。バックスラッシュと重ねた引用符はエスケープとして扱う
+			end := i + 1
+			for end < len(content) {
+				if content[end] == '\\' && ch != '`' {
+					end += 2
+					continue
+				}
+				if content[end] == ch {
+					if end+1 < len(content) && content[end+1] == ch {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end, len(content)-1)
+			current.WriteString(content[i : end+1])
+			i = end
+		case ch == '#' || isDashComment(content[i:]):
+			// 行末までのコメント
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				i = len(content)
+				continue
+			}
+			i += end
+			current.WriteByte('\n')
+		case ch == '/' && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+				continue
+			}
+			i += end + 3
+			current.WriteByte(' ')
+		case ch == ';':
+			flush()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	flush()
+	return statements
+}
+
+// isDashComment は s が -- で始まる行末までのコメントかを返します
+// MySQLでは -- の後に空白か改行が必要です
+func isDashComment(s string) bool {
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	return len(s) == 2 || s[2] == ' ' || s[2] == '\t' || s[2] == '\r' || s[2] == '\n'
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go-gin-sqlc/db/migration"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("埋め込んだマイグレーション", func(t *testing.T) {
+		migrations, err := loadMigrations(migration.Files)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+
+		for i, m := range migrations {
+			// バージョンの古い順に並び、全てのバージョンを取り消せる
+			if i > 0 {
+				assert.Greater(t, m.Version, migrations[i-1].Version)
+			}
+			assert.NotEmpty(t, splitStatements(m.up), "%d_%s.up.sql", m.Version, m.Name)
+			assert.NotEmpty(t, splitStatements(m.down), "%d_%s.down.sql", m.Version, m.Name)
+		}
+	})
+
+	t.Run("欠番があってもよい", func(t *testing.T) {
+		migrations, err := loadMigrations(fstest.MapFS{
+			"000001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id BIGINT);")},
+			"000001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+			"000003_add_name.up.sql":       {Data: []byte("ALTER TABLE users ADD COLUMN name TEXT;")},
+			"000003_add_name.down.sql":     {Data: []byte("ALTER TABLE users DROP COLUMN name;")},
+		})
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, int64(3), migrations[1].Version)
+		assert.Equal(t, "add_name", migrations[1].Name)
+	})
+
+	// テストケースの定義
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name: "名前の形式が不正",
+			files: fstest.MapFS{
+				"create_users.up.sql": {Data: []byte("CREATE TABLE users (id BIGINT);")},
+			},
+		},
+		{
+			name: "同じバージョンで名前が異なる",
+			files: fstest.MapFS{
+				"000001_create_users.up.sql": {Data: []byte("CREATE TABLE users (id BIGINT);")},
+				"000001_create_roles.up.sql": {Data: []byte("CREATE TABLE roles (id BIGINT);")},
+			},
+		},
+		{
+			name: "upがない",
+			files: fstest.MapFS{
+				"000001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "複数の文",
+			content:  "DROP TABLE a;\nDROP TABLE b;\n",
+			expected: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name:     "最後の ; は省略できる",
+			content:  "DROP TABLE a",
+			expected: []string{"DROP TABLE a"},
+		},
+		{
+			name:     "文字列と識別子の中の ; では区切らない",
+			content:  "INSERT INTO `a;b` (name) VALUES ('x;y'), (\"it''s;\"), ('a\\';b');",
+			expected: []string{"INSERT INTO `a;b` (name) VALUES ('x;y'), (\"it''s;\"), ('a\\';b')"},
+		},
+		{
+			name:     "コメントは取り除く",
+			content:  "-- 説明; です\nALTER TABLE a /* 途中; */ ADD COLUMN b INT; # 末尾;\n-- コメントだけ\n",
+			expected: []string{"ALTER TABLE a ADD COLUMN b INT"},
+		},
+		{
+			name:     "-- の後に空白がない場合はコメントではない",
+			content:  "SELECT 1--1;",
+			expected: []string{"SELECT 1--1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := splitStatements(tt.content)
+			for i := range statements {
+				statements[i] = strings.Join(strings.Fields(statements[i]), " ")
+			}
+			expected := make([]string, len(tt.expected))
+			for i := range tt.expected {
+				expected[i] = strings.Join(strings.Fields(tt.expected[i]), " ")
+			}
+			assert.Equal(t, expected, statements)
+		})
+	}
+}
@@ -1,20 +1,22 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
 	"go-gin-sqlc/internal/util"
 
 	"github.com/gin-gonic/gin"
 )
 
 // AuthRequired は認証を必要とするエンドポイントに使用するミドルウェアです
-func AuthRequired() gin.HandlerFunc {
+// revocationsがnilでない場合は、失効済みのトークンを拒否します
+func AuthRequired(revocations util.RevocationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "認証ヘッダーがありません"})
+			c.Error(apperror.New(apperror.CodeUnauthorized, i18n.MissingAuthHeader))
 			c.Abort()
 			return
 		}
@@ -22,7 +24,7 @@ func AuthRequired() gin.HandlerFunc {
 		// Bearer トークンの形式を確認
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "無効な認証形式です"})
+			c.Error(apperror.New(apperror.CodeUnauthorized, i18n.InvalidAuthScheme))
 			c.Abort()
 			return
 		}
@@ -30,13 +32,21 @@ func AuthRequired() gin.HandlerFunc {
 		// トークンの検証
 		claims, err := util.ValidateToken(parts[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なトークンです"})
+			c.Error(apperror.New(apperror.CodeUnauthorized, i18n.InvalidToken))
 			c.Abort()
 			return
 		}
 
-		// ユーザーIDをコンテキストに設定
+		// 失効リストの確認
+		if revocations != nil && revocations.IsRevoked(claims) {
+			c.Error(apperror.New(apperror.CodeUnauthorized, i18n.TokenRevoked))
+			c.Abort()
+			return
+		}
+
+		// ユーザーIDとクレームをコンテキストに設定
 		c.Set("userID", claims.UserID)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"go-gin-sqlc/internal/util"
@@ -12,6 +14,33 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// TestMain はテストで発行・検証するトークンの署名鍵を設定してからテストを実行します
+func TestMain(m *testing.M) {
+	keys, err := util.NewKeySet(util.JWTConfig{Algorithm: "HS256", Secret: "test-secret"})
+	if err != nil {
+		panic(err)
+	}
+	util.SetKeySet(keys)
+	os.Exit(m.Run())
+}
+
+// stubRevocationStore は失効済みとして扱うユーザーIDを保持するテスト用の実装です
+type stubRevocationStore struct {
+	revokedUsers map[int64]bool
+}
+
+func (s *stubRevocationStore) Revoke(ctx context.Context, claims *util.Claims) error {
+	return nil
+}
+
+func (s *stubRevocationStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	return nil
+}
+
+func (s *stubRevocationStore) IsRevoked(claims *util.Claims) bool {
+	return s.revokedUsers[claims.UserID]
+}
+
 func TestAuthRequired(t *testing.T) {
 	// Ginのテストモード設定
 	gin.SetMode(gin.TestMode)
@@ -25,7 +54,7 @@ func TestAuthRequired(t *testing.T) {
 		{
 			name: "有効なトークン",
 			setupAuth: func(r *http.Request) {
-				token, _ := util.GenerateToken(1)
+				token, _ := util.GenerateToken(1, nil)
 				r.Header.Set("Authorization", "Bearer "+token)
 			},
 			expectedStatus: http.StatusOK,
@@ -53,6 +82,15 @@ func TestAuthRequired(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedError:  "無効なトークンです",
 		},
+		{
+			name: "失効済みのトークン",
+			setupAuth: func(r *http.Request) {
+				token, _ := util.GenerateToken(2, nil)
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "このトークンは失効しています",
+		},
 	}
 
 	for _, tt := range tests {
@@ -60,9 +98,10 @@ func TestAuthRequired(t *testing.T) {
 			// テスト用のルーターとレスポンスレコーダーの設定
 			w := httptest.NewRecorder()
 			_, r := gin.CreateTestContext(w)
+			r.Use(ErrorHandler())
 
 			// ミドルウェアとハンドラーの設定
-			r.Use(AuthRequired())
+			r.Use(AuthRequired(&stubRevocationStore{revokedUsers: map[int64]bool{2: true}}))
 			r.GET("/test", func(c *gin.Context) {
 				c.Status(http.StatusOK)
 			})
@@ -78,10 +117,10 @@ func TestAuthRequired(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			if tt.expectedError != "" {
-				var response map[string]string
+				var response Problem
 				err := json.NewDecoder(w.Body).Decode(&response)
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedError, response["error"])
+				assert.Equal(t, tt.expectedError, response.Detail)
 			}
 		})
 	}
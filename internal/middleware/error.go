@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"strconv"
+
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType は RFC 9457 のエラーレスポンスのメディアタイプです
+const ProblemContentType = "application/problem+json"
+
+// Problem は RFC 9457 の problem details に、エラーコードと項目ごとの詳細を加えたものです
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     apperror.Code  `json:"code"`
+	Errors   []ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField は入力項目ごとの検証エラーです
+type ProblemField struct {
+	// Field はリクエストのJSONやクエリパラメータでの項目名です
+	Field string `json:"field"`
+	// Reason は検証ルールの名前です（required, email など）
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// ErrorHandler はハンドラーが c.Error に渡したエラーを problem+json で返すミドルウェアです
+// 他のミドルウェアより先に登録してください
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		RenderErrors(c)
+	}
+}
+
+// RenderErrors はコンテキストに記録された最後のエラーを problem+json で返します
+// メッセージはリクエストの言語に翻訳します
+// 既にレスポンスが書き込まれている場合やエラーがない場合は何もしません
+func RenderErrors(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	appErr := apperror.From(c.Errors.Last().Err)
+	status := appErr.Status()
+	// 内部エラーの詳細はクライアントに返さず、ログにのみ出力する
+	if status >= 500 {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, appErr)
+	}
+
+	locale := RequestLocale(c)
+	var fields []ProblemField
+	for _, field := range appErr.Fields {
+		fields = append(fields, ProblemField{
+			Field:   field.Field,
+			Reason:  field.Reason,
+			Message: i18n.T(locale, field.Message, field.Args...),
+		})
+	}
+
+	if appErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, Problem{
+		Type:     "/problems/" + string(appErr.Code),
+		Title:    appErr.Title(locale),
+		Status:   status,
+		Detail:   appErr.Detail(locale),
+		Instance: c.Request.URL.Path,
+		Code:     appErr.Code,
+		Errors:   fields,
+	})
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name               string
+		handler            gin.HandlerFunc
+		acceptLanguage     string
+		expectedStatus     int
+		expectedProblem    *Problem
+		expectedRetryAfter string
+	}{
+		{
+			name: "エラーなし",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "ok"})
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "検証エラー",
+			handler: func(c *gin.Context) {
+				c.Error(apperror.InvalidField("sort", "invalid", i18n.InvalidSort, "name"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedProblem: &Problem{
+				Type:     "/problems/validation_failed",
+				Title:    "入力内容に誤りがあります",
+				Status:   http.StatusBadRequest,
+				Detail:   "無効なsortパラメータ: name",
+				Instance: "/test",
+				Code:     apperror.CodeValidation,
+				Errors:   []ProblemField{{Field: "sort", Reason: "invalid", Message: "無効なsortパラメータ: name"}},
+			},
+		},
+		{
+			name: "Accept-Languageの言語で返す",
+			handler: func(c *gin.Context) {
+				c.Error(apperror.InvalidField("sort", "invalid", i18n.InvalidSort, "name"))
+			},
+			acceptLanguage: "en",
+			expectedStatus: http.StatusBadRequest,
+			expectedProblem: &Problem{
+				Type:     "/problems/validation_failed",
+				Title:    "Validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   "Invalid sort parameter: name",
+				Instance: "/test",
+				Code:     apperror.CodeValidation,
+				Errors:   []ProblemField{{Field: "sort", Reason: "invalid", Message: "Invalid sort parameter: name"}},
+			},
+		},
+		{
+			name: "内部エラーの詳細は返さない",
+			handler: func(c *gin.Context) {
+				c.Error(errors.New("dial tcp: connection refused"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedProblem: &Problem{
+				Type:     "/problems/internal_error",
+				Title:    "サーバーエラーが発生しました",
+				Status:   http.StatusInternalServerError,
+				Detail:   "サーバーでエラーが発生しました",
+				Instance: "/test",
+				Code:     apperror.CodeInternal,
+			},
+		},
+		{
+			name: "再試行できるまでの時間は Retry-After ヘッダーで返す",
+			handler: func(c *gin.Context) {
+				c.Error(apperror.New(apperror.CodeLoginThrottled, i18n.LoginThrottled).WithRetryAfter(1500 * time.Millisecond))
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedProblem: &Problem{
+				Type:     "/problems/login_throttled",
+				Title:    "試行回数が多すぎます",
+				Status:   http.StatusTooManyRequests,
+				Detail:   "しばらく時間をおいてから再度お試しください",
+				Instance: "/test",
+				Code:     apperror.CodeLoginThrottled,
+			},
+			// 秒未満は切り上げる
+			expectedRetryAfter: "2",
+		},
+		{
+			name: "書き込み済みのレスポンスは変更しない",
+			handler: func(c *gin.Context) {
+				c.Error(errors.New("ignored"))
+				c.Status(http.StatusNoContent)
+				c.Writer.WriteHeaderNow()
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(ErrorHandler(), Locale())
+			r.GET("/test", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedRetryAfter, w.Header().Get("Retry-After"))
+
+			if tt.expectedProblem != nil {
+				assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+				var response Problem
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, *tt.expectedProblem, response)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localeKey はリクエストの言語を保持するコンテキストのキーです
+const localeKey = "locale"
+
+// Locale は Accept-Language ヘッダーからレスポンスの言語を決めるミドルウェアです
+// 決めた言語は Content-Language ヘッダーで返します
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := RequestLocale(c)
+		c.Header("Content-Language", string(locale))
+		// キャッシュがリクエストの言語ごとに区別するよう Vary を付ける
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// RequestLocale はリクエストの言語を返します
+// Locale ミドルウェアを経由していない場合は、その場で Accept-Language ヘッダーから決めます
+func RequestLocale(c *gin.Context) i18n.Locale {
+	if value, ok := c.Get(localeKey); ok {
+		if locale, ok := value.(i18n.Locale); ok {
+			return locale
+		}
+	}
+	locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Set(localeKey, locale)
+	return locale
+}
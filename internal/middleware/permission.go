@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadPermissions はトークンのロールから権限を解決し、コンテキストに設定するミドルウェアです
+// AuthRequired の後に使用してください
+func LoadPermissions(resolver util.PermissionResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var roles []string
+		if claims, ok := c.Get("claims"); ok {
+			roles = claims.(*util.Claims).Roles
+		}
+
+		c.Set("permissions", resolver.Permissions(roles))
+		c.Next()
+	}
+}
+
+// RequirePermission は指定された権限を持たないリクエストを拒否するミドルウェアです
+// LoadPermissions の後に使用してください
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			c.Error(apperror.New(apperror.CodeForbidden, i18n.Forbidden))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission は LoadPermissions で設定された権限に、指定された権限が含まれるかを返します
+func HasPermission(c *gin.Context, permission string) bool {
+	permissions, ok := c.Get("permissions")
+	if !ok {
+		return false
+	}
+	return permissions.(util.PermissionSet).Has(permission)
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-gin-sqlc/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubPermissionResolver はロールごとの権限を保持するテスト用の実装です
+type stubPermissionResolver map[string]util.PermissionSet
+
+func (s stubPermissionResolver) Permissions(roles []string) util.PermissionSet {
+	permissions := make(util.PermissionSet)
+	for _, role := range roles {
+		for permission := range s[role] {
+			permissions[permission] = struct{}{}
+		}
+	}
+	return permissions
+}
+
+func TestRequirePermission(t *testing.T) {
+	// Ginのテストモード設定
+	gin.SetMode(gin.TestMode)
+
+	resolver := stubPermissionResolver{
+		util.RoleAdmin: {util.PermissionUsersDelete: {}},
+		util.RoleUser:  {},
+	}
+
+	tests := []struct {
+		name           string
+		roles          []string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "権限を持つロール",
+			roles:          []string{util.RoleAdmin},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "権限を持たないロール",
+			roles:          []string{util.RoleUser},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "この操作を行う権限がありません",
+		},
+		{
+			name:           "ロールなし",
+			roles:          nil,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "この操作を行う権限がありません",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// テスト用のルーターとレスポンスレコーダーの設定
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+			r.Use(ErrorHandler())
+
+			// ミドルウェアとハンドラーの設定
+			r.Use(AuthRequired(nil), LoadPermissions(resolver))
+			r.DELETE("/test", RequirePermission(util.PermissionUsersDelete), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			// リクエストの準備
+			token, _ := util.GenerateToken(1, tt.roles)
+			req := httptest.NewRequest(http.MethodDelete, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			// リクエストの実行
+			r.ServeHTTP(w, req)
+
+			// アサーション
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response Problem
+				err := json.NewDecoder(w.Body).Decode(&response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, response.Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/url"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// verificationResendInterval は確認メールを再送できるまでの間隔です
+const verificationResendInterval = time.Minute
+
+// AuthService はログイン、ユーザー登録、メールアドレスの確認とトークンの発行・失効を扱います
+type AuthService struct {
+	store       db.Store
+	revocations util.RevocationStore
+	// loginGuard が nil の場合、ログイン試行の制限は行わない
+	loginGuard util.LoginGuard
+	clock      Clock
+	config     *config.Config
+}
+
+// NewAuthService は新しいAuthServiceを作成します
+func NewAuthService(store db.Store, revocations util.RevocationStore, loginGuard util.LoginGuard, clock Clock, cfg *config.Config) *AuthService {
+	return &AuthService{
+		store:       store,
+		revocations: revocations,
+		loginGuard:  loginGuard,
+		clock:       clock,
+		config:      cfg,
+	}
+}
+
+// Tokens はアクセストークンとリフレッシュトークンの組です
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn はアクセストークンの有効期間です
+	ExpiresIn time.Duration
+}
+
+// LoginInput はログインの入力です
+type LoginInput struct {
+	Email    string
+	Password string
+	// ClientIP はログイン試行の制限に使用する接続元のIPアドレスです
+	ClientIP string
+	// Locale はユーザーが言語を設定していない場合に通知メールで使用する言語です
+	Locale i18n.Locale
+}
+
+// LoginMFAInput は二要素認証の入力です。Code と RecoveryCode のどちらかを指定します
+type LoginMFAInput struct {
+	MFAToken     string
+	Code         string
+	RecoveryCode string
+	ClientIP     string
+	Locale       i18n.Locale
+}
+
+// LoginResult はログインの結果です
+type LoginResult struct {
+	User db.User
+	// Tokens は発行したトークンです。二要素認証が必要な場合は nil になります
+	Tokens *Tokens
+	// MFAToken は二要素認証が必要な場合に発行する、認証コードの入力待ちのトークンです
+	MFAToken string
+}
+
+// RegisterInput はユーザー登録の入力です
+type RegisterInput struct {
+	Email     string
+	Password  string
+	FirstName string
+	LastName  string
+	// Locale はユーザーの言語の設定です。空の場合は設定しません
+	Locale string
+	// RequestLocale は Locale が空の場合に確認メールで使用する言語です
+	RequestLocale i18n.Locale
+}
+
+// Login はメールアドレスとパスワードを検証し、トークンを発行します
+// 二要素認証が有効な場合は、トークンの代わりに認証コードの入力待ちのトークンを返します
+func (s *AuthService) Login(ctx context.Context, in LoginInput) (LoginResult, error) {
+	// 連続したログイン失敗による制限の確認
+	if err := s.checkLoginGuard(ctx, in.Email, in.ClientIP); err != nil {
+		return LoginResult{}, err
+	}
+
+	// メールアドレスでユーザーを検索
+	user, err := s.store.GetUserByEmail(ctx, in.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.recordLoginFailure(ctx, in.Email, in.ClientIP, nil, in.Locale)
+			return LoginResult{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidCredentials)
+		}
+		return LoginResult{}, err
+	}
+
+	// ユーザーステータスの確認（メールアドレス未確認の判定はパスワード検証後に行う）
+	pending := isPendingVerification(user)
+	if !pending && !isActive(user) {
+		return LoginResult{}, apperror.New(apperror.CodeUnauthorized, i18n.AccountDisabled)
+	}
+
+	// パスワードの検証
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(in.Password)); err != nil {
+		s.recordLoginFailure(ctx, in.Email, in.ClientIP, &user, in.Locale)
+		return LoginResult{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidCredentials)
+	}
+
+	// メールアドレスの確認が完了していない場合は、クライアントが判別できるコードを返す
+	if pending {
+		return LoginResult{}, apperror.New(apperror.CodeEmailNotVerified, i18n.EmailNotVerified)
+	}
+
+	// 二要素認証が有効な場合は、認証コードの入力を求める
+	totp, err := s.store.GetUserTOTP(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return LoginResult{}, err
+	}
+	if err == nil && totp.ConfirmedAt.Valid {
+		mfaToken, err := util.GeneratePurposeToken(user.ID, util.PurposeMFAPending, util.MFATokenExpiration)
+		if err != nil {
+			return LoginResult{}, apperror.Internal(i18n.TokenGenerationFailed, err)
+		}
+		return LoginResult{User: user, MFAToken: mfaToken}, nil
+	}
+
+	// トークンの発行
+	tokens, err := s.issueTokens(ctx, user.ID, "")
+	if err != nil {
+		return LoginResult{}, apperror.Internal(i18n.TokenGenerationFailed, err)
+	}
+
+	s.resetLoginFailures(ctx, user.Email)
+	return LoginResult{User: user, Tokens: &tokens}, nil
+}
+
+// LoginMFA は二要素認証待ちのトークンと認証コード（またはリカバリーコード）を検証し、トークンを発行します
+func (s *AuthService) LoginMFA(ctx context.Context, in LoginMFAInput) (db.User, Tokens, error) {
+	claims, err := util.ValidatePurposeToken(in.MFAToken, util.PurposeMFAPending)
+	if err != nil {
+		return db.User{}, Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidTokenLoginAgain)
+	}
+
+	// ユーザーステータスの確認
+	user, err := s.store.GetUser(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.User{}, Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidTokenLoginAgain)
+		}
+		return db.User{}, Tokens{}, err
+	}
+	if !isActive(user) {
+		return db.User{}, Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.AccountDisabled)
+	}
+
+	// 認証コードの総当たりを防ぐため、パスワードと同じ制限を適用する
+	if err := s.checkLoginGuard(ctx, user.Email, in.ClientIP); err != nil {
+		return db.User{}, Tokens{}, err
+	}
+
+	totp, err := s.store.GetUserTOTP(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return db.User{}, Tokens{}, err
+	}
+	if err == sql.ErrNoRows || !totp.ConfirmedAt.Valid {
+		return db.User{}, Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidTokenLoginAgain)
+	}
+
+	// 認証コード、またはリカバリーコードの検証
+	var verified bool
+	if in.Code != "" {
+		verified, err = verifyTOTPCode(ctx, s.store, totp, in.Code, s.clock.Now())
+	} else {
+		var used int64
+		used, err = s.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: util.HashToken(util.NormalizeRecoveryCode(in.RecoveryCode)),
+		})
+		verified = used == 1
+	}
+	if err != nil {
+		return db.User{}, Tokens{}, err
+	}
+	if !verified {
+		s.recordLoginFailure(ctx, user.Email, in.ClientIP, &user, in.Locale)
+		return db.User{}, Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidMFACode)
+	}
+
+	// トークンの発行
+	tokens, err := s.issueTokens(ctx, user.ID, "")
+	if err != nil {
+		return db.User{}, Tokens{}, apperror.Internal(i18n.TokenGenerationFailed, err)
+	}
+
+	s.resetLoginFailures(ctx, user.Email)
+	return user, tokens, nil
+}
+
+// checkLoginGuard はログイン試行が制限されていないかを確認します
+// 制限中の場合は、再試行できるまでの時間を付けたエラーを返します
+func (s *AuthService) checkLoginGuard(ctx context.Context, email, clientIP string) error {
+	if s.loginGuard == nil {
+		return nil
+	}
+
+	wait, locked, err := s.loginGuard.Check(ctx, email, clientIP)
+	if err != nil {
+		return err
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	if locked {
+		return apperror.New(apperror.CodeAccountLocked, i18n.AccountLocked).WithRetryAfter(wait)
+	}
+	return apperror.New(apperror.CodeLoginThrottled, i18n.LoginThrottled).WithRetryAfter(wait)
+}
+
+// recordLoginFailure はログイン失敗を記録し、アカウントがロックされた場合は本人にメールで通知します
+// userがnilの場合（存在しないメールアドレス）は通知しません
+func (s *AuthService) recordLoginFailure(ctx context.Context, email, clientIP string, user *db.User, locale i18n.Locale) {
+	if s.loginGuard == nil {
+		return
+	}
+
+	lockedUntil, err := s.loginGuard.RecordFailure(ctx, email, clientIP)
+	if err != nil {
+		log.Println("ログイン失敗の記録に失敗しました:", err)
+		return
+	}
+	if user == nil || lockedUntil.IsZero() {
+		return
+	}
+
+	data := util.AccountLockedMail{LockedUntil: lockedUntil}
+	if err := util.EnqueueMail(ctx, s.store, user.Email, util.MailTemplateAccountLocked, userLocale(*user, locale), data); err != nil {
+		log.Println("ロック通知メールの送信待ちへの追加に失敗しました:", err)
+	}
+}
+
+// resetLoginFailures はログイン成功時にアカウントの失敗記録を消去します
+func (s *AuthService) resetLoginFailures(ctx context.Context, email string) {
+	if s.loginGuard == nil {
+		return
+	}
+	if err := s.loginGuard.Reset(ctx, email); err != nil {
+		log.Println("ログイン失敗の記録の消去に失敗しました:", err)
+	}
+}
+
+// Register はメールアドレスの確認待ちのユーザーを作成し、確認メールを送信待ちに追加します
+// 作成したユーザーのIDを返します
+func (s *AuthService) Register(ctx context.Context, in RegisterInput) (int64, error) {
+	// メールアドレスの重複チェック（削除済みで復元可能なユーザーを含む）
+	exists, err := s.store.UserEmailExists(ctx, in.Email)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, apperror.New(apperror.CodeAlreadyExists, i18n.EmailAlreadyRegistered)
+	}
+
+	hashedPassword, err := hashPassword(in.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	locale := in.RequestLocale
+	if in.Locale != "" {
+		locale = i18n.Locale(in.Locale)
+	}
+
+	// ユーザーの作成と確認メールの送信待ちへの追加
+	var userID int64
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		// メールアドレスの確認が完了するまでは確認待ちの状態で作成する
+		result, err := q.CreateUser(ctx, db.CreateUserParams{
+			Email:        in.Email,
+			PasswordHash: hashedPassword,
+			FirstName:    in.FirstName,
+			LastName:     in.LastName,
+			Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusPendingVerification, Valid: true},
+			Locale:       sql.NullString{String: in.Locale, Valid: in.Locale != ""},
+		})
+		if err != nil {
+			return err
+		}
+
+		// 作成されたユーザーのIDを取得
+		userID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		// 一般ユーザーのロールを付与
+		err = q.AssignUserRole(ctx, db.AssignUserRoleParams{UserID: userID, RoleName: util.RoleUser})
+		if err != nil {
+			return err
+		}
+
+		return s.queueVerificationEmail(ctx, q, userID, in.Email, locale)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// VerifyEmail はメールアドレス確認トークンを検証し、アカウントを有効にします
+// 既に確認済みのアカウントの場合は alreadyVerified に true を返します
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (alreadyVerified bool, err error) {
+	claims, err := util.ValidatePurposeToken(token, util.PurposeEmailVerification)
+	if err != nil {
+		return false, apperror.New(apperror.CodeInvalidRequest, i18n.InvalidVerificationLink)
+	}
+
+	user, err := s.store.GetUser(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, apperror.New(apperror.CodeInvalidRequest, i18n.InvalidVerificationLink)
+		}
+		return false, err
+	}
+
+	// 確認待ち以外のステータスは変更しない
+	if !isPendingVerification(user) {
+		if isActive(user) {
+			return true, nil
+		}
+		return false, apperror.New(apperror.CodeInvalidRequest, i18n.AccountDisabled)
+	}
+
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		if _, err := q.ActivateUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return q.DeleteEmailVerification(ctx, user.ID)
+	})
+	return false, err
+}
+
+// ResendVerification は確認待ちのユーザーに確認メールを再送します
+// アカウントの存在を推測されないよう、確認待ちのユーザーがいない場合もエラーを返しません
+// locale はユーザーが言語を設定していない場合のメールの言語です
+func (s *AuthService) ResendVerification(ctx context.Context, email string, locale i18n.Locale) error {
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == sql.ErrNoRows || !isPendingVerification(user) {
+		return nil
+	}
+
+	// 短時間での連続送信を制限する
+	verification, err := s.store.GetEmailVerification(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		if wait := verification.LastSentAt.Add(verificationResendInterval).Sub(s.clock.Now()); wait > 0 {
+			return apperror.New(apperror.CodeLoginThrottled, i18n.LoginThrottled).WithRetryAfter(wait)
+		}
+	}
+
+	return s.store.ExecTx(ctx, func(q db.Store) error {
+		return s.queueVerificationEmail(ctx, q, user.ID, user.Email, userLocale(user, locale))
+	})
+}
+
+// queueVerificationEmail は署名付きの確認トークンを含むメールを指定した言語で送信待ちに追加し、送信日時を記録します
+func (s *AuthService) queueVerificationEmail(ctx context.Context, queries db.Querier, userID int64, email string, locale i18n.Locale) error {
+	token, err := util.GeneratePurposeToken(userID, util.PurposeEmailVerification, util.EmailVerificationExpiration)
+	if err != nil {
+		return err
+	}
+
+	err = queries.UpsertEmailVerification(ctx, db.UpsertEmailVerificationParams{
+		UserID:     userID,
+		LastSentAt: s.clock.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	verifyURL := s.config.BaseURL + "/verify-email?token=" + url.QueryEscape(token)
+	return util.EnqueueMail(ctx, queries, email, util.MailTemplateEmailVerification, locale, util.EmailVerificationMail{VerifyURL: verifyURL})
+}
+
+// Refresh はリフレッシュトークンをローテーションし、新しいトークンの組を発行します
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	// ハッシュ化したトークンで保存済みのリフレッシュトークンを検索
+	stored, err := s.store.GetRefreshTokenByHash(ctx, util.HashToken(refreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidRefreshToken)
+		}
+		return Tokens{}, err
+	}
+
+	// ローテーション済みのトークンが再利用された場合はファミリー全体を失効させる
+	if stored.RevokedAt.Valid {
+		return Tokens{}, s.revokeFamily(ctx, stored.FamilyID)
+	}
+
+	if s.clock.Now().After(stored.ExpiresAt) {
+		return Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.RefreshTokenExpired)
+	}
+
+	// 使用したトークンを失効させる（同時に使用された場合は再利用とみなす）
+	revoked, err := s.store.RevokeRefreshToken(ctx, stored.ID)
+	if err != nil {
+		return Tokens{}, err
+	}
+	if revoked == 0 {
+		return Tokens{}, s.revokeFamily(ctx, stored.FamilyID)
+	}
+
+	// ユーザーステータスの確認
+	user, err := s.store.GetUser(ctx, stored.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.InvalidRefreshToken)
+		}
+		return Tokens{}, err
+	}
+	if !isActive(user) {
+		return Tokens{}, apperror.New(apperror.CodeUnauthorized, i18n.AccountDisabled)
+	}
+
+	// 同じファミリーで新しいトークンを発行
+	tokens, err := s.issueTokens(ctx, user.ID, stored.FamilyID)
+	if err != nil {
+		return Tokens{}, apperror.Internal(i18n.TokenGenerationFailed, err)
+	}
+	return tokens, nil
+}
+
+// revokeFamily はリフレッシュトークンの再利用を検知した際にファミリー全体を失効させ、再利用のエラーを返します
+func (s *AuthService) revokeFamily(ctx context.Context, familyID string) error {
+	if err := s.store.RevokeRefreshTokenFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return apperror.New(apperror.CodeUnauthorized, i18n.RefreshTokenReused)
+}
+
+// Logout は現在のアクセストークンと、指定されたリフレッシュトークンのファミリーを失効させます
+// refreshToken が空の場合はアクセストークンだけを失効させます
+func (s *AuthService) Logout(ctx context.Context, claims *util.Claims, refreshToken string) error {
+	// リフレッシュトークンが指定された場合は、本人のものに限り同じファミリーを失効させる
+	if refreshToken != "" {
+		stored, err := s.store.GetRefreshTokenByHash(ctx, util.HashToken(refreshToken))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && stored.UserID == claims.UserID {
+			if err := s.store.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// アクセストークンの失効
+	if err := s.revocations.Revoke(ctx, claims); err != nil {
+		return apperror.Internal(i18n.TokenRevocationFailed, err)
+	}
+	return nil
+}
+
+// LogoutAll はユーザーの全てのアクセストークンとリフレッシュトークンを失効させます
+func (s *AuthService) LogoutAll(ctx context.Context, userID int64) error {
+	if err := s.store.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return apperror.Internal(i18n.TokenRevocationFailed, err)
+	}
+	return nil
+}
+
+// issueTokens はユーザーのロールを含むアクセストークンとリフレッシュトークンを発行します
+// familyIDが空の場合は新しいトークンファミリーを開始します
+func (s *AuthService) issueTokens(ctx context.Context, userID int64, familyID string) (Tokens, error) {
+	// ロールの変更はトークンの再発行時に反映される
+	roles, err := s.store.ListUserRoles(ctx, userID)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	accessToken, err := util.GenerateToken(userID, roles)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if familyID == "" {
+		familyID, err = util.GenerateRandomToken(16)
+		if err != nil {
+			return Tokens{}, err
+		}
+	}
+
+	refreshToken, err := util.GenerateRefreshToken()
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	_, err = s.store.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		UserID:    userID,
+		TokenHash: util.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: s.clock.Now().Add(util.RefreshTokenExpiration),
+	})
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	return Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    util.AccessTokenExpiration,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthServiceResendVerification(t *testing.T) {
+	now := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	pendingUser := db.User{
+		ID:     1,
+		Email:  "test@example.com",
+		Status: db.NullUsersStatus{UsersStatus: db.UsersStatusPendingVerification, Valid: true},
+	}
+
+	// テストケースの定義
+	tests := []struct {
+		name               string
+		setupMock          func(*mockStore)
+		expectedCode       apperror.Code
+		expectedRetryAfter time.Duration
+	}{
+		{
+			name: "送信間隔が短すぎる場合は再送できるまでの時間を返す",
+			setupMock: func(m *mockStore) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(pendingUser, nil)
+				m.On("GetEmailVerification", mock.Anything, int64(1)).Return(db.EmailVerification{
+					UserID:     1,
+					LastSentAt: now.Add(-20 * time.Second),
+				}, nil)
+			},
+			expectedCode:       apperror.CodeLoginThrottled,
+			expectedRetryAfter: 40 * time.Second,
+		},
+		{
+			name: "存在しないユーザーはエラーにしない",
+			setupMock: func(m *mockStore) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(db.User{}, sql.ErrNoRows)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			tt.setupMock(store)
+
+			auth := NewAuthService(store, nil, nil, fixedClock{now}, &config.Config{})
+			err := auth.ResendVerification(context.Background(), "test@example.com", i18n.Japanese)
+
+			if tt.expectedCode == "" {
+				assert.NoError(t, err)
+			} else {
+				appErr, ok := apperror.As(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCode, appErr.Code)
+				assert.Equal(t, tt.expectedRetryAfter, appErr.RetryAfter)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthServiceRefreshExpired(t *testing.T) {
+	now := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	store := new(mockStore)
+	store.On("GetRefreshTokenByHash", mock.Anything, util.HashToken("refresh")).Return(db.RefreshToken{
+		ID:        1,
+		UserID:    1,
+		FamilyID:  "family",
+		ExpiresAt: now.Add(-time.Second),
+	}, nil)
+
+	auth := NewAuthService(store, nil, nil, fixedClock{now}, &config.Config{})
+	_, err := auth.Refresh(context.Background(), "refresh")
+
+	appErr, ok := apperror.As(err)
+	require.True(t, ok)
+	assert.Equal(t, apperror.CodeUnauthorized, appErr.Code)
+	assert.Equal(t, i18n.RefreshTokenExpired, appErr.Message)
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+)
+
+// EmailService は送信待ちのメールと配信を停止したメールの参照と再送を扱います
+type EmailService struct {
+	store db.Store
+	clock Clock
+}
+
+// NewEmailService は新しいEmailServiceを作成します
+func NewEmailService(store db.Store, clock Clock) *EmailService {
+	return &EmailService{
+		store: store,
+		clock: clock,
+	}
+}
+
+// List は指定したステータスのメールを新しい順に取得し、ステータスに一致する全件数とともに返します
+func (s *EmailService) List(ctx context.Context, status db.EmailOutboxStatus, limit, offset int32) ([]db.EmailOutbox, int64, error) {
+	emails, err := s.store.ListOutboxEmails(ctx, db.ListOutboxEmailsParams{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.store.CountOutboxEmails(ctx, status)
+	if err != nil {
+		return nil, 0, err
+	}
+	return emails, total, nil
+}
+
+// Get は指定したIDのメールを取得します
+func (s *EmailService) Get(ctx context.Context, id int64) (db.EmailOutbox, error) {
+	email, err := s.store.GetOutboxEmail(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.EmailOutbox{}, apperror.New(apperror.CodeNotFound, i18n.OutboxEmailNotFound)
+		}
+		return db.EmailOutbox{}, err
+	}
+	return email, nil
+}
+
+// Requeue は配信を停止したメールの試行回数をリセットし、送信待ちに戻します
+// リセット用・確認用のリンクを含むメールは配信を停止したときにリンクを破棄しているため、再送できません
+func (s *EmailService) Requeue(ctx context.Context, id int64) error {
+	email, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if email.Status != db.EmailOutboxStatusDead {
+		return apperror.New(apperror.CodeConflict, i18n.EmailNotRequeueable)
+	}
+	if util.MailDataRedactable(email.Template) {
+		return apperror.New(apperror.CodeConflict, i18n.EmailContentDiscarded)
+	}
+
+	requeued, err := s.store.RequeueEmail(ctx, db.RequeueEmailParams{
+		NextAttemptAt: s.clock.Now(),
+		ID:            id,
+	})
+	if err != nil {
+		return err
+	}
+	// 取得した後に他の管理者が再送した場合は更新されない
+	if requeued == 0 {
+		return apperror.New(apperror.CodeConflict, i18n.EmailNotRequeueable)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailServiceRequeue(t *testing.T) {
+	now := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	dead := db.EmailOutbox{
+		ID:       1,
+		Template: util.MailTemplateAccountLocked,
+		Data:     json.RawMessage(`{"LockedUntil":"2024-01-23T12:34:00Z"}`),
+		Status:   db.EmailOutboxStatusDead,
+	}
+
+	// テストケースの定義
+	tests := []struct {
+		name         string
+		setupMock    func(*mockStore)
+		expectedCode apperror.Code
+		expectedMsg  i18n.Message
+	}{
+		{
+			name: "配信を停止したメールはすぐに送信待ちに戻す",
+			setupMock: func(m *mockStore) {
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(dead, nil)
+				m.On("RequeueEmail", mock.Anything, db.RequeueEmailParams{NextAttemptAt: now, ID: 1}).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "リンクを破棄したメール",
+			setupMock: func(m *mockStore) {
+				verification := dead
+				verification.Template = util.MailTemplateEmailVerification
+				verification.Data = json.RawMessage(`{"VerifyURL":""}`)
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(verification, nil)
+			},
+			expectedCode: apperror.CodeConflict,
+			expectedMsg:  i18n.EmailContentDiscarded,
+		},
+		{
+			name: "配信待ちのメール",
+			setupMock: func(m *mockStore) {
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(db.EmailOutbox{ID: 1, Status: db.EmailOutboxStatusPending}, nil)
+			},
+			expectedCode: apperror.CodeConflict,
+			expectedMsg:  i18n.EmailNotRequeueable,
+		},
+		{
+			name: "取得した後に他の管理者が再送したメール",
+			setupMock: func(m *mockStore) {
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(dead, nil)
+				m.On("RequeueEmail", mock.Anything, mock.AnythingOfType("db.RequeueEmailParams")).Return(int64(0), nil)
+			},
+			expectedCode: apperror.CodeConflict,
+			expectedMsg:  i18n.EmailNotRequeueable,
+		},
+		{
+			name: "存在しないメール",
+			setupMock: func(m *mockStore) {
+				m.On("GetOutboxEmail", mock.Anything, int64(1)).Return(db.EmailOutbox{}, sql.ErrNoRows)
+			},
+			expectedCode: apperror.CodeNotFound,
+			expectedMsg:  i18n.OutboxEmailNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			tt.setupMock(store)
+
+			err := NewEmailService(store, fixedClock{now}).Requeue(context.Background(), 1)
+
+			if tt.expectedCode == "" {
+				assert.NoError(t, err)
+			} else {
+				appErr, ok := apperror.As(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCode, appErr.Code)
+				assert.Equal(t, tt.expectedMsg, appErr.Message)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
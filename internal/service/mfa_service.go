@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// totpIssuer は認証アプリに表示される発行者名です
+	totpIssuer = "Go-Gin-SQLC"
+	// recoveryCodeCount は一度に発行するリカバリーコードの数です
+	recoveryCodeCount = 10
+)
+
+// MFAService はTOTPによる二要素認証の設定とリカバリーコードを扱います
+type MFAService struct {
+	store db.Store
+	clock Clock
+}
+
+// NewMFAService は新しいMFAServiceを作成します
+func NewMFAService(store db.Store, clock Clock) *MFAService {
+	return &MFAService{
+		store: store,
+		clock: clock,
+	}
+}
+
+// TOTPSetup は認証アプリに登録するTOTPシークレットです
+type TOTPSetup struct {
+	Secret string
+	// OTPAuthURI は認証アプリ登録用の otpauth:// のURIです
+	OTPAuthURI string
+}
+
+// SetupTOTP はTOTPシークレットを生成して保存します
+// 確認コードで ConfirmTOTP を呼び出すまで二要素認証は有効になりません
+func (s *MFAService) SetupTOTP(ctx context.Context, userID int64) (TOTPSetup, error) {
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TOTPSetup{}, apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+		}
+		return TOTPSetup{}, err
+	}
+
+	// 既に有効な場合は、無効化してから再設定させる
+	current, err := s.store.GetUserTOTP(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return TOTPSetup{}, err
+	}
+	if err == nil && current.ConfirmedAt.Valid {
+		return TOTPSetup{}, apperror.New(apperror.CodeConflict, i18n.MFAAlreadyEnabled)
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return TOTPSetup{}, apperror.Internal(i18n.SecretGenerationFailed, err)
+	}
+
+	err = s.store.UpsertUserTOTP(ctx, db.UpsertUserTOTPParams{
+		UserID: userID,
+		Secret: secret,
+	})
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+
+	return TOTPSetup{
+		Secret:     secret,
+		OTPAuthURI: util.TOTPURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP は認証アプリが生成したコードを確認して二要素認証を有効にし、発行したリカバリーコードを返します
+func (s *MFAService) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	totp, err := s.store.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.New(apperror.CodeInvalidRequest, i18n.MFASetupNotStarted)
+		}
+		return nil, err
+	}
+	if totp.ConfirmedAt.Valid {
+		return nil, apperror.New(apperror.CodeConflict, i18n.MFAAlreadyEnabled)
+	}
+
+	step, ok := util.ValidateTOTP(totp.Secret, code, s.clock.Now())
+	if !ok {
+		return nil, apperror.New(apperror.CodeInvalidRequest, i18n.InvalidMFACode)
+	}
+
+	codes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, apperror.Internal(i18n.RecoveryCodeGenerationFailed, err)
+	}
+
+	// リカバリーコードがないまま二要素認証が有効にならないよう、まとめて保存する
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		err := q.ConfirmUserTOTP(ctx, db.ConfirmUserTOTPParams{
+			LastUsedStep: step,
+			UserID:       userID,
+		})
+		if err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(ctx, q, userID, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP はパスワードを確認して二要素認証を無効にし、リカバリーコードを破棄します
+func (s *MFAService) DisableTOTP(ctx context.Context, userID int64, password string) error {
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+		}
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return apperror.New(apperror.CodeUnauthorized, i18n.IncorrectPassword)
+	}
+
+	return s.store.ExecTx(ctx, func(q db.Store) error {
+		if err := q.DeleteUserTOTP(ctx, userID); err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodes(ctx, userID)
+	})
+}
+
+// RegenerateRecoveryCodes は認証コードを確認してリカバリーコードを再発行します
+// 以前のリカバリーコードは全て無効になります
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	totp, err := s.store.GetUserTOTP(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == sql.ErrNoRows || !totp.ConfirmedAt.Valid {
+		return nil, apperror.New(apperror.CodeInvalidRequest, i18n.MFANotEnabled)
+	}
+
+	ok, err := verifyTOTPCode(ctx, s.store, totp, code, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperror.New(apperror.CodeInvalidRequest, i18n.InvalidMFACode)
+	}
+
+	codes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, apperror.Internal(i18n.RecoveryCodeGenerationFailed, err)
+	}
+
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		return replaceRecoveryCodes(ctx, q, userID, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// replaceRecoveryCodes は既存のリカバリーコードを破棄し、新しいコードのハッシュを保存します
+// 一部のコードだけが保存されないよう、トランザクション内で呼び出してください
+func replaceRecoveryCodes(ctx context.Context, queries db.Querier, userID int64, codes []string) error {
+	if err := queries.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		err := queries.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: util.HashToken(code),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyTOTPCode はTOTPコードを検証し、使用済みのタイムステップを記録します
+// 一度使用されたコード（またはそれ以前のコード）は再利用できません
+func verifyTOTPCode(ctx context.Context, queries db.Querier, totp db.UserTotp, code string, now time.Time) (bool, error) {
+	step, ok := util.ValidateTOTP(totp.Secret, code, now)
+	if !ok {
+		return false, nil
+	}
+
+	updated, err := queries.UpdateTOTPLastUsedStep(ctx, db.UpdateTOTPLastUsedStepParams{
+		LastUsedStep:   step,
+		UserID:         totp.UserID,
+		LastUsedStep_2: step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+func TestMFAServiceConfirmTOTP(t *testing.T) {
+	now := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	code, err := util.GenerateTOTPCode(testTOTPSecret, now)
+	require.NoError(t, err)
+
+	// テストケースの定義
+	tests := []struct {
+		name        string
+		code        string
+		setupMock   func(*mockStore)
+		expectedErr error
+		expectedMsg i18n.Message
+	}{
+		{
+			name: "有効化とリカバリーコードの保存を1つのトランザクションで行う",
+			code: code,
+			setupMock: func(m *mockStore) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{UserID: 1, Secret: testTOTPSecret}, nil)
+				m.On("ConfirmUserTOTP", mock.Anything, mock.AnythingOfType("db.ConfirmUserTOTPParams")).Return(nil).Run(func(mock.Arguments) {
+					assert.True(t, m.inTx)
+				})
+				m.On("DeleteRecoveryCodes", mock.Anything, int64(1)).Return(nil).Run(func(mock.Arguments) {
+					assert.True(t, m.inTx)
+				})
+				m.On("CreateRecoveryCode", mock.Anything, mock.AnythingOfType("db.CreateRecoveryCodeParams")).Return(nil).Run(func(mock.Arguments) {
+					assert.True(t, m.inTx)
+				}).Times(recoveryCodeCount)
+			},
+		},
+		{
+			name: "リカバリーコードの保存に失敗した場合はエラーを返す",
+			code: code,
+			setupMock: func(m *mockStore) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{UserID: 1, Secret: testTOTPSecret}, nil)
+				m.On("ConfirmUserTOTP", mock.Anything, mock.AnythingOfType("db.ConfirmUserTOTPParams")).Return(nil)
+				m.On("DeleteRecoveryCodes", mock.Anything, int64(1)).Return(nil)
+				m.On("CreateRecoveryCode", mock.Anything, mock.AnythingOfType("db.CreateRecoveryCodeParams")).Return(errors.New("connection lost")).Once()
+			},
+			expectedErr: errors.New("connection lost"),
+		},
+		{
+			name: "誤った確認コード",
+			code: "000000",
+			setupMock: func(m *mockStore) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{UserID: 1, Secret: testTOTPSecret}, nil)
+			},
+			expectedMsg: i18n.InvalidMFACode,
+		},
+		{
+			name: "セットアップ前",
+			code: code,
+			setupMock: func(m *mockStore) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{}, sql.ErrNoRows)
+			},
+			expectedMsg: i18n.MFASetupNotStarted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			tt.setupMock(store)
+
+			mfa := NewMFAService(store, fixedClock{now})
+			codes, err := mfa.ConfirmTOTP(context.Background(), 1, tt.code)
+
+			switch {
+			case tt.expectedErr != nil:
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			case tt.expectedMsg != "":
+				appErr, ok := apperror.As(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedMsg, appErr.Message)
+			default:
+				require.NoError(t, err)
+				assert.Len(t, codes, recoveryCodeCount)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMFAServiceDisableTOTP(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := db.User{ID: 1, PasswordHash: string(hashedPassword)}
+
+	t.Run("TOTPとリカバリーコードを1つのトランザクションで削除する", func(t *testing.T) {
+		store := new(mockStore)
+		store.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+		store.On("DeleteUserTOTP", mock.Anything, int64(1)).Return(nil).Run(func(mock.Arguments) {
+			assert.True(t, store.inTx)
+		})
+		store.On("DeleteRecoveryCodes", mock.Anything, int64(1)).Return(nil).Run(func(mock.Arguments) {
+			assert.True(t, store.inTx)
+		})
+
+		err := NewMFAService(store, SystemClock{}).DisableTOTP(context.Background(), 1, "password123")
+		require.NoError(t, err)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("パスワードが正しくない場合は削除しない", func(t *testing.T) {
+		store := new(mockStore)
+		store.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+
+		err := NewMFAService(store, SystemClock{}).DisableTOTP(context.Background(), 1, "wrongpassword")
+		appErr, ok := apperror.As(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.CodeUnauthorized, appErr.Code)
+		assert.Equal(t, i18n.IncorrectPassword, appErr.Message)
+		store.AssertExpectations(t)
+	})
+}
+
+func TestMFAServiceRegenerateRecoveryCodes(t *testing.T) {
+	now := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	code, err := util.GenerateTOTPCode(testTOTPSecret, now)
+	require.NoError(t, err)
+	enabled := db.UserTotp{
+		UserID:      1,
+		Secret:      testTOTPSecret,
+		ConfirmedAt: sql.NullTime{Time: now.Add(-time.Hour), Valid: true},
+	}
+
+	// テストケースの定義
+	tests := []struct {
+		name        string
+		setupMock   func(*mockStore)
+		expectedMsg i18n.Message
+	}{
+		{
+			name: "以前のコードを破棄して新しいコードを保存する",
+			setupMock: func(m *mockStore) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(enabled, nil)
+				m.On("UpdateTOTPLastUsedStep", mock.Anything, mock.AnythingOfType("db.UpdateTOTPLastUsedStepParams")).Return(int64(1), nil)
+				m.On("DeleteRecoveryCodes", mock.Anything, int64(1)).Return(nil).Run(func(mock.Arguments) {
+					assert.True(t, m.inTx)
+				})
+				m.On("CreateRecoveryCode", mock.Anything, mock.AnythingOfType("db.CreateRecoveryCodeParams")).Return(nil).Run(func(mock.Arguments) {
+					assert.True(t, m.inTx)
+				}).Times(recoveryCodeCount)
+			},
+		},
+		{
+			name: "使用済みの認証コード",
+			setupMock: func(m *mockStore) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(enabled, nil)
+				m.On("UpdateTOTPLastUsedStep", mock.Anything, mock.AnythingOfType("db.UpdateTOTPLastUsedStepParams")).Return(int64(0), nil)
+			},
+			expectedMsg: i18n.InvalidMFACode,
+		},
+		{
+			name: "二要素認証が有効でない",
+			setupMock: func(m *mockStore) {
+				m.On("GetUserTOTP", mock.Anything, int64(1)).Return(db.UserTotp{UserID: 1, Secret: testTOTPSecret}, nil)
+			},
+			expectedMsg: i18n.MFANotEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			tt.setupMock(store)
+
+			mfa := NewMFAService(store, fixedClock{now})
+			codes, err := mfa.RegenerateRecoveryCodes(context.Background(), 1, code)
+
+			if tt.expectedMsg == "" {
+				require.NoError(t, err)
+				assert.Len(t, codes, recoveryCodeCount)
+			} else {
+				appErr, ok := apperror.As(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedMsg, appErr.Message)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
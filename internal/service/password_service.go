@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordService はパスワードのリセットと変更を扱います
+type PasswordService struct {
+	store       db.Store
+	revocations util.RevocationStore
+	clock       Clock
+	config      *config.Config
+}
+
+// NewPasswordService は新しいPasswordServiceを作成します
+func NewPasswordService(store db.Store, revocations util.RevocationStore, clock Clock, cfg *config.Config) *PasswordService {
+	return &PasswordService{
+		store:       store,
+		revocations: revocations,
+		clock:       clock,
+		config:      cfg,
+	}
+}
+
+// RequestReset はパスワードリセット用のリンクを発行し、メールを送信待ちに追加します
+// アカウントの存在を推測されないよう、ユーザーが存在しない場合もエラーを返しません
+// locale はユーザーが言語を設定していない場合のメールの言語です
+func (s *PasswordService) RequestReset(ctx context.Context, email string, locale i18n.Locale) error {
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	token, err := util.GenerateRandomToken(32)
+	if err != nil {
+		return apperror.Internal(i18n.TokenGenerationFailed, err)
+	}
+	resetURL := s.config.BaseURL + "/reset-password?token=" + token
+
+	// パスワードリセットレコードの作成とメールの送信待ちへの追加
+	// データベースにはトークンのハッシュだけを保存し、以前に発行したトークンは使用できなくする
+	// メールはバックグラウンドで配信するため、SMTPサーバーの障害でリクエストが失敗することはない
+	expiresAt := s.clock.Now().Add(s.config.PasswordResetTTL)
+	return s.store.ExecTx(ctx, func(q db.Store) error {
+		if err := q.DeleteUserPasswordResets(ctx, user.ID); err != nil {
+			return err
+		}
+
+		_, err := q.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+			UserID:    user.ID,
+			TokenHash: util.HashToken(token),
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		data := util.PasswordResetMail{ResetURL: resetURL, ExpiresAt: expiresAt}
+		return util.EnqueueMail(ctx, q, user.Email, util.MailTemplatePasswordReset, userLocale(user, locale), data)
+	})
+}
+
+// Reset はリセット用のトークンを検証してパスワードを変更します
+// トークンは1回だけ使用でき、リセット後は全てのトークンを失効させて再度のログインを求めます
+func (s *PasswordService) Reset(ctx context.Context, token, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// トークンの検証とパスワードの更新
+	// 同じトークンでの同時のリセットは、行のロックによって一方だけが成功する
+	var userID int64
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		reset, err := q.GetPasswordResetByHash(ctx, util.HashToken(token))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperror.New(apperror.CodeInvalidRequest, i18n.InvalidToken)
+			}
+			return err
+		}
+		userID = reset.UserID
+
+		err = q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:           reset.UserID,
+			PasswordHash: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 使用したトークンと、他に発行済みのトークンをまとめて削除する
+		if err := q.DeleteUserPasswordResets(ctx, reset.UserID); err != nil {
+			return err
+		}
+
+		// 第三者に使われている可能性のあるセッションを残さないよう、リフレッシュトークンを失効させる
+		return q.RevokeUserRefreshTokens(ctx, reset.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 発行済みのアクセストークンも失効させる
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return apperror.Internal(i18n.TokenRevocationFailed, err)
+	}
+	return nil
+}
+
+// Change は現在のパスワードを確認した上でパスワードを変更します
+// 変更後は全てのトークンを失効させ、再度のログインを求めます
+func (s *PasswordService) Change(ctx context.Context, userID int64, currentPassword, newPassword string) error {
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+		}
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return apperror.New(apperror.CodeUnauthorized, i18n.IncorrectCurrentPassword)
+	}
+
+	return s.setPassword(ctx, userID, newPassword)
+}
+
+// Set は現在のパスワードを確認せずにパスワードを設定します。管理者によるリセットに使用します
+// 変更後は全てのトークンを失効させ、再度のログインを求めます
+func (s *PasswordService) Set(ctx context.Context, userID int64, newPassword string) error {
+	if _, err := s.store.GetUser(ctx, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+		}
+		return err
+	}
+	return s.setPassword(ctx, userID, newPassword)
+}
+
+// setPassword はパスワードを更新し、発行済みのリセット用のリンクとトークンを全て無効にします
+func (s *PasswordService) setPassword(ctx context.Context, userID int64, newPassword string) error {
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			ID:           userID,
+			PasswordHash: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 変更前に発行したパスワードリセット用のリンクは使用できなくする
+		if err := q.DeleteUserPasswordResets(ctx, userID); err != nil {
+			return err
+		}
+
+		// 漏洩したパスワードで作られたセッションを残さないよう、全てのトークンを失効させる
+		return q.RevokeUserRefreshTokens(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return apperror.Internal(i18n.TokenRevocationFailed, err)
+	}
+	return nil
+}
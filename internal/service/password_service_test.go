@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordServiceRequestReset(t *testing.T) {
+	now := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	expiresAt := now.Add(2 * time.Hour)
+	user := db.User{
+		ID:     1,
+		Email:  "test@example.com",
+		Locale: sql.NullString{String: "en", Valid: true},
+	}
+
+	store := new(mockStore)
+	store.On("GetUserByEmail", mock.Anything, "test@example.com").Return(user, nil)
+	store.On("DeleteUserPasswordResets", mock.Anything, int64(1)).Return(nil)
+	store.On("CreatePasswordReset", mock.Anything, mock.MatchedBy(func(arg db.CreatePasswordResetParams) bool {
+		return arg.UserID == 1 && len(arg.TokenHash) == 64 && arg.ExpiresAt.Equal(expiresAt)
+	})).Return(nil, nil)
+	// ユーザーが設定した言語で、有効期限を含むメールを送信待ちに追加する
+	store.On("EnqueueEmail", mock.Anything, mock.MatchedBy(func(arg db.EnqueueEmailParams) bool {
+		var data util.PasswordResetMail
+		if err := json.Unmarshal(arg.Data, &data); err != nil {
+			return false
+		}
+		return arg.Recipient == "test@example.com" &&
+			arg.Template == util.MailTemplatePasswordReset &&
+			arg.Locale == string(i18n.English) &&
+			data.ExpiresAt.Equal(expiresAt)
+	})).Return(nil)
+
+	passwords := NewPasswordService(store, nil, fixedClock{now}, &config.Config{
+		BaseURL:          "http://localhost:8080",
+		PasswordResetTTL: 2 * time.Hour,
+	})
+	err := passwords.RequestReset(context.Background(), "test@example.com", i18n.Japanese)
+	require.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestPasswordServiceRequestResetUnknownUser(t *testing.T) {
+	store := new(mockStore)
+	store.On("GetUserByEmail", mock.Anything, "unknown@example.com").Return(db.User{}, sql.ErrNoRows)
+
+	passwords := NewPasswordService(store, nil, SystemClock{}, &config.Config{})
+	// アカウントの存在を推測されないよう、エラーにしない
+	err := passwords.RequestReset(context.Background(), "unknown@example.com", i18n.Japanese)
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestPasswordServiceSet(t *testing.T) {
+	store := new(mockStore)
+	revocations := new(mockRevocations)
+	store.On("GetUser", mock.Anything, int64(1)).Return(db.User{ID: 1}, nil)
+	store.On("UpdateUserPassword", mock.Anything, mock.MatchedBy(func(arg db.UpdateUserPasswordParams) bool {
+		return arg.ID == 1 && bcrypt.CompareHashAndPassword([]byte(arg.PasswordHash), []byte("newpassword")) == nil
+	})).Return(nil)
+	// 発行済みのリセット用のリンクとトークンは全て使用できなくする
+	store.On("DeleteUserPasswordResets", mock.Anything, int64(1)).Return(nil)
+	store.On("RevokeUserRefreshTokens", mock.Anything, int64(1)).Return(nil)
+	revocations.On("RevokeAllForUser", mock.Anything, int64(1)).Return(nil)
+
+	passwords := NewPasswordService(store, revocations, SystemClock{}, &config.Config{})
+	err := passwords.Set(context.Background(), 1, "newpassword")
+	require.NoError(t, err)
+	store.AssertExpectations(t)
+	revocations.AssertExpectations(t)
+
+	t.Run("存在しないユーザー", func(t *testing.T) {
+		store := new(mockStore)
+		store.On("GetUser", mock.Anything, int64(99)).Return(db.User{}, sql.ErrNoRows)
+
+		passwords := NewPasswordService(store, nil, SystemClock{}, &config.Config{})
+		err := passwords.Set(context.Background(), 99, "newpassword")
+		appErr, ok := apperror.As(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.CodeNotFound, appErr.Code)
+	})
+}
@@ -0,0 +1,57 @@
+// Package service はHTTPなどの入出力に依存しない業務処理を提供します
+// ハンドラーやコマンドは入力をサービスの引数に変換して呼び出し、結果を出力に変換するだけにします
+// クライアントに返せるエラーは *apperror.Error で返し、それ以外は内部のエラーとして扱います
+package service
+
+import (
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/i18n"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Clock は現在時刻を返します。テストで時刻を固定するために使用します
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock はシステムの現在時刻を返す Clock です
+type SystemClock struct{}
+
+// Now は現在時刻を返します
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// hashPassword はパスワードを保存用にハッシュ化します
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", apperror.Internal(i18n.PasswordHashFailed, err)
+	}
+	return string(hashed), nil
+}
+
+// isActive はユーザーが有効な状態かを返します
+func isActive(user db.User) bool {
+	return user.Status.Valid && user.Status.UsersStatus == db.UsersStatusActive
+}
+
+// isPendingVerification はユーザーがメールアドレスの確認待ちの状態かを返します
+func isPendingVerification(user db.User) bool {
+	return user.Status.Valid && user.Status.UsersStatus == db.UsersStatusPendingVerification
+}
+
+// userLocale はユーザーに送るメールの言語を返します
+// ユーザーが言語を設定している場合はその言語を、設定していない場合は fallback を使用します
+func userLocale(user db.User, fallback i18n.Locale) i18n.Locale {
+	if user.Locale.Valid {
+		if locale, ok := i18n.Parse(user.Locale.String); ok {
+			return locale
+		}
+	}
+	return fallback
+}
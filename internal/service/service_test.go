@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/util"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// TestMain はテストで発行・検証するトークンの署名鍵を設定してからテストを実行します
+func TestMain(m *testing.M) {
+	keys, err := util.NewKeySet(util.JWTConfig{Algorithm: "HS256", Secret: "test-secret"})
+	if err != nil {
+		panic(err)
+	}
+	util.SetKeySet(keys)
+	os.Exit(m.Run())
+}
+
+// fixedClock は常に同じ時刻を返す Clock です
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// mockStore はサービスのテストで使用するクエリだけを実装した Store のモックです
+type mockStore struct {
+	mock.Mock
+	db.Store
+	// inTx は ExecTx で fn を実行している間 true になります
+	inTx bool
+}
+
+func (m *mockStore) ExecTx(ctx context.Context, fn func(db.Store) error) error {
+	m.inTx = true
+	defer func() { m.inTx = false }()
+	return fn(m)
+}
+
+func (m *mockStore) GetUser(ctx context.Context, id int64) (db.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.User), args.Error(1)
+}
+
+func (m *mockStore) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(db.User), args.Error(1)
+}
+
+func (m *mockStore) UserEmailExists(ctx context.Context, email string) (bool, error) {
+	args := m.Called(ctx, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockStore) UpdateUser(ctx context.Context, arg db.UpdateUserParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockStore) UpdateUserStatus(ctx context.Context, arg db.UpdateUserStatusParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockStore) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *mockStore) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *mockStore) RestoreUser(ctx context.Context, arg db.RestoreUserParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockStore) GetEmailVerification(ctx context.Context, userID int64) (db.EmailVerification, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(db.EmailVerification), args.Error(1)
+}
+
+func (m *mockStore) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (db.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(db.RefreshToken), args.Error(1)
+}
+
+func (m *mockStore) DeleteUser(ctx context.Context, arg db.DeleteUserParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockStore) DeleteUserPasswordResets(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *mockStore) CreatePasswordReset(ctx context.Context, arg db.CreatePasswordResetParams) (sql.Result, error) {
+	args := m.Called(ctx, arg)
+	return nil, args.Error(1)
+}
+
+func (m *mockStore) EnqueueEmail(ctx context.Context, arg db.EnqueueEmailParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *mockStore) GetUserTOTP(ctx context.Context, userID int64) (db.UserTotp, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(db.UserTotp), args.Error(1)
+}
+
+func (m *mockStore) ConfirmUserTOTP(ctx context.Context, arg db.ConfirmUserTOTPParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *mockStore) UpdateTOTPLastUsedStep(ctx context.Context, arg db.UpdateTOTPLastUsedStepParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockStore) DeleteUserTOTP(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *mockStore) CreateRecoveryCode(ctx context.Context, arg db.CreateRecoveryCodeParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *mockStore) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *mockStore) GetOutboxEmail(ctx context.Context, id int64) (db.EmailOutbox, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.EmailOutbox), args.Error(1)
+}
+
+func (m *mockStore) RequeueEmail(ctx context.Context, arg db.RequeueEmailParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// mockRevocations はアクセストークンの失効リストのモックです
+type mockRevocations struct {
+	mock.Mock
+}
+
+func (m *mockRevocations) Revoke(ctx context.Context, claims *util.Claims) error {
+	args := m.Called(ctx, claims)
+	return args.Error(0)
+}
+
+func (m *mockRevocations) RevokeAllForUser(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *mockRevocations) IsRevoked(claims *util.Claims) bool {
+	return m.Called(claims).Bool(0)
+}
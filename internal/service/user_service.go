@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+	"go-gin-sqlc/internal/util"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserService はユーザーの作成、更新、削除と復元を扱います
+type UserService struct {
+	store       db.Store
+	revocations util.RevocationStore
+	loginGuard  util.LoginGuard
+	clock       Clock
+	config      *config.Config
+}
+
+// NewUserService は新しいUserServiceを作成します
+func NewUserService(store db.Store, revocations util.RevocationStore, loginGuard util.LoginGuard, clock Clock, cfg *config.Config) *UserService {
+	return &UserService{
+		store:       store,
+		revocations: revocations,
+		loginGuard:  loginGuard,
+		clock:       clock,
+		config:      cfg,
+	}
+}
+
+// CreateUserInput はユーザー作成の入力です
+type CreateUserInput struct {
+	Email     string
+	Password  string
+	FirstName string
+	LastName  string
+	// Roles は付与するロールです。省略した場合は一般ユーザーのロールだけを付与します
+	Roles []string
+}
+
+// UserChanges は更新後のユーザー情報です。全ての項目をこの値で置き換えます
+type UserChanges struct {
+	Email     string
+	FirstName string
+	LastName  string
+	Status    db.NullUsersStatus
+}
+
+// ProfileChanges は本人によるプロフィールの変更です。空の項目は変更しません
+type ProfileChanges struct {
+	// Email は新しいアドレスの所有を確認できないため、現在と異なる場合は拒否します
+	Email     string
+	FirstName string
+	LastName  string
+	Locale    string
+}
+
+// SearchUsersInput はユーザー検索の入力です
+type SearchUsersInput struct {
+	// Query は検索キーワードです。空の場合は全てのユーザーを対象にします
+	Query  string
+	Status db.NullUsersStatus
+	Limit  int32
+	Offset int32
+}
+
+// Create は新しいユーザーを作成し、作成したユーザーを返します
+func (s *UserService) Create(ctx context.Context, in CreateUserInput) (db.User, error) {
+	// メールアドレスの重複チェック（削除済みで復元可能なユーザーを含む）
+	if err := s.checkEmailAvailable(ctx, in.Email); err != nil {
+		return db.User{}, err
+	}
+
+	hashedPassword, err := hashPassword(in.Password)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	// ユーザーの作成とロールの付与
+	// 途中で失敗した場合にロールのないユーザーが残らないよう、1つのトランザクションで実行する
+	var user db.User
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		result, err := q.CreateUser(ctx, db.CreateUserParams{
+			Email:        in.Email,
+			PasswordHash: hashedPassword,
+			FirstName:    in.FirstName,
+			LastName:     in.LastName,
+			Status:       db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// 作成されたユーザーIDの取得
+		id, err := result.LastInsertId()
+		if err != nil {
+			return apperror.Internal(i18n.UserIDFetchFailed, err)
+		}
+
+		// ロールを付与（省略時は一般ユーザーのロール）
+		roles := in.Roles
+		if len(roles) == 0 {
+			roles = []string{util.RoleUser}
+		}
+		for _, role := range roles {
+			if err := q.AssignUserRole(ctx, db.AssignUserRoleParams{UserID: id, RoleName: role}); err != nil {
+				return err
+			}
+		}
+
+		// 作成されたユーザーの取得
+		user, err = q.GetUser(ctx, id)
+		if err != nil {
+			return apperror.Internal(i18n.UserFetchFailed, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+// Get は指定されたIDのユーザーを取得します
+func (s *UserService) Get(ctx context.Context, id int64) (db.User, error) {
+	user, err := s.store.GetUser(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.User{}, apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+		}
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+// GetByEmail は指定されたメールアドレスのユーザーを取得します
+func (s *UserService) GetByEmail(ctx context.Context, email string) (db.User, error) {
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.User{}, apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+		}
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+// List は絞り込み条件と並び順を指定してユーザー一覧を取得します
+func (s *UserService) List(ctx context.Context, params db.FilterUsersParams) ([]db.User, error) {
+	users, err := s.store.FilterUsers(ctx, params)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidKeyset) {
+			return nil, apperror.New(apperror.CodeInvalidRequest, i18n.InvalidCursor)
+		}
+		return nil, err
+	}
+	return users, nil
+}
+
+// Count は絞り込み条件に一致するユーザーの件数を取得します
+func (s *UserService) Count(ctx context.Context, filter db.UserFilter) (int64, error) {
+	return s.store.CountFilteredUsers(ctx, filter)
+}
+
+// Search はメールアドレスと氏名の全文検索でユーザーを検索し、条件に一致する全件数とともに返します
+func (s *UserService) Search(ctx context.Context, in SearchUsersInput) ([]db.User, int64, error) {
+	phrase := toFulltextPhrase(in.Query)
+
+	users, err := s.store.SearchUsers(ctx, db.SearchUsersParams{
+		Query:  phrase,
+		Status: in.Status,
+		Limit:  in.Limit,
+		Offset: in.Offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.store.CountSearchUsers(ctx, db.CountSearchUsersParams{
+		Query:  phrase,
+		Status: in.Status,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// toFulltextPhrase は検索キーワードをBOOLEAN MODEのフレーズ検索に変換します
+// ngramパーサーでは連続した文字列として一致させるため、演算子として解釈されないよう引用符で囲みます
+func toFulltextPhrase(query string) string {
+	query = strings.TrimSpace(strings.ReplaceAll(query, `"`, " "))
+	if query == "" {
+		return ""
+	}
+	return `"` + query + `"`
+}
+
+// Update は current のユーザー情報を changes の内容で更新し、更新後のユーザーを返します
+// expectedVersion を指定した場合は、バージョンが一致するときだけ更新します
+func (s *UserService) Update(ctx context.Context, current db.User, changes UserChanges, expectedVersion sql.NullInt32) (db.User, error) {
+	// メールアドレスの重複チェック（削除済みで復元可能なユーザーを含む）
+	if changes.Email != current.Email {
+		if err := s.checkEmailAvailable(ctx, changes.Email); err != nil {
+			return db.User{}, err
+		}
+	}
+
+	updated, err := s.store.UpdateUser(ctx, db.UpdateUserParams{
+		ID:              current.ID,
+		Email:           changes.Email,
+		FirstName:       changes.FirstName,
+		LastName:        changes.LastName,
+		Status:          changes.Status,
+		ExpectedVersion: expectedVersion,
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	if updated == 0 {
+		return db.User{}, updateConflict(expectedVersion)
+	}
+
+	// 更新後のユーザー情報を取得
+	user, err := s.store.GetUser(ctx, current.ID)
+	if err != nil {
+		return db.User{}, apperror.Internal(i18n.UpdatedUserFetchFailed, err)
+	}
+	return user, nil
+}
+
+// UpdateProfile は本人のプロフィールを部分的に更新し、更新後のユーザーを返します
+// ステータスは変更しません
+func (s *UserService) UpdateProfile(ctx context.Context, userID int64, changes ProfileChanges) (db.User, error) {
+	current, err := s.Get(ctx, userID)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	// 確認していないアドレスにパスワードリセットなどのメールを送信させないため、メールアドレスは変更させない
+	if changes.Email != "" && changes.Email != current.Email {
+		return db.User{}, apperror.New(apperror.CodeInvalidRequest, i18n.EmailChangeNotAllowed)
+	}
+
+	// 指定されなかった項目とステータスは現在の値を使用
+	params := db.UpdateUserParams{
+		ID:        userID,
+		Email:     current.Email,
+		FirstName: current.FirstName,
+		LastName:  current.LastName,
+		Status:    current.Status,
+	}
+	if changes.FirstName != "" {
+		params.FirstName = changes.FirstName
+	}
+	if changes.LastName != "" {
+		params.LastName = changes.LastName
+	}
+
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		if _, err := q.UpdateUser(ctx, params); err != nil {
+			return err
+		}
+
+		if changes.Locale == "" || changes.Locale == current.Locale.String {
+			return nil
+		}
+		_, err := q.UpdateUserLocale(ctx, db.UpdateUserLocaleParams{
+			ID:     userID,
+			Locale: sql.NullString{String: changes.Locale, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return db.User{}, apperror.Internal(i18n.UpdatedUserFetchFailed, err)
+	}
+	return user, nil
+}
+
+// SetStatus はユーザーのステータスだけを変更します
+// 有効以外のステータスにした場合は、利用中のセッションが残らないよう全てのトークンを失効させます
+func (s *UserService) SetStatus(ctx context.Context, id int64, status db.UsersStatus) error {
+	var updated int64
+	err := s.store.ExecTx(ctx, func(q db.Store) error {
+		var err error
+		updated, err = q.UpdateUserStatus(ctx, db.UpdateUserStatusParams{
+			ID:     id,
+			Status: db.NullUsersStatus{UsersStatus: status, Valid: true},
+		})
+		if err != nil || updated == 0 || status == db.UsersStatusActive {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+	}
+
+	if status != db.UsersStatusActive {
+		if err := s.revocations.RevokeAllForUser(ctx, id); err != nil {
+			return apperror.Internal(i18n.TokenRevocationFailed, err)
+		}
+	}
+	return nil
+}
+
+// Delete は指定されたIDのユーザーを論理削除し、全てのトークンを失効させます
+// expectedVersion を指定した場合は、バージョンが一致するときだけ削除します
+func (s *UserService) Delete(ctx context.Context, id int64, expectedVersion sql.NullInt32) error {
+	// 削除とリフレッシュトークンの失効を1つのトランザクションで実行する
+	var deleted int64
+	err := s.store.ExecTx(ctx, func(q db.Store) error {
+		var err error
+		deleted, err = q.DeleteUser(ctx, db.DeleteUserParams{ID: id, ExpectedVersion: expectedVersion})
+		if err != nil || deleted == 0 {
+			return err
+		}
+
+		// 復元された際に古いセッションが使えないよう、リフレッシュトークンを失効させる
+		return q.RevokeUserRefreshTokens(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return updateConflict(expectedVersion)
+	}
+
+	// 削除したユーザーのアクセストークンが有効期限まで使えないよう失効させる
+	if err := s.revocations.RevokeAllForUser(ctx, id); err != nil {
+		return apperror.Internal(i18n.TokenRevocationFailed, err)
+	}
+	return nil
+}
+
+// DeleteAccount はパスワードを確認した上で本人のアカウントを論理削除し、全てのトークンを失効させます
+func (s *UserService) DeleteAccount(ctx context.Context, userID int64, password string) error {
+	user, err := s.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return apperror.New(apperror.CodeUnauthorized, i18n.IncorrectPassword)
+	}
+
+	err = s.store.ExecTx(ctx, func(q db.Store) error {
+		if _, err := q.DeleteUser(ctx, db.DeleteUserParams{ID: userID}); err != nil {
+			return err
+		}
+
+		// 復元された際に古いセッションが使えないよう、全てのトークンを失効させる
+		return q.RevokeUserRefreshTokens(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return apperror.Internal(i18n.TokenRevocationFailed, err)
+	}
+	return nil
+}
+
+// ListDeleted は削除済みのユーザー一覧を削除日時の新しい順に取得し、全件数とともに返します
+func (s *UserService) ListDeleted(ctx context.Context, limit, offset int32) ([]db.User, int64, error) {
+	users, err := s.store.ListDeletedUsers(ctx, db.ListDeletedUsersParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.store.CountDeletedUsers(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Restore は保持期間内に削除されたユーザーを復元し、復元したユーザーを返します
+func (s *UserService) Restore(ctx context.Context, id int64) (db.User, error) {
+	restored, err := s.store.RestoreUser(ctx, db.RestoreUserParams{
+		ID:           id,
+		DeletedAfter: sql.NullTime{Time: s.clock.Now().Add(-s.config.UserRetention), Valid: true},
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	if restored == 0 {
+		return db.User{}, apperror.New(apperror.CodeNotFound, i18n.RestorableUserNotFound)
+	}
+
+	user, err := s.store.GetUser(ctx, id)
+	if err != nil {
+		return db.User{}, apperror.Internal(i18n.UserFetchFailed, err)
+	}
+	return user, nil
+}
+
+// Unlock はログイン失敗によるアカウントのロックを解除します
+func (s *UserService) Unlock(ctx context.Context, id int64) error {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.loginGuard == nil {
+		return nil
+	}
+	return s.loginGuard.Reset(ctx, user.Email)
+}
+
+// checkEmailAvailable はメールアドレスが他のユーザーに使われていないかを確認します
+// 削除済みで復元可能なユーザーのメールアドレスも使用できません
+func (s *UserService) checkEmailAvailable(ctx context.Context, email string) error {
+	exists, err := s.store.UserEmailExists(ctx, email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return apperror.New(apperror.CodeAlreadyExists, i18n.EmailAlreadyRegistered)
+	}
+	return nil
+}
+
+// updateConflict は更新・削除の対象行がなかった場合のエラーを返します
+// バージョンを指定していた場合は、取得後に他の操作で更新または削除されたとみなします
+func updateConflict(expectedVersion sql.NullInt32) error {
+	if expectedVersion.Valid {
+		return apperror.New(apperror.CodePreconditionFailed, i18n.UserModified)
+	}
+	return apperror.New(apperror.CodeNotFound, i18n.UserNotFound)
+}
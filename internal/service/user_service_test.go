@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/apperror"
+	"go-gin-sqlc/internal/config"
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserServiceUpdate(t *testing.T) {
+	current := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		FirstName: "Test",
+		LastName:  "User",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+	}
+
+	// テストケースの定義
+	tests := []struct {
+		name            string
+		changes         UserChanges
+		expectedVersion sql.NullInt32
+		setupMock       func(*mockStore)
+		expectedCode    apperror.Code
+	}{
+		{
+			name:    "名前の変更",
+			changes: UserChanges{Email: "test@example.com", FirstName: "New", LastName: "User", Status: current.Status},
+			setupMock: func(m *mockStore) {
+				m.On("UpdateUser", mock.Anything, mock.MatchedBy(func(arg db.UpdateUserParams) bool {
+					return arg.ID == 1 && arg.FirstName == "New"
+				})).Return(int64(1), nil)
+				m.On("GetUser", mock.Anything, int64(1)).Return(current, nil)
+			},
+		},
+		{
+			name:    "登録済みのメールアドレスへの変更",
+			changes: UserChanges{Email: "taken@example.com", FirstName: "Test", LastName: "User", Status: current.Status},
+			setupMock: func(m *mockStore) {
+				m.On("UserEmailExists", mock.Anything, "taken@example.com").Return(true, nil)
+			},
+			expectedCode: apperror.CodeAlreadyExists,
+		},
+		{
+			name:            "取得後に他の操作で更新された",
+			changes:         UserChanges{Email: "test@example.com", FirstName: "New", LastName: "User", Status: current.Status},
+			expectedVersion: sql.NullInt32{Int32: 1, Valid: true},
+			setupMock: func(m *mockStore) {
+				m.On("UpdateUser", mock.Anything, mock.AnythingOfType("db.UpdateUserParams")).Return(int64(0), nil)
+			},
+			expectedCode: apperror.CodePreconditionFailed,
+		},
+		{
+			name:    "更新中に削除された",
+			changes: UserChanges{Email: "test@example.com", FirstName: "New", LastName: "User", Status: current.Status},
+			setupMock: func(m *mockStore) {
+				m.On("UpdateUser", mock.Anything, mock.AnythingOfType("db.UpdateUserParams")).Return(int64(0), nil)
+			},
+			expectedCode: apperror.CodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			tt.setupMock(store)
+
+			users := NewUserService(store, nil, nil, SystemClock{}, &config.Config{})
+			_, err := users.Update(context.Background(), current, tt.changes, tt.expectedVersion)
+
+			if tt.expectedCode == "" {
+				assert.NoError(t, err)
+			} else {
+				appErr, ok := apperror.As(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCode, appErr.Code)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserServiceUpdateProfile(t *testing.T) {
+	current := db.User{
+		ID:        1,
+		Email:     "test@example.com",
+		FirstName: "Test",
+		LastName:  "User",
+		Status:    db.NullUsersStatus{UsersStatus: db.UsersStatusActive, Valid: true},
+	}
+
+	t.Run("確認していないメールアドレスには変更しない", func(t *testing.T) {
+		store := new(mockStore)
+		store.On("GetUser", mock.Anything, int64(1)).Return(current, nil)
+
+		_, err := NewUserService(store, nil, nil, SystemClock{}, &config.Config{}).UpdateProfile(context.Background(), 1, ProfileChanges{
+			Email:     "new@example.com",
+			FirstName: "New",
+		})
+		appErr, ok := apperror.As(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.CodeInvalidRequest, appErr.Code)
+		assert.Equal(t, i18n.EmailChangeNotAllowed, appErr.Message)
+		// 他の項目も含めて何も更新しない
+		store.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("名前だけを変更する", func(t *testing.T) {
+		store := new(mockStore)
+		store.On("GetUser", mock.Anything, int64(1)).Return(current, nil)
+		store.On("UpdateUser", mock.Anything, db.UpdateUserParams{
+			ID:        1,
+			Email:     "test@example.com",
+			FirstName: "New",
+			LastName:  "User",
+			Status:    current.Status,
+		}).Return(int64(1), nil)
+
+		_, err := NewUserService(store, nil, nil, SystemClock{}, &config.Config{}).UpdateProfile(context.Background(), 1, ProfileChanges{FirstName: "New"})
+		require.NoError(t, err)
+		store.AssertExpectations(t)
+	})
+}
+
+func TestUserServiceRestore(t *testing.T) {
+	now := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	store := new(mockStore)
+	// 保持期間内に削除されたユーザーだけを復元する
+	store.On("RestoreUser", mock.Anything, db.RestoreUserParams{
+		ID:           1,
+		DeletedAfter: sql.NullTime{Time: now.Add(-30 * 24 * time.Hour), Valid: true},
+	}).Return(int64(1), nil)
+	store.On("GetUser", mock.Anything, int64(1)).Return(db.User{ID: 1}, nil)
+
+	users := NewUserService(store, nil, nil, fixedClock{now}, &config.Config{UserRetention: 30 * 24 * time.Hour})
+	user, err := users.Restore(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), user.ID)
+	store.AssertExpectations(t)
+}
+
+func TestUserServiceSetStatus(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name         string
+		status       db.UsersStatus
+		setupMock    func(*mockStore, *mockRevocations)
+		expectedCode apperror.Code
+	}{
+		{
+			name:   "停止した場合は全てのトークンを失効させる",
+			status: db.UsersStatusSuspended,
+			setupMock: func(m *mockStore, r *mockRevocations) {
+				m.On("UpdateUserStatus", mock.Anything, db.UpdateUserStatusParams{
+					ID:     1,
+					Status: db.NullUsersStatus{UsersStatus: db.UsersStatusSuspended, Valid: true},
+				}).Return(int64(1), nil)
+				m.On("RevokeUserRefreshTokens", mock.Anything, int64(1)).Return(nil)
+				r.On("RevokeAllForUser", mock.Anything, int64(1)).Return(nil)
+			},
+		},
+		{
+			name:   "有効にした場合はトークンを失効させない",
+			status: db.UsersStatusActive,
+			setupMock: func(m *mockStore, r *mockRevocations) {
+				m.On("UpdateUserStatus", mock.Anything, mock.AnythingOfType("db.UpdateUserStatusParams")).Return(int64(1), nil)
+			},
+		},
+		{
+			name:   "存在しないユーザー",
+			status: db.UsersStatusInactive,
+			setupMock: func(m *mockStore, r *mockRevocations) {
+				m.On("UpdateUserStatus", mock.Anything, mock.AnythingOfType("db.UpdateUserStatusParams")).Return(int64(0), nil)
+			},
+			expectedCode: apperror.CodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			revocations := new(mockRevocations)
+			tt.setupMock(store, revocations)
+
+			users := NewUserService(store, revocations, nil, SystemClock{}, &config.Config{})
+			err := users.SetStatus(context.Background(), 1, tt.status)
+
+			if tt.expectedCode == "" {
+				assert.NoError(t, err)
+			} else {
+				appErr, ok := apperror.As(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCode, appErr.Code)
+			}
+			store.AssertExpectations(t)
+			revocations.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserServiceDelete(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name            string
+		expectedVersion sql.NullInt32
+		setupMock       func(*mockStore, *mockRevocations)
+		expectedCode    apperror.Code
+	}{
+		{
+			name: "削除した場合は全てのトークンを失効させる",
+			setupMock: func(m *mockStore, r *mockRevocations) {
+				m.On("DeleteUser", mock.Anything, db.DeleteUserParams{ID: 1}).Return(int64(1), nil)
+				m.On("RevokeUserRefreshTokens", mock.Anything, int64(1)).Return(nil)
+				r.On("RevokeAllForUser", mock.Anything, int64(1)).Return(nil)
+			},
+		},
+		{
+			name:            "取得後に他の操作で更新された場合は失効させない",
+			expectedVersion: sql.NullInt32{Int32: 1, Valid: true},
+			setupMock: func(m *mockStore, r *mockRevocations) {
+				m.On("DeleteUser", mock.Anything, db.DeleteUserParams{ID: 1, ExpectedVersion: sql.NullInt32{Int32: 1, Valid: true}}).Return(int64(0), nil)
+			},
+			expectedCode: apperror.CodePreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			revocations := new(mockRevocations)
+			tt.setupMock(store, revocations)
+
+			users := NewUserService(store, revocations, nil, SystemClock{}, &config.Config{})
+			err := users.Delete(context.Background(), 1, tt.expectedVersion)
+
+			if tt.expectedCode == "" {
+				assert.NoError(t, err)
+			} else {
+				appErr, ok := apperror.As(err)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCode, appErr.Code)
+			}
+			store.AssertExpectations(t)
+			revocations.AssertExpectations(t)
+		})
+	}
+}
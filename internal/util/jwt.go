@@ -1,6 +1,7 @@
 package util
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,46 +10,130 @@ import (
 
 // JWTの設定
 const (
-	tokenExpiration = 24 * time.Hour
-	signingKey      = "your-secret-key" // 本番環境では環境変数から読み込むべき
+	// AccessTokenExpiration はアクセストークンの有効期間です
+	AccessTokenExpiration = 15 * time.Minute
+	// MFATokenExpiration は二要素認証待ちトークンの有効期間です
+	MFATokenExpiration = 5 * time.Minute
+	// EmailVerificationExpiration はメールアドレス確認トークンの有効期間です
+	EmailVerificationExpiration = 24 * time.Hour
+)
+
+// ErrKeySetNotConfigured は SetKeySet で鍵セットを設定する前にトークンを署名・検証しようとした場合のエラーです
+var ErrKeySetNotConfigured = errors.New("JWT署名鍵が設定されていません")
+
+func init() {
+	// 全デバイスからのログアウトなどで失効させた直後に発行したトークンを、同じ秒に発行されたことを理由に
+	// 失効扱いにしないよう、発行日時（iat）はマイクロ秒単位で記録する
+	jwt.TimePrecision = time.Microsecond
+}
+
+// keys はトークンの署名と検証に使用する鍵セットです。起動時に SetKeySet で設定します
+// 既知の鍵で署名してしまわないよう、設定されるまでは nil のままにし、署名と検証はエラーにします
+var keys *KeySet
+
+// SetKeySet はトークンの署名と検証に使用する鍵セットを設定します
+// サーバーがリクエストを受け付ける前に一度だけ呼び出してください
+func SetKeySet(ks *KeySet) {
+	keys = ks
+}
+
+// currentKeySet は設定済みの鍵セットを返します
+func currentKeySet() (*KeySet, error) {
+	if keys == nil {
+		return nil, ErrKeySetNotConfigured
+	}
+	return keys, nil
+}
+
+// トークンの用途
+const (
+	// PurposeMFAPending はパスワード認証後、二要素認証の完了を待っているトークンです
+	PurposeMFAPending = "mfa_pending"
+	// PurposeEmailVerification はメールアドレスの確認用に送信するトークンです
+	PurposeEmailVerification = "email_verification"
 )
 
 // Claims はJWTのペイロードを定義します
 type Claims struct {
 	UserID int64 `json:"user_id"`
+	// Roles はトークン発行時点でユーザーに付与されていたロールです
+	Roles []string `json:"roles,omitempty"`
+	// Purpose はアクセストークン以外の用途で発行されたトークンの用途です
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken はJWTトークンを生成します
-func GenerateToken(userID int64) (string, error) {
+// GenerateToken はユーザーのロールを含むJWTトークンを生成します
+func GenerateToken(userID int64, roles []string) (string, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return "", err
+	}
+
+	// 失効管理のためにトークンごとに一意なIDを付与
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
 	claims := Claims{
 		UserID: userID,
+		Roles:  roles,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenExpiration)),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(signingKey))
+	return ks.Sign(claims)
 }
 
-// ValidateToken はJWTトークンを検証します
+// ValidateToken はアクセストークンを検証します
 func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(signingKey), nil
-	})
+	return ValidatePurposeToken(tokenString, "")
+}
+
+// GeneratePurposeToken はアクセストークン以外の用途の短期間有効なトークンを生成します
+func GeneratePurposeToken(userID int64, purpose string, expiration time.Duration) (string, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return ks.Sign(claims)
+}
 
+// ValidatePurposeToken はトークンを検証し、用途が一致することを確認します
+func ValidatePurposeToken(tokenString, purpose string) (*Claims, error) {
+	ks, err := currentKeySet()
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	token, err := ks.Parse(tokenString, &Claims{})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	// 用途の異なるトークンの流用を防ぐ
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("invalid token purpose: %q", claims.Purpose)
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	return claims, nil
 }
@@ -0,0 +1,332 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig はJWTの署名鍵に関する設定を保持します
+type JWTConfig struct {
+	// Algorithm は署名アルゴリズムです（HS256, RS256, ES256, EdDSA）
+	Algorithm string
+	// KeyID はJWTヘッダーのkidに設定する鍵IDです。空の場合は鍵から導出します
+	KeyID string
+	// Secret はHS256で使用する共有シークレットです
+	Secret string
+	// PrivateKey はPEM形式の秘密鍵です。PrivateKeyFileより優先されます
+	PrivateKey string
+	// PrivateKeyFile はPEM形式の秘密鍵ファイルのパスです
+	PrivateKeyFile string
+	// VerificationKeys はローテーション中に検証のみ許可する旧鍵です（kid=ファイルパス）
+	// ファイルはPEM形式の公開鍵・秘密鍵、またはHS256の場合はシークレットそのものです
+	VerificationKeys []string
+}
+
+// jwtKey は1つの署名鍵を表します
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet はトークンの署名に使う鍵と、検証に使える鍵の集合です
+type KeySet struct {
+	signer *jwtKey
+	keys   map[string]*jwtKey
+}
+
+// NewKeySet は設定から鍵セットを作成します
+func NewKeySet(cfg JWTConfig) (*KeySet, error) {
+	signer, err := loadSigningKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{
+		signer: signer,
+		keys:   map[string]*jwtKey{signer.kid: signer},
+	}
+
+	for _, entry := range cfg.VerificationKeys {
+		kid, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("検証鍵の指定が不正です（kid=path の形式で指定してください）: %q", entry)
+		}
+		if _, exists := ks.keys[kid]; exists {
+			return nil, fmt.Errorf("鍵ID %q が重複しています", kid)
+		}
+
+		key, err := loadVerificationKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[kid] = key
+	}
+
+	return ks, nil
+}
+
+// Sign はクレームに署名し、kidヘッダー付きのトークンを返します
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signer.method, claims)
+	token.Header["kid"] = ks.signer.kid
+	return token.SignedString(ks.signer.signKey)
+}
+
+// Parse はkidヘッダーに対応する鍵でトークンを検証し、クレームを読み込みます
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		key := ks.signer
+		// kidのないトークンは現在の署名鍵で検証する
+		if kid, ok := token.Header["kid"].(string); ok {
+			if key, ok = ks.keys[kid]; !ok {
+				return nil, fmt.Errorf("unknown key id: %s", kid)
+			}
+		}
+
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.verifyKey, nil
+	})
+}
+
+// JWK はJSON Web Key（RFC 7517）の公開鍵表現です
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS はJSON Web Key Setです
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS は検証に使える公開鍵の一覧を返します。共有シークレット（HS256）は含みません
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		jwk, ok := toJWK(key)
+		if ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
+
+func toJWK(key *jwtKey) (JWK, bool) {
+	jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+	enc := base64.RawURLEncoding
+
+	switch pub := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(pub.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, false
+		}
+		// 非圧縮形式: 0x04 || X || Y
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = enc.EncodeToString(point[:size])
+		jwk.Y = enc.EncodeToString(point[size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(pub)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
+
+// loadSigningKey は署名に使用する鍵を読み込みます
+func loadSigningKey(cfg JWTConfig) (*jwtKey, error) {
+	algorithm := strings.ToUpper(cfg.Algorithm)
+	if algorithm == "" {
+		algorithm = "HS256"
+	}
+
+	if algorithm == "HS256" {
+		if cfg.Secret == "" {
+			return nil, errors.New("HS256にはJWTシークレットの設定が必要です")
+		}
+		kid := cfg.KeyID
+		if kid == "" {
+			kid = "default"
+		}
+		return &jwtKey{kid: kid, method: jwt.SigningMethodHS256, signKey: []byte(cfg.Secret), verifyKey: []byte(cfg.Secret)}, nil
+	}
+
+	pemData := []byte(cfg.PrivateKey)
+	if len(pemData) == 0 {
+		if cfg.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("%sには秘密鍵の設定が必要です", algorithm)
+		}
+		data, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("秘密鍵ファイルの読み込みに失敗しました: %w", err)
+		}
+		pemData = data
+	}
+
+	privateKey, err := parsePrivateKeyPEM(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("署名に使用できない秘密鍵です")
+	}
+
+	method, err := signingMethodFor(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToUpper(method.Alg()) != algorithm {
+		return nil, fmt.Errorf("秘密鍵の種類がアルゴリズム %s と一致しません（%s）", algorithm, method.Alg())
+	}
+
+	kid := cfg.KeyID
+	if kid == "" {
+		kid, err = keyThumbprint(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &jwtKey{kid: kid, method: method, signKey: privateKey, verifyKey: signer.Public()}, nil
+}
+
+// loadVerificationKey は検証専用の鍵をファイルから読み込みます
+func loadVerificationKey(kid, path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("検証鍵ファイルの読み込みに失敗しました: %w", err)
+	}
+
+	// PEMでなければHS256のシークレットとして扱う
+	if block, _ := pem.Decode(data); block == nil {
+		secret := []byte(strings.TrimSpace(string(data)))
+		return &jwtKey{kid: kid, method: jwt.SigningMethodHS256, verifyKey: secret}, nil
+	}
+
+	publicKey, err := parsePublicKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("検証鍵 %q: %w", kid, err)
+	}
+
+	method, err := signingMethodFor(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &jwtKey{kid: kid, method: method, verifyKey: publicKey}, nil
+}
+
+// parsePrivateKeyPEM はPKCS#8、PKCS#1、SEC 1形式の秘密鍵を読み込みます
+func parsePrivateKeyPEM(data []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM形式の秘密鍵ではありません")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("未対応のPEMブロックです: %s", block.Type)
+	}
+}
+
+// parsePublicKeyPEM は公開鍵、または秘密鍵から公開鍵を読み込みます
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM形式の鍵ではありません")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	privateKey, err := parsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("公開鍵を取得できない秘密鍵です")
+	}
+	return signer.Public(), nil
+}
+
+// signingMethodFor は公開鍵の種類に対応する署名方式を返します
+func signingMethodFor(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf("未対応の楕円曲線です: %s", pub.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("未対応の鍵の種類です: %T", publicKey)
+	}
+}
+
+// keyThumbprint は公開鍵から鍵IDを導出します
+func keyThumbprint(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
@@ -0,0 +1,173 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPEM はテスト用の秘密鍵をPKCS#8形式でファイルに書き出します
+func writeKeyPEM(t *testing.T, key crypto.PrivateKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	require.NoError(t, err)
+	return path
+}
+
+func testClaims() Claims {
+	return Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+}
+
+func TestKeySet_SignAndParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// テストケースの定義
+	tests := []struct {
+		name    string
+		cfg     JWTConfig
+		wantAlg string
+		wantKty string
+	}{
+		{
+			name:    "HS256",
+			cfg:     JWTConfig{Algorithm: "HS256", Secret: "secret"},
+			wantAlg: "HS256",
+		},
+		{
+			name:    "RS256",
+			cfg:     JWTConfig{Algorithm: "RS256", PrivateKeyFile: writeKeyPEM(t, rsaKey)},
+			wantAlg: "RS256",
+			wantKty: "RSA",
+		},
+		{
+			name:    "ES256",
+			cfg:     JWTConfig{Algorithm: "ES256", PrivateKeyFile: writeKeyPEM(t, ecKey)},
+			wantAlg: "ES256",
+			wantKty: "EC",
+		},
+		{
+			name:    "EdDSA",
+			cfg:     JWTConfig{Algorithm: "EdDSA", KeyID: "ed-1", PrivateKeyFile: writeKeyPEM(t, edKey)},
+			wantAlg: "EdDSA",
+			wantKty: "OKP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := NewKeySet(tt.cfg)
+			require.NoError(t, err)
+
+			// 署名と検証
+			tokenString, err := ks.Sign(testClaims())
+			require.NoError(t, err)
+
+			token, err := ks.Parse(tokenString, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, token.Method.Alg())
+			assert.Equal(t, ks.signer.kid, token.Header["kid"])
+
+			// JWKSには非対称鍵の公開鍵のみを含める
+			jwks := ks.JWKS()
+			if tt.wantKty == "" {
+				assert.Empty(t, jwks.Keys)
+				return
+			}
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.wantKty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.wantAlg, jwks.Keys[0].Alg)
+			assert.Equal(t, ks.signer.kid, jwks.Keys[0].Kid)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	oldKeyPath := writeKeyPEM(t, oldKey)
+
+	// 旧鍵で発行されたトークン
+	oldKeys, err := NewKeySet(JWTConfig{Algorithm: "RS256", KeyID: "old", PrivateKeyFile: oldKeyPath})
+	require.NoError(t, err)
+	oldToken, err := oldKeys.Sign(testClaims())
+	require.NoError(t, err)
+
+	// 新しい鍵に切り替え、旧鍵は検証用として残す
+	rotated, err := NewKeySet(JWTConfig{
+		Algorithm:        "EdDSA",
+		KeyID:            "new",
+		PrivateKeyFile:   writeKeyPEM(t, newKey),
+		VerificationKeys: []string{"old=" + oldKeyPath},
+	})
+	require.NoError(t, err)
+
+	_, err = rotated.Parse(oldToken, &Claims{})
+	assert.NoError(t, err)
+
+	newToken, err := rotated.Sign(testClaims())
+	require.NoError(t, err)
+	_, err = rotated.Parse(newToken, &Claims{})
+	assert.NoError(t, err)
+
+	jwks := rotated.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "new", jwks.Keys[0].Kid)
+	assert.Equal(t, "old", jwks.Keys[1].Kid)
+
+	// 旧鍵の登録を外すと検証できなくなる
+	withoutOld, err := NewKeySet(JWTConfig{Algorithm: "EdDSA", KeyID: "new", PrivateKeyFile: writeKeyPEM(t, newKey)})
+	require.NoError(t, err)
+	_, err = withoutOld.Parse(oldToken, &Claims{})
+	assert.ErrorContains(t, err, "unknown key id")
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ks, err := NewKeySet(JWTConfig{Algorithm: "RS256", KeyID: "rsa", PrivateKeyFile: writeKeyPEM(t, rsaKey)})
+	require.NoError(t, err)
+
+	// 同じkidでHS256に差し替えたトークンは拒否する
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	token.Header["kid"] = "rsa"
+	forged, err := token.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = ks.Parse(forged, &Claims{})
+	assert.ErrorContains(t, err, "unexpected signing method")
+
+	// アルゴリズムと鍵の種類が一致しない設定はエラー
+	_, err = NewKeySet(JWTConfig{Algorithm: "ES256", PrivateKeyFile: writeKeyPEM(t, rsaKey)})
+	assert.Error(t, err)
+}
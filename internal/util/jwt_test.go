@@ -1,17 +1,34 @@
 package util
 
 import (
+	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// TestMain はテストで発行・検証するトークンの署名鍵を設定してからテストを実行します
+func TestMain(m *testing.M) {
+	SetKeySet(testKeySet())
+	os.Exit(m.Run())
+}
+
+// testKeySet はテスト用のHS256の鍵セットを返します
+func testKeySet() *KeySet {
+	ks, err := NewKeySet(JWTConfig{Algorithm: "HS256", Secret: "test-secret"})
+	if err != nil {
+		panic(err)
+	}
+	return ks
+}
+
 func TestGenerateAndValidateToken(t *testing.T) {
 	// テストケースの定義
 	tests := []struct {
 		name    string
 		userID  int64
+		roles   []string
 		wantErr bool
 	}{
 		{
@@ -19,6 +36,12 @@ func TestGenerateAndValidateToken(t *testing.T) {
 			userID:  1,
 			wantErr: false,
 		},
+		{
+			name:    "ロールを含むトークンの生成と検証",
+			userID:  1,
+			roles:   []string{RoleAdmin, RoleUser},
+			wantErr: false,
+		},
 		{
 			name:    "ユーザーID 0 でのトークン生成と検証",
 			userID:  0,
@@ -34,7 +57,7 @@ func TestGenerateAndValidateToken(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// トークンの生成
-			token, err := GenerateToken(tt.userID)
+			token, err := GenerateToken(tt.userID, tt.roles)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -47,6 +70,7 @@ func TestGenerateAndValidateToken(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotNil(t, claims)
 			assert.Equal(t, tt.userID, claims.UserID)
+			assert.Equal(t, tt.roles, claims.Roles)
 
 			// 有効期限の検証
 			assert.True(t, claims.ExpiresAt.After(time.Now()))
@@ -87,3 +111,39 @@ func TestValidateToken_Invalid(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePurposeToken(t *testing.T) {
+	mfaToken, err := GeneratePurposeToken(1, PurposeMFAPending, MFATokenExpiration)
+	assert.NoError(t, err)
+
+	// 用途が一致すれば検証できる
+	claims, err := ValidatePurposeToken(mfaToken, PurposeMFAPending)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+
+	// 二要素認証待ちのトークンはアクセストークンとして使用できない
+	_, err = ValidateToken(mfaToken)
+	assert.Error(t, err)
+
+	// アクセストークンは二要素認証待ちのトークンとして使用できない
+	accessToken, err := GenerateToken(1, nil)
+	assert.NoError(t, err)
+	_, err = ValidatePurposeToken(accessToken, PurposeMFAPending)
+	assert.Error(t, err)
+}
+
+func TestKeySetNotConfigured(t *testing.T) {
+	// 署名鍵を設定する前は、既知の鍵で署名・検証せずにエラーを返す
+	token, err := GenerateToken(1, nil)
+	assert.NoError(t, err)
+
+	SetKeySet(nil)
+	t.Cleanup(func() { SetKeySet(testKeySet()) })
+
+	_, err = GenerateToken(1, nil)
+	assert.ErrorIs(t, err, ErrKeySetNotConfigured)
+	_, err = GeneratePurposeToken(1, PurposeEmailVerification, EmailVerificationExpiration)
+	assert.ErrorIs(t, err, ErrKeySetNotConfigured)
+	_, err = ValidateToken(token)
+	assert.ErrorIs(t, err, ErrKeySetNotConfigured)
+}
@@ -0,0 +1,177 @@
+package util
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+)
+
+// LoginGuardConfig はログイン失敗時の遅延とロックの設定を保持します
+type LoginGuardConfig struct {
+	// MaxAccountFailures はアカウントをロックするまでの連続失敗回数です
+	MaxAccountFailures int
+	// MaxIPFailures は送信元IPをロックするまでの連続失敗回数です
+	MaxIPFailures int
+	// LockDuration はロックの継続時間です
+	LockDuration time.Duration
+	// BaseDelay は1回目の失敗後に必要な待機時間です。以降は失敗のたびに倍になります
+	BaseDelay time.Duration
+	// MaxDelay は待機時間の上限です
+	MaxDelay time.Duration
+	// FailureWindow は失敗回数を数える期間です。最後の失敗からこの期間が過ぎると回数をリセットします
+	FailureWindow time.Duration
+}
+
+// LoginGuard はアカウントと送信元IPごとのログイン失敗を記録し、試行を制限するインターフェースです
+type LoginGuard interface {
+	// Check は次のログイン試行までに必要な待機時間を返します。ロック中の場合は locked が true になります
+	Check(ctx context.Context, email, ip string) (wait time.Duration, locked bool, err error)
+	// RecordFailure はログイン失敗を記録し、今回の失敗でアカウントがロックされた場合はロックの解除時刻を返します
+	RecordFailure(ctx context.Context, email, ip string) (lockedUntil time.Time, err error)
+	// Reset はアカウントのログイン失敗の記録とロックを解除します
+	Reset(ctx context.Context, email string) error
+}
+
+// DBLoginGuard はMySQLにログイン失敗を記録する LoginGuard の実装です
+type DBLoginGuard struct {
+	queries db.Querier
+	config  LoginGuardConfig
+}
+
+// NewDBLoginGuard は新しいDBLoginGuardを作成します
+func NewDBLoginGuard(queries db.Querier, config LoginGuardConfig) *DBLoginGuard {
+	return &DBLoginGuard{
+		queries: queries,
+		config:  config,
+	}
+}
+
+// Check は次のログイン試行までに必要な待機時間を返します
+func (g *DBLoginGuard) Check(ctx context.Context, email, ip string) (time.Duration, bool, error) {
+	now := time.Now()
+
+	var wait time.Duration
+	var locked bool
+	for _, target := range g.targets(email, ip) {
+		failure, err := g.queries.GetLoginFailure(ctx, db.GetLoginFailureParams{
+			Scope:   target.scope,
+			Subject: target.subject,
+		})
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return 0, false, err
+		}
+
+		w, l := g.retryAfter(failure, now)
+		if w > wait {
+			wait = w
+		}
+		locked = locked || l
+	}
+
+	return wait, locked, nil
+}
+
+// RecordFailure はアカウントと送信元IPのログイン失敗を記録し、閾値に達した場合はロックします
+func (g *DBLoginGuard) RecordFailure(ctx context.Context, email, ip string) (time.Time, error) {
+	now := time.Now()
+
+	var accountLockedUntil time.Time
+	for _, target := range g.targets(email, ip) {
+		err := g.queries.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+			Scope:       target.scope,
+			Subject:     target.subject,
+			FailedAt:    now,
+			ResetBefore: now.Add(-g.config.FailureWindow),
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		failure, err := g.queries.GetLoginFailure(ctx, db.GetLoginFailureParams{
+			Scope:   target.scope,
+			Subject: target.subject,
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+		if target.max <= 0 || int(failure.FailedCount) < target.max {
+			continue
+		}
+
+		lockedUntil := now.Add(g.config.LockDuration)
+		err = g.queries.LockLoginSubject(ctx, db.LockLoginSubjectParams{
+			LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+			Scope:       target.scope,
+			Subject:     target.subject,
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+		if target.scope == db.LoginFailuresScopeAccount {
+			accountLockedUntil = lockedUntil
+		}
+	}
+
+	return accountLockedUntil, nil
+}
+
+// Reset はアカウントのログイン失敗の記録とロックを解除します
+func (g *DBLoginGuard) Reset(ctx context.Context, email string) error {
+	return g.queries.DeleteLoginFailure(ctx, db.DeleteLoginFailureParams{
+		Scope:   db.LoginFailuresScopeAccount,
+		Subject: normalizeLoginSubject(email),
+	})
+}
+
+type loginTarget struct {
+	scope   db.LoginFailuresScope
+	subject string
+	max     int
+}
+
+// targets はログイン失敗を記録する対象の一覧を返します
+func (g *DBLoginGuard) targets(email, ip string) []loginTarget {
+	targets := []loginTarget{
+		{scope: db.LoginFailuresScopeAccount, subject: normalizeLoginSubject(email), max: g.config.MaxAccountFailures},
+	}
+	if ip != "" {
+		targets = append(targets, loginTarget{scope: db.LoginFailuresScopeIp, subject: ip, max: g.config.MaxIPFailures})
+	}
+	return targets
+}
+
+// retryAfter は失敗の記録から次の試行までに必要な待機時間を計算します
+func (g *DBLoginGuard) retryAfter(failure db.LoginFailure, now time.Time) (time.Duration, bool) {
+	if failure.LockedUntil.Valid && failure.LockedUntil.Time.After(now) {
+		return failure.LockedUntil.Time.Sub(now), true
+	}
+
+	// 期間内に失敗がない場合は回数がリセットされるため待機は不要
+	if failure.FailedCount <= 0 || !failure.LastFailedAt.After(now.Add(-g.config.FailureWindow)) {
+		return 0, false
+	}
+
+	delay := g.config.BaseDelay
+	for i := int32(1); i < failure.FailedCount && delay < g.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.config.MaxDelay {
+		delay = g.config.MaxDelay
+	}
+
+	if wait := failure.LastFailedAt.Add(delay).Sub(now); wait > 0 {
+		return wait, false
+	}
+	return 0, false
+}
+
+// normalizeLoginSubject はメールアドレスの表記揺れで制限を回避されないよう正規化します
+func normalizeLoginSubject(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
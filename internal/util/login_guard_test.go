@@ -0,0 +1,83 @@
+package util
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBLoginGuard_RetryAfter(t *testing.T) {
+	now := time.Now()
+
+	guard := NewDBLoginGuard(nil, LoginGuardConfig{
+		LockDuration:  15 * time.Minute,
+		BaseDelay:     time.Second,
+		MaxDelay:      time.Minute,
+		FailureWindow: time.Hour,
+	})
+
+	// テストケースの定義
+	tests := []struct {
+		name       string
+		failure    db.LoginFailure
+		wantWait   time.Duration
+		wantLocked bool
+	}{
+		{
+			name:     "1回目の失敗直後",
+			failure:  db.LoginFailure{FailedCount: 1, LastFailedAt: now},
+			wantWait: time.Second,
+		},
+		{
+			name:     "4回目の失敗直後は待機時間が倍増する",
+			failure:  db.LoginFailure{FailedCount: 4, LastFailedAt: now},
+			wantWait: 8 * time.Second,
+		},
+		{
+			name:     "待機時間は上限を超えない",
+			failure:  db.LoginFailure{FailedCount: 30, LastFailedAt: now},
+			wantWait: time.Minute,
+		},
+		{
+			name:     "待機時間が経過済み",
+			failure:  db.LoginFailure{FailedCount: 4, LastFailedAt: now.Add(-10 * time.Second)},
+			wantWait: 0,
+		},
+		{
+			name:     "集計期間を過ぎた失敗",
+			failure:  db.LoginFailure{FailedCount: 30, LastFailedAt: now.Add(-2 * time.Hour)},
+			wantWait: 0,
+		},
+		{
+			name: "ロック中",
+			failure: db.LoginFailure{
+				FailedCount:  5,
+				LastFailedAt: now,
+				LockedUntil:  sql.NullTime{Time: now.Add(15 * time.Minute), Valid: true},
+			},
+			wantWait:   15 * time.Minute,
+			wantLocked: true,
+		},
+		{
+			name: "ロック期限切れ",
+			failure: db.LoginFailure{
+				FailedCount:  5,
+				LastFailedAt: now.Add(-20 * time.Minute),
+				LockedUntil:  sql.NullTime{Time: now.Add(-5 * time.Minute), Valid: true},
+			},
+			wantWait: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, locked := guard.retryAfter(tt.failure, now)
+			assert.Equal(t, tt.wantWait, wait)
+			assert.Equal(t, tt.wantLocked, locked)
+		})
+	}
+}
@@ -1,21 +1,92 @@
 package util
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-gin-sqlc/internal/i18n"
+)
+
+// メールの送信方法
+const (
+	// MailTransportSMTP はSMTPサーバーに送信します
+	MailTransportSMTP = "smtp"
+	// MailTransportFile はMaildir形式のディレクトリに書き出します。CIなど実際に送信しない環境で使用します
+	MailTransportFile = "file"
+	// MailTransportDevInbox はメモリ上に保持し、/dev/mail で参照できるようにします。ローカルでの開発用です
+	MailTransportDevInbox = "devinbox"
+)
+
+// SMTPの暗号化方式
+const (
+	// MailTLSStartTLS は平文で接続した後に STARTTLS で暗号化します。サーバーが対応していない場合は送信しません
+	MailTLSStartTLS = "starttls"
+	// MailTLSImplicit は接続時からTLSで暗号化します（SMTPS、通常はポート465）
+	MailTLSImplicit = "tls"
+	// MailTLSNone は暗号化しません。ローカルのSMTPサーバー以外では認証情報を送信できません
+	MailTLSNone = "none"
 )
 
 type MailConfig struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
-	From     string
+	// Transport はメールの送信方法です（smtp, file, devinbox）
+	Transport string
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	// TLS はSMTPの暗号化方式です（starttls, tls, none）
+	TLS string
+	// DialTimeout はSMTPサーバーへの接続のタイムアウトです
+	DialTimeout time.Duration
+	// Timeout は接続してから1通のメールを送信し終えるまでのタイムアウトです
+	Timeout time.Duration
+	// FileDir は送信方法が file の場合にメールを書き出す Maildir のディレクトリです
+	FileDir string
+	// TemplateDir は埋め込みのメールテンプレートを上書きするテンプレートのディレクトリです（省略可）
+	TemplateDir string
 }
 
 // Mailer はメール送信のインターフェースです
 type Mailer interface {
+	// SendMail はテキストのメールを送信します
 	SendMail(to, subject, body string) error
+	// SendTemplate は指定した名前と言語のテンプレートからメールを生成して送信します
+	SendTemplate(to, name string, locale i18n.Locale, data interface{}) error
+}
+
+// NewMailer は設定の送信方法に応じた Mailer を作成します
+// 送信方法が devinbox の場合は *DevInbox を返します
+func NewMailer(config MailConfig) (Mailer, error) {
+	switch config.Transport {
+	case MailTransportSMTP, "":
+		switch config.TLS {
+		case MailTLSStartTLS, MailTLSImplicit, MailTLSNone:
+		default:
+			return nil, fmt.Errorf("対応していないSMTPの暗号化方式です: %q", config.TLS)
+		}
+		return NewSMTPMailer(config), nil
+	case MailTransportFile:
+		return NewFileMailer(config)
+	case MailTransportDevInbox:
+		return NewDevInbox(config), nil
+	default:
+		return nil, fmt.Errorf("対応していないメールの送信方法です: %q", config.Transport)
+	}
 }
 
 // SMTPMailer はSMTPを使用したメール送信の実装です
@@ -32,26 +103,192 @@ func NewSMTPMailer(config MailConfig) *SMTPMailer {
 
 // SendMail はメールを送信します
 func (m *SMTPMailer) SendMail(to, subject, body string) error {
-	auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
-	addr := fmt.Sprintf("%s:%d", m.config.Host, m.config.Port)
+	return m.send(to, &MailContent{Subject: subject, Text: body})
+}
+
+// SendTemplate はテンプレートから生成したメールを送信します
+// テンプレートにHTML版がある場合は multipart/alternative で送信します
+func (m *SMTPMailer) SendTemplate(to, name string, locale i18n.Locale, data interface{}) error {
+	content, err := mailTemplates.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	return m.send(to, content)
+}
+
+func (m *SMTPMailer) send(to string, content *MailContent) error {
+	msg, err := BuildMailMessage(m.config.From, to, content, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.config.Username != "" {
+		// 認証情報が設定されている場合は、認証せずに送信を続けない
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("SMTPサーバーが AUTH に対応していないため、設定された認証情報で認証できません")
+		}
+		// PlainAuth は暗号化されていない接続ではローカルのサーバー以外に認証情報を送信しない
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.config.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial はSMTPサーバーに接続し、設定された暗号化方式で通信を暗号化します
+func (m *SMTPMailer) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: m.config.DialTimeout}
+	tlsConfig := &tls.Config{ServerName: m.config.Host}
+
+	var conn net.Conn
+	var err error
+	if m.config.TLS == MailTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 応答のないサーバーで処理が止まらないよう、送信全体に期限を設ける
+	if m.config.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(m.config.Timeout)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.config.TLS == MailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTPサーバーが STARTTLS に対応していません")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// BuildMailMessage は送信するメールのMIMEメッセージを生成します
+// 件名は RFC 2047 でエンコードし、本文は quoted-printable でエンコードします
+// HTML版の本文がある場合はテキスト版との multipart/alternative にします
+func BuildMailMessage(from, to string, content *MailContent, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", from)
+	header.Set("To", to)
+	header.Set("Subject", mime.BEncoding.Encode("UTF-8", content.Subject))
+	header.Set("Date", date.Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(from))
+	header.Set("MIME-Version", "1.0")
 
-	msg := []byte(fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"Content-Type: text/plain; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s", m.config.From, to, subject, body))
+	if content.HTML == "" {
+		header.Set("Content-Type", "text/plain; charset=UTF-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeMailHeader(&buf, header)
+		if err := writeQuotedPrintable(&buf, content.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	// 受信側は後の部分ほど優先して表示するため、HTML版を後にする（RFC 2046 5.1.4）
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", content.Text},
+		{"text/html; charset=UTF-8", content.HTML},
+	}
+	for _, part := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
 
-	return smtp.SendMail(addr, auth, m.config.From, []string{to}, msg)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()}))
+	writeMailHeader(&buf, header)
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
 }
 
-// GeneratePasswordResetEmail はパスワードリセットメールの本文を生成します
-func GeneratePasswordResetEmail(resetURL string) string {
-	return fmt.Sprintf(`パスワードリセットのリクエストを受け付けました。
+// mailHeaderOrder はヘッダーを書き込む順序です
+var mailHeaderOrder = []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "Content-Transfer-Encoding"}
+
+func writeMailHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, key := range mailHeaderOrder {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
 
-以下のURLをクリックしてパスワードをリセットしてください：
-%s
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, text string) error {
+	qw := quotedprintable.NewWriter(w)
+	// SMTPの改行はCRLFに統一する
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qw.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
 
-このリンクは24時間有効です。
-心当たりがない場合は、このメールを無視してください。`, resetURL)
+// messageID は送信元のドメインを使った一意な Message-ID を生成します
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndex(addr.Address, "@"); i >= 0 {
+			domain = addr.Address[i+1:]
+		}
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("<%d@%s>", time.Now().UnixNano(), domain)
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
 }
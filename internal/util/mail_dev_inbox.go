@@ -0,0 +1,114 @@
+package util
+
+import (
+	"sync"
+	"time"
+
+	"go-gin-sqlc/internal/i18n"
+)
+
+// devInboxCapacity は DevInbox が保持するメールの最大件数です
+// 上限を超えた場合は古いメールから削除します
+const devInboxCapacity = 100
+
+// DevMail は DevInbox が受け取ったメールです
+type DevMail struct {
+	ID      int64
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	SentAt  time.Time
+	// Raw は送信される MIME メッセージです
+	Raw []byte
+}
+
+// DevInbox はメールを送信せず、メモリ上に保持する Mailer の実装です
+// ローカルでの開発時に /dev/mail から受け取ったメールを確認するために使用します
+type DevInbox struct {
+	config MailConfig
+
+	mu     sync.Mutex
+	nextID int64
+	mails  []DevMail
+}
+
+// NewDevInbox は新しいDevInboxを作成します
+func NewDevInbox(config MailConfig) *DevInbox {
+	return &DevInbox{
+		config: config,
+		nextID: 1,
+	}
+}
+
+// SendMail はメールを受信箱に追加します
+func (m *DevInbox) SendMail(to, subject, body string) error {
+	return m.send(to, &MailContent{Subject: subject, Text: body})
+}
+
+// SendTemplate はテンプレートから生成したメールを受信箱に追加します
+func (m *DevInbox) SendTemplate(to, name string, locale i18n.Locale, data interface{}) error {
+	content, err := mailTemplates.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	return m.send(to, content)
+}
+
+func (m *DevInbox) send(to string, content *MailContent) error {
+	now := time.Now()
+	// 実際に送信されるメッセージを確認できるよう、SMTPと同じ MIME メッセージも保持する
+	raw, err := BuildMailMessage(m.config.From, to, content, now)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mails = append(m.mails, DevMail{
+		ID:      m.nextID,
+		From:    m.config.From,
+		To:      to,
+		Subject: content.Subject,
+		Text:    content.Text,
+		HTML:    content.HTML,
+		SentAt:  now,
+		Raw:     raw,
+	})
+	m.nextID++
+	if len(m.mails) > devInboxCapacity {
+		m.mails = append([]DevMail(nil), m.mails[len(m.mails)-devInboxCapacity:]...)
+	}
+	return nil
+}
+
+// Mails は受け取ったメールを新しい順に返します
+func (m *DevInbox) Mails() []DevMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mails := make([]DevMail, len(m.mails))
+	for i, mail := range m.mails {
+		mails[len(m.mails)-1-i] = mail
+	}
+	return mails
+}
+
+// Mail は指定したIDのメールを返します
+func (m *DevInbox) Mail(id int64) (DevMail, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mail := range m.mails {
+		if mail.ID == id {
+			return mail, true
+		}
+	}
+	return DevMail{}, false
+}
+
+// Clear は受け取ったメールをすべて削除します
+func (m *DevInbox) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mails = nil
+}
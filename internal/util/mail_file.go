@@ -0,0 +1,85 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-gin-sqlc/internal/i18n"
+)
+
+// FileMailer はメールを送信せず、Maildir 形式のディレクトリに書き出す Mailer の実装です
+// CIなどでメールの内容を確認するために使用します
+type FileMailer struct {
+	config MailConfig
+}
+
+// NewFileMailer は新しいFileMailerを作成します
+// 書き出し先のディレクトリに Maildir の tmp, new, cur ディレクトリがなければ作成します
+func NewFileMailer(config MailConfig) (*FileMailer, error) {
+	if config.FileDir == "" {
+		return nil, fmt.Errorf("メールの書き出し先のディレクトリが設定されていません")
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(config.FileDir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &FileMailer{
+		config: config,
+	}, nil
+}
+
+// SendMail はメールをファイルに書き出します
+func (m *FileMailer) SendMail(to, subject, body string) error {
+	return m.send(to, &MailContent{Subject: subject, Text: body})
+}
+
+// SendTemplate はテンプレートから生成したメールをファイルに書き出します
+func (m *FileMailer) SendTemplate(to, name string, locale i18n.Locale, data interface{}) error {
+	content, err := mailTemplates.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	return m.send(to, content)
+}
+
+// send はメールを tmp に書き込んでから new に移動します
+// 読み取る側が書き込み途中のファイルを読むことはありません
+func (m *FileMailer) send(to string, content *MailContent) error {
+	now := time.Now()
+	msg, err := BuildMailMessage(m.config.From, to, content, now)
+	if err != nil {
+		return err
+	}
+
+	name, err := maildirName(now)
+	if err != nil {
+		return err
+	}
+	tmpPath := filepath.Join(m.config.FileDir, "tmp", name)
+	if err := os.WriteFile(tmpPath, msg, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(m.config.FileDir, "new", name)); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// maildirName は Maildir の一意なファイル名を生成します
+func maildirName(now time.Time) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	return fmt.Sprintf("%d.%d_%s.%s", now.Unix(), now.Nanosecond(), hex.EncodeToString(b), hostname), nil
+}
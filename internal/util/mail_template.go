@@ -0,0 +1,227 @@
+package util
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go-gin-sqlc/internal/i18n"
+)
+
+// メールテンプレートの名前
+const (
+	MailTemplatePasswordReset     = "password_reset"
+	MailTemplateEmailVerification = "email_verification"
+	MailTemplateAccountLocked     = "account_locked"
+)
+
+// requiredMailTemplates は既定の言語で必ず用意する必要があるテンプレートです
+var requiredMailTemplates = []string{
+	MailTemplatePasswordReset,
+	MailTemplateEmailVerification,
+	MailTemplateAccountLocked,
+}
+
+// PasswordResetMail はパスワードリセットメールのテンプレートに渡すデータです
+type PasswordResetMail struct {
+	ResetURL string `mail:"secret"`
+	// ExpiresAt はリセット用のリンクの有効期限です
+	ExpiresAt time.Time
+}
+
+// EmailVerificationMail はメールアドレス確認メールのテンプレートに渡すデータです
+type EmailVerificationMail struct {
+	VerifyURL string `mail:"secret"`
+}
+
+// AccountLockedMail はアカウントロック通知メールのテンプレートに渡すデータです
+type AccountLockedMail struct {
+	LockedUntil time.Time
+}
+
+// mailDataTypes はテンプレートごとのデータの型です
+// 送信待ちのメールに保存したJSONのデータは、この型に戻してからテンプレートに渡します
+// mail:"secret" を指定したフィールドは、配信済みや配信を停止したメールのデータから取り除きます
+var mailDataTypes = map[string]reflect.Type{
+	MailTemplatePasswordReset:     reflect.TypeOf(PasswordResetMail{}),
+	MailTemplateEmailVerification: reflect.TypeOf(EmailVerificationMail{}),
+	MailTemplateAccountLocked:     reflect.TypeOf(AccountLockedMail{}),
+}
+
+// ErrMailTemplateNotFound は指定した名前のテンプレートがない場合のエラーです
+var ErrMailTemplateNotFound = errors.New("mail template not found")
+
+// embeddedMailTemplates はバイナリに埋め込んだ既定のテンプレートです
+//
+//go:embed mailtemplates
+var embeddedMailTemplates embed.FS
+
+// mailTemplates はメールの生成に使用するテンプレートです。起動時に SetMailTemplates で設定します
+var mailTemplates = mustLoadMailTemplates()
+
+// SetMailTemplates はメールの生成に使用するテンプレートを設定します
+// サーバーがリクエストを受け付ける前に一度だけ呼び出してください
+func SetMailTemplates(t *MailTemplates) {
+	mailTemplates = t
+}
+
+// MailContent はテンプレートから生成したメールの件名と本文です
+type MailContent struct {
+	Subject string
+	Text    string
+	// HTML はHTML版の本文です。テンプレートにHTML版がない場合は空になります
+	HTML string
+}
+
+// MailTemplates は名前と言語ごとのメールテンプレートを保持します
+//
+// テンプレートは <言語>/<名前>.txt と <言語>/<名前>.html のファイルで定義します
+// .txt は text/template で、件名を {{define "subject"}} で定義します
+// .html は html/template で、HTML版の本文を定義します。HTML版は省略できます
+type MailTemplates struct {
+	templates map[string]map[i18n.Locale]*mailTemplate
+}
+
+type mailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// LoadMailTemplates は埋め込みのテンプレートを読み込み、dir を指定した場合はそのディレクトリのテンプレートで上書きします
+// dir には埋め込みのテンプレートと同じ構成でファイルを配置し、変更したいファイルだけを置くこともできます
+func LoadMailTemplates(dir string) (*MailTemplates, error) {
+	if dir == "" {
+		return loadMailTemplates()
+	}
+	return loadMailTemplates(os.DirFS(dir))
+}
+
+func mustLoadMailTemplates() *MailTemplates {
+	t, err := loadMailTemplates()
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// loadMailTemplates は埋め込みのテンプレートに overrides のテンプレートを順に重ねて読み込みます
+func loadMailTemplates(overrides ...fs.FS) (*MailTemplates, error) {
+	embedded, err := fs.Sub(embeddedMailTemplates, "mailtemplates")
+	if err != nil {
+		return nil, err
+	}
+
+	// ファイルごとに後から読み込んだものを優先する
+	sources := make(map[string][]byte)
+	for _, fsys := range append([]fs.FS{embedded}, overrides...) {
+		for _, pattern := range []string{"*/*.txt", "*/*.html"} {
+			files, err := fs.Glob(fsys, pattern)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				content, err := fs.ReadFile(fsys, file)
+				if err != nil {
+					return nil, err
+				}
+				sources[file] = content
+			}
+		}
+	}
+
+	t := &MailTemplates{templates: make(map[string]map[i18n.Locale]*mailTemplate)}
+	for file, content := range sources {
+		if err := t.parse(file, content); err != nil {
+			return nil, fmt.Errorf("メールテンプレート %s の読み込みに失敗しました: %w", file, err)
+		}
+	}
+
+	for name, locales := range t.templates {
+		for locale, tmpl := range locales {
+			if tmpl.text == nil {
+				return nil, fmt.Errorf("メールテンプレート %s/%s.txt がありません", locale, name)
+			}
+		}
+	}
+	for _, name := range requiredMailTemplates {
+		if _, ok := t.templates[name][i18n.Default]; !ok {
+			return nil, fmt.Errorf("メールテンプレート %s/%s.txt がありません", i18n.Default, name)
+		}
+	}
+	return t, nil
+}
+
+// parse は <言語>/<名前>.<拡張子> のファイルを解析して登録します
+func (t *MailTemplates) parse(file string, content []byte) error {
+	dir, base := path.Split(file)
+	locale, ok := i18n.Parse(strings.TrimSuffix(dir, "/"))
+	if !ok {
+		return fmt.Errorf("対応していない言語です: %q", dir)
+	}
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	if t.templates[name] == nil {
+		t.templates[name] = make(map[i18n.Locale]*mailTemplate)
+	}
+	tmpl := t.templates[name][locale]
+	if tmpl == nil {
+		tmpl = &mailTemplate{}
+		t.templates[name][locale] = tmpl
+	}
+
+	var err error
+	switch ext {
+	case ".txt":
+		tmpl.text, err = texttemplate.New(name).Option("missingkey=error").Parse(string(content))
+		if err == nil && tmpl.text.Lookup("subject") == nil {
+			err = errors.New(`件名（{{define "subject"}}）が定義されていません`)
+		}
+	case ".html":
+		tmpl.html, err = htmltemplate.New(name).Option("missingkey=error").Parse(string(content))
+	}
+	return err
+}
+
+// Render は指定した言語のテンプレートからメールを生成します
+// 指定した言語のテンプレートがない場合は既定の言語のテンプレートを使用します
+func (t *MailTemplates) Render(name string, locale i18n.Locale, data interface{}) (*MailContent, error) {
+	tmpl, ok := t.templates[name][locale]
+	if !ok {
+		tmpl, ok = t.templates[name][i18n.Default]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMailTemplateNotFound, name)
+	}
+
+	var subject, text bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	content := &MailContent{
+		// 件名はヘッダーに入るため改行を取り除く
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+	}
+
+	if tmpl.html != nil {
+		var html bytes.Buffer
+		if err := tmpl.html.Execute(&html, data); err != nil {
+			return nil, err
+		}
+		content.HTML = html.String()
+	}
+	return content, nil
+}
@@ -0,0 +1,472 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailTemplatesRender(t *testing.T) {
+	templates, err := loadMailTemplates()
+	require.NoError(t, err)
+
+	lockedUntil := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+
+	// テストケースの定義
+	tests := []struct {
+		name            string
+		template        string
+		locale          i18n.Locale
+		data            interface{}
+		expectedSubject string
+		expectedText    string
+		expectedHTML    string
+	}{
+		{
+			name:            "パスワードリセット（日本語）",
+			template:        MailTemplatePasswordReset,
+			locale:          i18n.Japanese,
+			data:            PasswordResetMail{ResetURL: "http://localhost:8080/reset-password?token=abc", ExpiresAt: lockedUntil},
+			expectedSubject: "パスワードリセットのリクエスト",
+			expectedText:    "このリンクは 2024-01-23 12:34 UTC まで有効です。",
+			expectedHTML:    `<a href="http://localhost:8080/reset-password?token=abc">`,
+		},
+		{
+			name:            "パスワードリセット（英語）",
+			template:        MailTemplatePasswordReset,
+			locale:          i18n.English,
+			data:            PasswordResetMail{ResetURL: "http://localhost:8080/reset-password?token=abc", ExpiresAt: lockedUntil},
+			expectedSubject: "Password reset request",
+			expectedText:    "This link is valid until 2024-01-23 12:34 UTC.",
+			expectedHTML:    `<html lang="en">`,
+		},
+		{
+			name:            "メールアドレスの確認",
+			template:        MailTemplateEmailVerification,
+			locale:          i18n.Japanese,
+			data:            EmailVerificationMail{VerifyURL: "http://localhost:8080/verify-email?token=a&b"},
+			expectedSubject: "メールアドレスの確認",
+			expectedText:    "http://localhost:8080/verify-email?token=a&b",
+			// HTML版では属性の値がエスケープされる
+			expectedHTML: `href="http://localhost:8080/verify-email?token=a&amp;b"`,
+		},
+		{
+			name:            "アカウントロック",
+			template:        MailTemplateAccountLocked,
+			locale:          i18n.English,
+			data:            AccountLockedMail{LockedUntil: lockedUntil},
+			expectedSubject: "Your account has been locked",
+			expectedText:    "2024-01-23 12:34 UTC",
+			expectedHTML:    "2024-01-23 12:34 UTC",
+		},
+		{
+			name:            "対応していない言語は既定の言語で生成する",
+			template:        MailTemplateAccountLocked,
+			locale:          i18n.Locale("fr"),
+			data:            AccountLockedMail{LockedUntil: lockedUntil},
+			expectedSubject: "アカウントがロックされました",
+			expectedText:    "2024-01-23 12:34 UTC",
+			expectedHTML:    "2024-01-23 12:34 UTC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := templates.Render(tt.template, tt.locale, tt.data)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedSubject, content.Subject)
+			assert.Contains(t, content.Text, tt.expectedText)
+			assert.Contains(t, content.HTML, tt.expectedHTML)
+		})
+	}
+
+	t.Run("存在しないテンプレート", func(t *testing.T) {
+		_, err := templates.Render("unknown", i18n.Japanese, nil)
+		assert.ErrorIs(t, err, ErrMailTemplateNotFound)
+	})
+
+	t.Run("データの項目が足りない", func(t *testing.T) {
+		_, err := templates.Render(MailTemplatePasswordReset, i18n.Japanese, map[string]string{})
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadMailTemplatesOverride(t *testing.T) {
+	t.Run("ファイル単位で上書きする", func(t *testing.T) {
+		templates, err := loadMailTemplates(fstest.MapFS{
+			"ja/password_reset.txt": {Data: []byte(`{{define "subject"}}独自の件名{{end}}{{.ResetURL}}`)},
+			"en/welcome.txt":        {Data: []byte(`{{define "subject"}}Welcome{{end}}Hello`)},
+		})
+		require.NoError(t, err)
+
+		content, err := templates.Render(MailTemplatePasswordReset, i18n.Japanese, PasswordResetMail{ResetURL: "http://example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "独自の件名", content.Subject)
+		assert.Equal(t, "http://example.com", content.Text)
+		// HTML版は埋め込みのテンプレートを使用する
+		assert.Contains(t, content.HTML, `href="http://example.com"`)
+
+		// 追加したテンプレートも使用できる
+		content, err = templates.Render("welcome", i18n.English, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Welcome", content.Subject)
+		assert.Empty(t, content.HTML)
+	})
+
+	t.Run("件名が定義されていない", func(t *testing.T) {
+		_, err := loadMailTemplates(fstest.MapFS{
+			"ja/password_reset.txt": {Data: []byte(`{{.ResetURL}}`)},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("テキスト版がない", func(t *testing.T) {
+		_, err := loadMailTemplates(fstest.MapFS{
+			"en/welcome.html": {Data: []byte(`<p>Hello</p>`)},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("対応していない言語", func(t *testing.T) {
+		_, err := loadMailTemplates(fstest.MapFS{
+			"fr/welcome.txt": {Data: []byte(`{{define "subject"}}Bienvenue{{end}}Bonjour`)},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestBuildMailMessage(t *testing.T) {
+	date := time.Date(2024, 1, 23, 12, 34, 56, 0, time.UTC)
+
+	t.Run("HTML版がある場合は multipart/alternative", func(t *testing.T) {
+		raw, err := BuildMailMessage("noreply@example.com", "user@example.com", &MailContent{
+			Subject: "パスワードリセットのリクエスト",
+			Text:    "テキスト版\nhttp://example.com/reset?token=abc",
+			HTML:    `<p><a href="http://example.com/reset?token=abc">リセット</a></p>`,
+		}, date)
+		require.NoError(t, err)
+
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		require.NoError(t, err)
+
+		// 件名は RFC 2047 でエンコードされている
+		rawSubject := msg.Header.Get("Subject")
+		assert.True(t, strings.HasPrefix(rawSubject, "=?UTF-8?b?"), rawSubject)
+		subject, err := new(mime.WordDecoder).DecodeHeader(rawSubject)
+		require.NoError(t, err)
+		assert.Equal(t, "パスワードリセットのリクエスト", subject)
+		assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+		assert.Regexp(t, `^<[0-9a-f]{32}@example\.com>$`, msg.Header.Get("Message-ID"))
+
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		reader := multipart.NewReader(msg.Body, params["boundary"])
+		var parts []string
+		var bodies []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			// multipart.Reader は quoted-printable を自動でデコードする
+			body, err := io.ReadAll(part)
+			require.NoError(t, err)
+			parts = append(parts, part.Header.Get("Content-Type"))
+			bodies = append(bodies, string(body))
+		}
+		assert.Equal(t, []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}, parts)
+		assert.Equal(t, "テキスト版\r\nhttp://example.com/reset?token=abc", bodies[0])
+		assert.Equal(t, `<p><a href="http://example.com/reset?token=abc">リセット</a></p>`, bodies[1])
+	})
+
+	t.Run("HTML版がない場合は text/plain", func(t *testing.T) {
+		raw, err := BuildMailMessage("noreply@example.com", "user@example.com", &MailContent{
+			Subject: "Subject",
+			Text:    "本文",
+		}, date)
+		require.NoError(t, err)
+
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		require.NoError(t, err)
+		assert.Equal(t, "Subject", msg.Header.Get("Subject"))
+		assert.Equal(t, "text/plain; charset=UTF-8", msg.Header.Get("Content-Type"))
+		assert.Equal(t, "quoted-printable", msg.Header.Get("Content-Transfer-Encoding"))
+
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		require.NoError(t, err)
+		assert.Equal(t, "本文", string(body))
+	})
+}
+
+func TestNewMailer(t *testing.T) {
+	// テストケースの定義
+	tests := []struct {
+		name        string
+		config      MailConfig
+		expectedErr bool
+		check       func(*testing.T, Mailer)
+	}{
+		{
+			name:   "SMTP",
+			config: MailConfig{Transport: MailTransportSMTP, TLS: MailTLSStartTLS},
+			check: func(t *testing.T, m Mailer) {
+				assert.IsType(t, &SMTPMailer{}, m)
+			},
+		},
+		{
+			name:        "対応していない暗号化方式",
+			config:      MailConfig{Transport: MailTransportSMTP, TLS: "ssl"},
+			expectedErr: true,
+		},
+		{
+			name:   "ファイル",
+			config: MailConfig{Transport: MailTransportFile, FileDir: t.TempDir()},
+			check: func(t *testing.T, m Mailer) {
+				assert.IsType(t, &FileMailer{}, m)
+			},
+		},
+		{
+			name:        "ファイルの書き出し先がない",
+			config:      MailConfig{Transport: MailTransportFile},
+			expectedErr: true,
+		},
+		{
+			name:   "開発用の受信箱",
+			config: MailConfig{Transport: MailTransportDevInbox},
+			check: func(t *testing.T, m Mailer) {
+				assert.IsType(t, &DevInbox{}, m)
+			},
+		},
+		{
+			name:        "対応していない送信方法",
+			config:      MailConfig{Transport: "sendmail"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer, err := NewMailer(tt.config)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, mailer)
+		})
+	}
+}
+
+// startFakeSMTPServer は1件の接続だけを受け付ける最小限のSMTPサーバーを起動し、ポート番号と受け取ったメッセージのチャネルを返します
+// extensions は EHLO の応答で通知する拡張です
+func startFakeSMTPServer(t *testing.T, extensions ...string) (int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+			case "EHLO":
+				tp.PrintfLine("250-localhost")
+				for _, ext := range extensions {
+					tp.PrintfLine("250-%s", ext)
+				}
+				tp.PrintfLine("250 HELP")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				received <- string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	t.Run("暗号化せずに送信", func(t *testing.T) {
+		port, received := startFakeSMTPServer(t)
+		mailer := NewSMTPMailer(MailConfig{
+			Host:    "127.0.0.1",
+			Port:    port,
+			From:    "noreply@example.com",
+			TLS:     MailTLSNone,
+			Timeout: 5 * time.Second,
+		})
+
+		err := mailer.SendTemplate("user@example.com", MailTemplatePasswordReset, i18n.English, PasswordResetMail{ResetURL: "http://example.com"})
+		require.NoError(t, err)
+
+		msg, err := mail.ReadMessage(strings.NewReader(<-received))
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", msg.Header.Get("To"))
+		assert.Equal(t, "Password reset request", msg.Header.Get("Subject"))
+	})
+
+	t.Run("STARTTLSに対応していないサーバーには送信しない", func(t *testing.T) {
+		port, received := startFakeSMTPServer(t)
+		mailer := NewSMTPMailer(MailConfig{
+			Host:    "127.0.0.1",
+			Port:    port,
+			From:    "noreply@example.com",
+			TLS:     MailTLSStartTLS,
+			Timeout: 5 * time.Second,
+		})
+
+		err := mailer.SendMail("user@example.com", "Subject", "本文")
+		assert.ErrorContains(t, err, "STARTTLS")
+		assert.Empty(t, received)
+	})
+
+	t.Run("AUTHに対応していないサーバーには認証情報が設定されていれば送信しない", func(t *testing.T) {
+		port, received := startFakeSMTPServer(t)
+		mailer := NewSMTPMailer(MailConfig{
+			Host:     "127.0.0.1",
+			Port:     port,
+			Username: "user",
+			Password: "secret",
+			From:     "noreply@example.com",
+			TLS:      MailTLSNone,
+			Timeout:  5 * time.Second,
+		})
+
+		err := mailer.SendMail("user@example.com", "Subject", "本文")
+		assert.ErrorContains(t, err, "AUTH")
+		assert.Empty(t, received)
+	})
+
+	t.Run("応答のないサーバーはタイムアウトする", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			// 接続を受け付けるだけで応答しない
+			conn, err := listener.Accept()
+			if err == nil {
+				defer conn.Close()
+				io.Copy(io.Discard, conn)
+			}
+		}()
+
+		mailer := NewSMTPMailer(MailConfig{
+			Host:    "127.0.0.1",
+			Port:    listener.Addr().(*net.TCPAddr).Port,
+			From:    "noreply@example.com",
+			TLS:     MailTLSNone,
+			Timeout: 100 * time.Millisecond,
+		})
+
+		start := time.Now()
+		err = mailer.SendMail("user@example.com", "Subject", "本文")
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(MailConfig{From: "noreply@example.com", FileDir: dir})
+	require.NoError(t, err)
+
+	err = mailer.SendTemplate("user@example.com", MailTemplateEmailVerification, i18n.English, EmailVerificationMail{VerifyURL: "http://example.com/verify"})
+	require.NoError(t, err)
+
+	// 書き込みが完了したメールだけが new に置かれる
+	tmp, err := os.ReadDir(filepath.Join(dir, "tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmp)
+	files, err := os.ReadDir(filepath.Join(dir, "new"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	raw, err := os.ReadFile(filepath.Join(dir, "new", files[0].Name()))
+	require.NoError(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", msg.Header.Get("To"))
+	assert.Contains(t, string(raw), "http://example.com/verify")
+}
+
+func TestDevInbox(t *testing.T) {
+	inbox := NewDevInbox(MailConfig{From: "noreply@example.com"})
+
+	require.NoError(t, inbox.SendMail("first@example.com", "1通目", "本文"))
+	require.NoError(t, inbox.SendTemplate("second@example.com", MailTemplatePasswordReset, i18n.Japanese, PasswordResetMail{ResetURL: "http://example.com"}))
+
+	// 新しい順に返す
+	mails := inbox.Mails()
+	require.Len(t, mails, 2)
+	assert.Equal(t, int64(2), mails[0].ID)
+	assert.Equal(t, "second@example.com", mails[0].To)
+	assert.Equal(t, "パスワードリセットのリクエスト", mails[0].Subject)
+	assert.Contains(t, mails[0].HTML, `href="http://example.com"`)
+	assert.Equal(t, "1通目", mails[1].Subject)
+
+	first, ok := inbox.Mail(1)
+	require.True(t, ok)
+	assert.Equal(t, "first@example.com", first.To)
+	assert.Contains(t, string(first.Raw), "To: first@example.com")
+
+	_, ok = inbox.Mail(99)
+	assert.False(t, ok)
+
+	t.Run("上限を超えた場合は古いメールから削除する", func(t *testing.T) {
+		for i := 0; i < devInboxCapacity; i++ {
+			require.NoError(t, inbox.SendMail("user@example.com", "Subject", "本文"))
+		}
+		mails := inbox.Mails()
+		assert.Len(t, mails, devInboxCapacity)
+		assert.Equal(t, int64(devInboxCapacity+2), mails[0].ID)
+		_, ok := inbox.Mail(1)
+		assert.False(t, ok)
+	})
+
+	t.Run("すべて削除", func(t *testing.T) {
+		inbox.Clear()
+		assert.Empty(t, inbox.Mails())
+	})
+}
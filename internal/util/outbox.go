@@ -0,0 +1,248 @@
+package util
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/i18n"
+)
+
+// maxOutboxErrorLength は送信待ちのメールに記録するエラーメッセージの最大長です
+const maxOutboxErrorLength = 1000
+
+// OutboxConfig は送信待ちのメールの配信と再試行の設定を保持します
+type OutboxConfig struct {
+	// PollInterval は送信待ちのメールを確認する間隔です
+	PollInterval time.Duration
+	// BatchSize は1回に取り出して配信するメールの件数です
+	BatchSize int
+	// MaxAttempts は配信を停止（dead）するまでの試行回数です
+	MaxAttempts int
+	// BaseDelay は1回目の失敗後に再試行するまでの待機時間です。以降は失敗のたびに倍になります
+	BaseDelay time.Duration
+	// MaxDelay は再試行までの待機時間の上限です
+	MaxDelay time.Duration
+	// Lease は配信中のメールを他のワーカーが取り出さないようにする時間です
+	// 配信中にプロセスが停止した場合は、この時間が過ぎた後に再試行します
+	Lease time.Duration
+	// Retention は配信済み・配信を停止したメールを残しておく期間です。期間を過ぎると削除します
+	Retention time.Duration
+}
+
+// errPermanentMailFailure は再試行しても配信できないメールのエラーです
+var errPermanentMailFailure = errors.New("permanent mail failure")
+
+// EnqueueMail はテンプレートから生成するメールを送信待ちとして追加します
+// 業務上の変更と同時に確定させるため、ExecTx に渡された Store を queries に指定してください
+func EnqueueMail(ctx context.Context, queries db.Querier, to, name string, locale i18n.Locale, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return queries.EnqueueEmail(ctx, db.EnqueueEmailParams{
+		Recipient: to,
+		Template:  name,
+		Locale:    string(locale),
+		Data:      payload,
+	})
+}
+
+// OutboxWorker は送信待ちのメールを Mailer で配信します
+// 配信に失敗したメールは待機時間を倍にしながら再試行し、試行回数の上限に達すると配信を停止します
+type OutboxWorker struct {
+	store  db.Store
+	mailer Mailer
+	config OutboxConfig
+}
+
+// NewOutboxWorker は新しいOutboxWorkerを作成します
+func NewOutboxWorker(store db.Store, mailer Mailer, config OutboxConfig) *OutboxWorker {
+	return &OutboxWorker{
+		store:  store,
+		mailer: mailer,
+		config: config,
+	}
+}
+
+// Deliver は配信時刻を過ぎた送信待ちのメールを取り出して配信し、取り出した件数を返します
+// 複数のワーカーが同時に実行しても、同じメールを重複して取り出すことはありません
+func (w *OutboxWorker) Deliver(ctx context.Context) (int, error) {
+	emails, err := w.claim(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, email := range emails {
+		if err := w.deliver(ctx, email); err != nil {
+			log.Printf("メール(id=%d)の配信結果の記録に失敗しました: %v", email.ID, err)
+		}
+	}
+	return len(emails), nil
+}
+
+// claim は配信するメールを取り出し、配信が終わるまで他のワーカーが取り出さないようにします
+func (w *OutboxWorker) claim(ctx context.Context, now time.Time) ([]db.EmailOutbox, error) {
+	var emails []db.EmailOutbox
+	err := w.store.ExecTx(ctx, func(q db.Store) error {
+		var err error
+		emails, err = q.ListDueEmails(ctx, db.ListDueEmailsParams{
+			NextAttemptAt: now,
+			Limit:         int32(w.config.BatchSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range emails {
+			err := q.LeaseEmail(ctx, db.LeaseEmailParams{
+				NextAttemptAt: now.Add(w.config.Lease),
+				ID:            emails[i].ID,
+			})
+			if err != nil {
+				return err
+			}
+			emails[i].Attempts++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// deliver は1件のメールを配信し、結果を記録します
+func (w *OutboxWorker) deliver(ctx context.Context, email db.EmailOutbox) error {
+	sendErr := w.send(email)
+	now := time.Now()
+	if sendErr == nil {
+		return w.store.MarkEmailSent(ctx, db.MarkEmailSentParams{
+			SentAt: sql.NullTime{Time: now, Valid: true},
+			Data:   redactMailData(email.Template, email.Data),
+			ID:     email.ID,
+		})
+	}
+
+	status := db.EmailOutboxStatusPending
+	nextAttemptAt := now.Add(w.retryDelay(email.Attempts))
+	data := email.Data
+	if errors.Is(sendErr, errPermanentMailFailure) || int(email.Attempts) >= w.config.MaxAttempts {
+		status = db.EmailOutboxStatusDead
+		nextAttemptAt = now
+		data = redactMailData(email.Template, email.Data)
+		log.Printf("メール(id=%d)の配信を停止しました: %v", email.ID, sendErr)
+	}
+
+	message := sendErr.Error()
+	if len(message) > maxOutboxErrorLength {
+		message = strings.ToValidUTF8(message[:maxOutboxErrorLength], "")
+	}
+	return w.store.MarkEmailFailed(ctx, db.MarkEmailFailedParams{
+		Status:        status,
+		LastError:     sql.NullString{String: message, Valid: true},
+		NextAttemptAt: nextAttemptAt,
+		Data:          data,
+		ID:            email.ID,
+	})
+}
+
+// send は保存されたデータをテンプレートのデータに戻してメールを送信します
+// データやテンプレートの不備は再試行しても解消しないため errPermanentMailFailure を返します
+func (w *OutboxWorker) send(email db.EmailOutbox) error {
+	data, err := decodeMailData(email.Template, email.Data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanentMailFailure, err)
+	}
+
+	err = w.mailer.SendTemplate(email.Recipient, email.Template, i18n.Locale(email.Locale), data)
+	if errors.Is(err, ErrMailTemplateNotFound) {
+		return fmt.Errorf("%w: %v", errPermanentMailFailure, err)
+	}
+	return err
+}
+
+// Purge は保持期間を過ぎた配信済み・配信を停止したメールを削除し、削除した件数を返します
+// 宛先などの個人情報を送信後もデータベースに残し続けないために使用します
+func (w *OutboxWorker) Purge(ctx context.Context) (int64, error) {
+	return w.store.PurgeOutboxEmails(ctx, time.Now().Add(-w.config.Retention))
+}
+
+// retryDelay は attempts 回目の失敗後に再試行するまでの待機時間を返します
+func (w *OutboxWorker) retryDelay(attempts int32) time.Duration {
+	delay := w.config.BaseDelay
+	for i := int32(1); i < attempts && delay < w.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > w.config.MaxDelay {
+		delay = w.config.MaxDelay
+	}
+	return delay
+}
+
+// decodeMailData はJSONのデータをテンプレートごとのデータの型に戻します
+// 型が登録されていないテンプレートのデータはマップとして返します
+func decodeMailData(name string, data json.RawMessage) (interface{}, error) {
+	typ, ok := mailDataTypes[name]
+	if !ok {
+		var m map[string]interface{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	v := reflect.New(typ)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// redactMailData は保存されたデータから mail:"secret" を指定したフィールドを取り除きます
+// 配信済みや配信を停止したメールのリンクを、データベースの参照だけで悪用させないために使用します
+// 型が登録されていないテンプレートや読み込めないデータは、何が含まれているかわからないため全て取り除きます
+func redactMailData(name string, data json.RawMessage) json.RawMessage {
+	typ, ok := mailDataTypes[name]
+	if !ok {
+		return json.RawMessage(`{}`)
+	}
+
+	v := reflect.New(typ).Elem()
+	if err := json.Unmarshal(data, v.Addr().Interface()); err != nil {
+		return json.RawMessage(`{}`)
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Tag.Get("mail") == "secret" {
+			v.Field(i).SetZero()
+		}
+	}
+
+	redacted, err := json.Marshal(v.Interface())
+	if err != nil {
+		return json.RawMessage(`{}`)
+	}
+	return redacted
+}
+
+// MailDataRedactable はテンプレートのデータが、配信済みや配信を停止したときに取り除かれる値を含むかを返します
+// 取り除いた後のデータからは元のメールを送信できないため、配信を停止したメールの再送の可否の判定に使用します
+func MailDataRedactable(name string) bool {
+	typ, ok := mailDataTypes[name]
+	if !ok {
+		return true
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Tag.Get("mail") == "secret" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,395 @@
+package util
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+	"go-gin-sqlc/internal/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOutboxStore は OutboxWorker が使用するクエリだけを実装した Store のモックです
+type mockOutboxStore struct {
+	mock.Mock
+	db.Store
+}
+
+func (m *mockOutboxStore) ExecTx(ctx context.Context, fn func(db.Store) error) error {
+	return fn(m)
+}
+
+func (m *mockOutboxStore) ListDueEmails(ctx context.Context, arg db.ListDueEmailsParams) ([]db.EmailOutbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.EmailOutbox), args.Error(1)
+}
+
+func (m *mockOutboxStore) LeaseEmail(ctx context.Context, arg db.LeaseEmailParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *mockOutboxStore) MarkEmailSent(ctx context.Context, arg db.MarkEmailSentParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *mockOutboxStore) PurgeOutboxEmails(ctx context.Context, updatedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, updatedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockOutboxStore) MarkEmailFailed(ctx context.Context, arg db.MarkEmailFailedParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// mockMailer はメール送信のモックです
+type mockMailer struct {
+	mock.Mock
+}
+
+func (m *mockMailer) SendMail(to, subject, body string) error {
+	args := m.Called(to, subject, body)
+	return args.Error(0)
+}
+
+func (m *mockMailer) SendTemplate(to, name string, locale i18n.Locale, data interface{}) error {
+	args := m.Called(to, name, locale, data)
+	return args.Error(0)
+}
+
+func TestEnqueueMail(t *testing.T) {
+	var captured db.EnqueueEmailParams
+	queries := &enqueueRecorder{params: &captured}
+	expiresAt := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	err := EnqueueMail(context.Background(), queries, "user@example.com", MailTemplatePasswordReset, i18n.English, PasswordResetMail{ResetURL: "http://example.com", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+
+	assert.Equal(t, "user@example.com", captured.Recipient)
+	assert.Equal(t, MailTemplatePasswordReset, captured.Template)
+	assert.Equal(t, "en", captured.Locale)
+	assert.JSONEq(t, `{"ResetURL":"http://example.com","ExpiresAt":"2024-01-23T12:34:00Z"}`, string(captured.Data))
+}
+
+// enqueueRecorder は EnqueueEmail に渡された引数を記録します
+type enqueueRecorder struct {
+	db.Querier
+	params *db.EnqueueEmailParams
+}
+
+func (r *enqueueRecorder) EnqueueEmail(ctx context.Context, arg db.EnqueueEmailParams) error {
+	*r.params = arg
+	return nil
+}
+
+func TestOutboxWorkerDeliver(t *testing.T) {
+	config := OutboxConfig{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Lease:       5 * time.Minute,
+	}
+	lockedUntil := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+	lockedData, _ := json.Marshal(AccountLockedMail{LockedUntil: lockedUntil})
+
+	// テストケースの定義
+	tests := []struct {
+		name      string
+		email     db.EmailOutbox
+		setupMock func(*mockOutboxStore, *mockMailer)
+	}{
+		{
+			name: "配信に成功",
+			email: db.EmailOutbox{
+				ID:        1,
+				Recipient: "user@example.com",
+				Template:  MailTemplateAccountLocked,
+				Locale:    "en",
+				Data:      lockedData,
+			},
+			setupMock: func(s *mockOutboxStore, m *mockMailer) {
+				// 保存したJSONはテンプレートごとのデータの型に戻して渡す
+				m.On("SendTemplate", "user@example.com", MailTemplateAccountLocked, i18n.English, mock.MatchedBy(func(data AccountLockedMail) bool {
+					return data.LockedUntil.Equal(lockedUntil)
+				})).Return(nil)
+				s.On("MarkEmailSent", mock.Anything, mock.MatchedBy(func(arg db.MarkEmailSentParams) bool {
+					return arg.ID == 1 && arg.SentAt.Valid
+				})).Return(nil)
+			},
+		},
+		{
+			name: "配信に失敗した場合は待機時間をおいて再試行する",
+			email: db.EmailOutbox{
+				ID:        2,
+				Recipient: "user@example.com",
+				Template:  MailTemplatePasswordReset,
+				Locale:    "ja",
+				Data:      json.RawMessage(`{"ResetURL":"http://example.com"}`),
+				Attempts:  1,
+			},
+			setupMock: func(s *mockOutboxStore, m *mockMailer) {
+				m.On("SendTemplate", "user@example.com", MailTemplatePasswordReset, i18n.Japanese, PasswordResetMail{ResetURL: "http://example.com"}).
+					Return(errors.New("421 service not available"))
+				s.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg db.MarkEmailFailedParams) bool {
+					// 2回目の失敗のため、待機時間は BaseDelay の2倍
+					wait := time.Until(arg.NextAttemptAt)
+					// 再試行するため、データはそのまま残す
+					return arg.ID == 2 &&
+						arg.Status == db.EmailOutboxStatusPending &&
+						arg.LastError.String == "421 service not available" &&
+						string(arg.Data) == `{"ResetURL":"http://example.com"}` &&
+						wait > time.Minute && wait <= 2*time.Minute
+				})).Return(nil)
+			},
+		},
+		{
+			name: "試行回数の上限に達した場合は配信を停止する",
+			email: db.EmailOutbox{
+				ID:        3,
+				Recipient: "user@example.com",
+				Template:  MailTemplatePasswordReset,
+				Locale:    "ja",
+				Data:      json.RawMessage(`{"ResetURL":"http://example.com"}`),
+				Attempts:  2,
+			},
+			setupMock: func(s *mockOutboxStore, m *mockMailer) {
+				m.On("SendTemplate", "user@example.com", MailTemplatePasswordReset, i18n.Japanese, mock.Anything).
+					Return(errors.New("421 service not available"))
+				s.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg db.MarkEmailFailedParams) bool {
+					// 配信を停止したメールのリンクは破棄する
+					return arg.ID == 3 && arg.Status == db.EmailOutboxStatusDead && !strings.Contains(string(arg.Data), "http://example.com")
+				})).Return(nil)
+			},
+		},
+		{
+			name: "テンプレートがない場合は再試行しない",
+			email: db.EmailOutbox{
+				ID:        4,
+				Recipient: "user@example.com",
+				Template:  "unknown",
+				Locale:    "ja",
+				Data:      json.RawMessage(`{}`),
+			},
+			setupMock: func(s *mockOutboxStore, m *mockMailer) {
+				m.On("SendTemplate", "user@example.com", "unknown", i18n.Japanese, map[string]interface{}{}).
+					Return(ErrMailTemplateNotFound)
+				s.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg db.MarkEmailFailedParams) bool {
+					return arg.ID == 4 && arg.Status == db.EmailOutboxStatusDead && string(arg.Data) == `{}`
+				})).Return(nil)
+			},
+		},
+		{
+			name: "データを復元できない場合は再試行しない",
+			email: db.EmailOutbox{
+				ID:        5,
+				Recipient: "user@example.com",
+				Template:  MailTemplateAccountLocked,
+				Locale:    "ja",
+				Data:      json.RawMessage(`{"LockedUntil":"invalid"}`),
+			},
+			setupMock: func(s *mockOutboxStore, m *mockMailer) {
+				s.On("MarkEmailFailed", mock.Anything, mock.MatchedBy(func(arg db.MarkEmailFailedParams) bool {
+					return arg.ID == 5 && arg.Status == db.EmailOutboxStatusDead && arg.LastError.Valid
+				})).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockOutboxStore)
+			mailer := new(mockMailer)
+
+			// 取り出したメールは試行回数を加算し、配信中は他のワーカーが取り出さないようにする
+			store.On("ListDueEmails", mock.Anything, mock.MatchedBy(func(arg db.ListDueEmailsParams) bool {
+				return arg.Limit == 10
+			})).Return([]db.EmailOutbox{tt.email}, nil)
+			store.On("LeaseEmail", mock.Anything, mock.MatchedBy(func(arg db.LeaseEmailParams) bool {
+				return arg.ID == tt.email.ID && time.Until(arg.NextAttemptAt) > 4*time.Minute
+			})).Return(nil)
+			tt.setupMock(store, mailer)
+
+			worker := NewOutboxWorker(store, mailer, config)
+			delivered, err := worker.Deliver(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, delivered)
+
+			store.AssertExpectations(t)
+			mailer.AssertExpectations(t)
+		})
+	}
+
+	t.Run("送信待ちのメールがない", func(t *testing.T) {
+		store := new(mockOutboxStore)
+		store.On("ListDueEmails", mock.Anything, mock.Anything).Return([]db.EmailOutbox{}, nil)
+
+		worker := NewOutboxWorker(store, new(mockMailer), config)
+		delivered, err := worker.Deliver(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+	})
+
+	t.Run("取り出しに失敗", func(t *testing.T) {
+		store := new(mockOutboxStore)
+		store.On("ListDueEmails", mock.Anything, mock.Anything).Return([]db.EmailOutbox{}, sql.ErrConnDone)
+
+		worker := NewOutboxWorker(store, new(mockMailer), config)
+		_, err := worker.Deliver(context.Background())
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
+
+func TestOutboxWorkerRedactsMailData(t *testing.T) {
+	resetURL := "http://localhost:8080/reset-password?token=raw-reset-token"
+	expiresAt := time.Date(2024, 1, 23, 12, 34, 0, 0, time.UTC)
+
+	// テストケースの定義
+	tests := []struct {
+		name           string
+		sendErr        error
+		expectedStatus db.EmailOutboxStatus
+	}{
+		{
+			name:           "配信済み",
+			expectedStatus: db.EmailOutboxStatusSent,
+		},
+		{
+			name:           "配信を停止",
+			sendErr:        errors.New("421 service not available"),
+			expectedStatus: db.EmailOutboxStatusDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryOutboxStore{}
+			err := EnqueueMail(context.Background(), store, "user@example.com", MailTemplatePasswordReset, i18n.English, PasswordResetMail{ResetURL: resetURL, ExpiresAt: expiresAt})
+			require.NoError(t, err)
+
+			mailer := new(mockMailer)
+			mailer.On("SendTemplate", "user@example.com", MailTemplatePasswordReset, i18n.English, PasswordResetMail{ResetURL: resetURL, ExpiresAt: expiresAt}).Return(tt.sendErr)
+
+			worker := NewOutboxWorker(store, mailer, OutboxConfig{BatchSize: 10, MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour, Lease: time.Minute})
+			_, err = worker.Deliver(context.Background())
+			require.NoError(t, err)
+
+			// データベースに残った行から、リセット用のトークンを読み取れない
+			email, err := store.GetOutboxEmail(context.Background(), 1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, email.Status)
+			assert.NotContains(t, string(email.Data), "raw-reset-token")
+			assert.JSONEq(t, `{"ResetURL":"","ExpiresAt":"2024-01-23T12:34:00Z"}`, string(email.Data))
+			mailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMailDataRedactable(t *testing.T) {
+	assert.True(t, MailDataRedactable(MailTemplatePasswordReset))
+	assert.True(t, MailDataRedactable(MailTemplateEmailVerification))
+	assert.False(t, MailDataRedactable(MailTemplateAccountLocked))
+	// 型が登録されていないテンプレートのデータは全て破棄する
+	assert.True(t, MailDataRedactable("unknown"))
+}
+
+// memoryOutboxStore は送信待ちのメールをメモリ上に保存する Store です
+type memoryOutboxStore struct {
+	db.Store
+	emails []db.EmailOutbox
+}
+
+func (s *memoryOutboxStore) ExecTx(ctx context.Context, fn func(db.Store) error) error {
+	return fn(s)
+}
+
+func (s *memoryOutboxStore) EnqueueEmail(ctx context.Context, arg db.EnqueueEmailParams) error {
+	s.emails = append(s.emails, db.EmailOutbox{
+		ID:        int64(len(s.emails) + 1),
+		Recipient: arg.Recipient,
+		Template:  arg.Template,
+		Locale:    arg.Locale,
+		Data:      arg.Data,
+		Status:    db.EmailOutboxStatusPending,
+	})
+	return nil
+}
+
+func (s *memoryOutboxStore) ListDueEmails(ctx context.Context, arg db.ListDueEmailsParams) ([]db.EmailOutbox, error) {
+	var emails []db.EmailOutbox
+	for _, email := range s.emails {
+		if email.Status == db.EmailOutboxStatusPending && !email.NextAttemptAt.After(arg.NextAttemptAt) {
+			emails = append(emails, email)
+		}
+	}
+	return emails, nil
+}
+
+func (s *memoryOutboxStore) LeaseEmail(ctx context.Context, arg db.LeaseEmailParams) error {
+	email := &s.emails[arg.ID-1]
+	email.Attempts++
+	email.NextAttemptAt = arg.NextAttemptAt
+	return nil
+}
+
+func (s *memoryOutboxStore) MarkEmailSent(ctx context.Context, arg db.MarkEmailSentParams) error {
+	email := &s.emails[arg.ID-1]
+	email.Status = db.EmailOutboxStatusSent
+	email.SentAt = arg.SentAt
+	email.LastError = sql.NullString{}
+	email.Data = arg.Data
+	return nil
+}
+
+func (s *memoryOutboxStore) MarkEmailFailed(ctx context.Context, arg db.MarkEmailFailedParams) error {
+	email := &s.emails[arg.ID-1]
+	email.Status = arg.Status
+	email.LastError = arg.LastError
+	email.NextAttemptAt = arg.NextAttemptAt
+	email.Data = arg.Data
+	return nil
+}
+
+func (s *memoryOutboxStore) GetOutboxEmail(ctx context.Context, id int64) (db.EmailOutbox, error) {
+	if id < 1 || int(id) > len(s.emails) {
+		return db.EmailOutbox{}, sql.ErrNoRows
+	}
+	return s.emails[id-1], nil
+}
+
+func TestOutboxWorkerPurge(t *testing.T) {
+	store := new(mockOutboxStore)
+	store.On("PurgeOutboxEmails", mock.Anything, mock.MatchedBy(func(updatedBefore time.Time) bool {
+		// 保持期間より前に更新されたメールだけを削除する
+		age := time.Since(updatedBefore)
+		return age >= 30*24*time.Hour && age < 30*24*time.Hour+time.Minute
+	})).Return(int64(3), nil)
+
+	worker := NewOutboxWorker(store, nil, OutboxConfig{Retention: 30 * 24 * time.Hour})
+	purged, err := worker.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	store.AssertExpectations(t)
+}
+
+func TestOutboxWorkerRetryDelay(t *testing.T) {
+	worker := NewOutboxWorker(nil, nil, OutboxConfig{
+		BaseDelay: 30 * time.Second,
+		MaxDelay:  time.Hour,
+	})
+
+	assert.Equal(t, 30*time.Second, worker.retryDelay(1))
+	assert.Equal(t, time.Minute, worker.retryDelay(2))
+	assert.Equal(t, 4*time.Minute, worker.retryDelay(4))
+	// 待機時間は上限を超えない
+	assert.Equal(t, time.Hour, worker.retryDelay(20))
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// テストケースの定義（RFC 7396 の付録の例から抜粋）
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "値の置き換え",
+			doc:   `{"a":"b"}`,
+			patch: `{"a":"c"}`,
+			want:  `{"a":"c"}`,
+		},
+		{
+			name:  "nullでメンバーを削除",
+			doc:   `{"a":"b","b":"c"}`,
+			patch: `{"a":null}`,
+			want:  `{"b":"c"}`,
+		},
+		{
+			name:  "オブジェクトは再帰的にマージ",
+			doc:   `{"a":{"b":"c","d":"e"}}`,
+			patch: `{"a":{"d":null,"f":"g"}}`,
+			want:  `{"a":{"b":"c","f":"g"}}`,
+		},
+		{
+			name:  "配列は置き換え",
+			doc:   `{"a":["b"]}`,
+			patch: `{"a":["c","d"]}`,
+			want:  `{"a":["c","d"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	// テストケースの定義（RFC 6902 の付録の例から抜粋）
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		want     string
+		conflict bool
+		invalid  bool
+	}{
+		{
+			name:  "配列の途中に追加",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "削除と置き換え",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"remove","path":"/baz"},{"op":"replace","path":"/foo","value":"boo"}]`,
+			want:  `{"foo":"boo"}`,
+		},
+		{
+			name:  "移動とコピー",
+			doc:   `{"foo":{"bar":"baz"},"qux":{}}`,
+			patch: `[{"op":"move","from":"/foo/bar","path":"/qux/thud"},{"op":"copy","from":"/qux","path":"/foo/qux"}]`,
+			want:  `{"foo":{"qux":{"thud":"baz"}},"qux":{"thud":"baz"}}`,
+		},
+		{
+			name:  "エスケープされたパス",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"test","path":"/a~1b","value":1},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":1}`,
+		},
+		{
+			name:     "testが一致しない",
+			doc:      `{"baz":"qux"}`,
+			patch:    `[{"op":"test","path":"/baz","value":"bar"}]`,
+			conflict: true,
+		},
+		{
+			name:     "存在しないメンバーの置き換え",
+			doc:      `{"foo":"bar"}`,
+			patch:    `[{"op":"replace","path":"/baz","value":"qux"}]`,
+			conflict: true,
+		},
+		{
+			name:    "不明な操作",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"merge","path":"/foo","value":"qux"}]`,
+			invalid: true,
+		},
+		{
+			name:    "valueがない",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz"}]`,
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+			switch {
+			case tt.conflict:
+				assert.ErrorIs(t, err, ErrPatchConflict)
+			case tt.invalid:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrPatchConflict)
+			default:
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.want, string(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+)
+
+// ロール
+const (
+	// RoleAdmin は全てのユーザーを管理できる管理者のロールです
+	RoleAdmin = "admin"
+	// RoleUser は自分自身のレコードのみ参照・編集できる一般ユーザーのロールです
+	RoleUser = "user"
+)
+
+// 権限
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersCreate = "users:create"
+	PermissionUsersUpdate = "users:update"
+	PermissionUsersDelete = "users:delete"
+	PermissionUsersUnlock = "users:unlock"
+
+	PermissionEmailsRead    = "emails:read"
+	PermissionEmailsRequeue = "emails:requeue"
+)
+
+// PermissionSet はユーザーが持つ権限の集合です
+type PermissionSet map[string]struct{}
+
+// Has は指定された権限を持っているかを返します
+func (s PermissionSet) Has(permission string) bool {
+	_, ok := s[permission]
+	return ok
+}
+
+// PermissionResolver はロールから権限を解決するインターフェースです
+type PermissionResolver interface {
+	// Permissions は指定されたロールが持つ権限の和集合を返します
+	Permissions(roles []string) PermissionSet
+}
+
+// DBPermissionResolver はMySQLのロールと権限の対応をメモリ上にキャッシュする PermissionResolver の実装です
+// ロールに付与された権限の変更は Run による定期的な再読み込みで反映されます
+type DBPermissionResolver struct {
+	queries db.Querier
+
+	mu    sync.RWMutex
+	roles map[string]PermissionSet
+}
+
+// NewDBPermissionResolver は新しいDBPermissionResolverを作成します
+func NewDBPermissionResolver(queries db.Querier) *DBPermissionResolver {
+	return &DBPermissionResolver{
+		queries: queries,
+		roles:   make(map[string]PermissionSet),
+	}
+}
+
+// Permissions は指定されたロールが持つ権限の和集合を返します
+func (r *DBPermissionResolver) Permissions(roles []string) PermissionSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	permissions := make(PermissionSet)
+	for _, role := range roles {
+		for permission := range r.roles[role] {
+			permissions[permission] = struct{}{}
+		}
+	}
+	return permissions
+}
+
+// Load はデータベースからロールと権限の対応を読み込み、キャッシュを置き換えます
+func (r *DBPermissionResolver) Load(ctx context.Context) error {
+	rows, err := r.queries.ListRolePermissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	roles := make(map[string]PermissionSet)
+	for _, row := range rows {
+		if roles[row.RoleName] == nil {
+			roles[row.RoleName] = make(PermissionSet)
+		}
+		roles[row.RoleName][row.PermissionName] = struct{}{}
+	}
+
+	r.mu.Lock()
+	r.roles = roles
+	r.mu.Unlock()
+	return nil
+}
+
+// Run はコンテキストがキャンセルされるまで、定期的にロールと権限の対応を再読み込みします
+func (r *DBPermissionResolver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Load(ctx); err != nil {
+				log.Println("ロールと権限の読み込みに失敗しました:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBPermissionResolver_Permissions(t *testing.T) {
+	resolver := NewDBPermissionResolver(nil)
+	resolver.roles = map[string]PermissionSet{
+		RoleAdmin: {PermissionUsersRead: {}, PermissionUsersDelete: {}},
+		"support": {PermissionUsersRead: {}, PermissionUsersUnlock: {}},
+		RoleUser:  {},
+	}
+
+	// テストケースの定義
+	tests := []struct {
+		name       string
+		roles      []string
+		permission string
+		want       bool
+	}{
+		{
+			name:       "管理者は削除権限を持つ",
+			roles:      []string{RoleAdmin},
+			permission: PermissionUsersDelete,
+			want:       true,
+		},
+		{
+			name:       "一般ユーザーは削除権限を持たない",
+			roles:      []string{RoleUser},
+			permission: PermissionUsersDelete,
+			want:       false,
+		},
+		{
+			name:       "複数のロールの権限を合わせて持つ",
+			roles:      []string{RoleUser, "support"},
+			permission: PermissionUsersUnlock,
+			want:       true,
+		},
+		{
+			name:       "存在しないロール",
+			roles:      []string{"unknown"},
+			permission: PermissionUsersRead,
+			want:       false,
+		},
+		{
+			name:       "ロールなし",
+			roles:      nil,
+			permission: PermissionUsersRead,
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolver.Permissions(tt.roles).Has(tt.permission))
+		})
+	}
+}
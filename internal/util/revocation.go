@@ -0,0 +1,178 @@
+package util
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+)
+
+// RevocationStore はアクセストークンの失効状態を管理するインターフェースです
+type RevocationStore interface {
+	// Revoke は指定されたトークンを有効期限まで失効させます
+	Revoke(ctx context.Context, claims *Claims) error
+	// RevokeAllForUser はユーザーに対して現在までに発行された全てのトークンを失効させます
+	RevokeAllForUser(ctx context.Context, userID int64) error
+	// IsRevoked はトークンが失効しているかを返します
+	IsRevoked(claims *Claims) bool
+}
+
+// DBRevocationStore はMySQLに失効リストを保存し、メモリ上にキャッシュする RevocationStore の実装です
+// 他のインスタンスで失効されたトークンは Run による定期的な再読み込みで反映されます
+type DBRevocationStore struct {
+	queries db.Querier
+
+	mu     sync.RWMutex
+	tokens map[string]time.Time // jti -> 有効期限
+	users  map[int64]userRevocation
+}
+
+type userRevocation struct {
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+// NewDBRevocationStore は新しいDBRevocationStoreを作成します
+func NewDBRevocationStore(queries db.Querier) *DBRevocationStore {
+	return &DBRevocationStore{
+		queries: queries,
+		tokens:  make(map[string]time.Time),
+		users:   make(map[int64]userRevocation),
+	}
+}
+
+// Revoke は指定されたトークンを有効期限まで失効させます
+func (s *DBRevocationStore) Revoke(ctx context.Context, claims *Claims) error {
+	expiresAt := time.Now().Add(AccessTokenExpiration)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	err := s.queries.CreateRevokedToken(ctx, db.CreateRevokedTokenParams{
+		Jti:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens[claims.ID] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAllForUser はユーザーに対して現在までに発行された全てのトークンを失効させます
+func (s *DBRevocationStore) RevokeAllForUser(ctx context.Context, userID int64) error {
+	// iatと同じマイクロ秒単位で記録し、この時刻より前に発行されたトークンだけを失効させる
+	revokedBefore := time.Now().Truncate(time.Microsecond)
+	// この時刻以降は、失効対象のトークンが全て期限切れになっている
+	expiresAt := revokedBefore.Add(AccessTokenExpiration)
+
+	err := s.queries.UpsertUserTokenRevocation(ctx, db.UpsertUserTokenRevocationParams{
+		UserID:        userID,
+		RevokedBefore: revokedBefore,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.users[userID] = userRevocation{revokedBefore: revokedBefore, expiresAt: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+// IsRevoked はトークンが失効しているかをキャッシュから判定します
+func (s *DBRevocationStore) IsRevoked(claims *Claims) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tokens[claims.ID]; ok {
+		return true
+	}
+
+	if revocation, ok := s.users[claims.UserID]; ok && claims.IssuedAt != nil {
+		return claims.IssuedAt.Time.Before(revocation.revokedBefore)
+	}
+
+	return false
+}
+
+// Load はデータベースから有効な失効リストを読み込み、キャッシュを置き換えます
+func (s *DBRevocationStore) Load(ctx context.Context) error {
+	revokedTokens, err := s.queries.ListActiveRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	userRevocations, err := s.queries.ListActiveUserTokenRevocations(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokens := make(map[string]time.Time, len(revokedTokens))
+	for _, t := range revokedTokens {
+		tokens[t.Jti] = t.ExpiresAt
+	}
+
+	users := make(map[int64]userRevocation, len(userRevocations))
+	for _, u := range userRevocations {
+		users[u.UserID] = userRevocation{revokedBefore: u.RevokedBefore, expiresAt: u.ExpiresAt}
+	}
+
+	s.mu.Lock()
+	s.tokens = tokens
+	s.users = users
+	s.mu.Unlock()
+	return nil
+}
+
+// Purge は有効期限を過ぎた失効エントリをデータベースとキャッシュから削除します
+func (s *DBRevocationStore) Purge(ctx context.Context) error {
+	if err := s.queries.DeleteExpiredRevokedTokens(ctx); err != nil {
+		return err
+	}
+	if err := s.queries.DeleteExpiredUserTokenRevocations(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for jti, expiresAt := range s.tokens {
+		if !expiresAt.After(now) {
+			delete(s.tokens, jti)
+		}
+	}
+	for userID, revocation := range s.users {
+		if !revocation.expiresAt.After(now) {
+			delete(s.users, userID)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Run はコンテキストがキャンセルされるまで、定期的に期限切れエントリの削除とキャッシュの再読み込みを行います
+func (s *DBRevocationStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Purge(ctx); err != nil {
+				log.Println("失効済みトークンの削除に失敗しました:", err)
+			}
+			if err := s.Load(ctx); err != nil {
+				log.Println("失効リストの読み込みに失敗しました:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "go-gin-sqlc/db/sqlc"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBRevocationStore_IsRevoked(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	store := NewDBRevocationStore(nil)
+	store.tokens["revoked-jti"] = now.Add(time.Minute)
+	store.users[2] = userRevocation{revokedBefore: now, expiresAt: now.Add(AccessTokenExpiration)}
+
+	// テストケースの定義
+	tests := []struct {
+		name   string
+		claims *Claims
+		want   bool
+	}{
+		{
+			name:   "失効していないトークン",
+			claims: &Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{ID: "active-jti", IssuedAt: jwt.NewNumericDate(now)}},
+			want:   false,
+		},
+		{
+			name:   "個別に失効したトークン",
+			claims: &Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{ID: "revoked-jti", IssuedAt: jwt.NewNumericDate(now)}},
+			want:   true,
+		},
+		{
+			name:   "全デバイスログアウト前に発行されたトークン",
+			claims: &Claims{UserID: 2, RegisteredClaims: jwt.RegisteredClaims{ID: "old-jti", IssuedAt: jwt.NewNumericDate(now.Add(-time.Minute))}},
+			want:   true,
+		},
+		{
+			name:   "全デバイスログアウト後に発行されたトークン",
+			claims: &Claims{UserID: 2, RegisteredClaims: jwt.RegisteredClaims{ID: "new-jti", IssuedAt: jwt.NewNumericDate(now.Add(time.Second))}},
+			want:   false,
+		},
+		{
+			name:   "全デバイスログアウトと同じ秒のうちに後から発行されたトークン",
+			claims: &Claims{UserID: 2, RegisteredClaims: jwt.RegisteredClaims{ID: "same-second-jti", IssuedAt: jwt.NewNumericDate(now.Add(300 * time.Millisecond))}},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, store.IsRevoked(tt.claims))
+		})
+	}
+}
+
+func TestDBRevocationStore_RevokeAllForUser(t *testing.T) {
+	store := NewDBRevocationStore(&revocationRecorder{})
+
+	oldToken, err := GenerateToken(1, nil)
+	assert.NoError(t, err)
+	// 発行日時はマイクロ秒単位のため、失効の前後で発行日時が変わるよう間隔をあける
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, store.RevokeAllForUser(context.Background(), 1))
+	time.Sleep(time.Millisecond)
+	// 失効と同じ秒のうちに再ログインしたトークンは使用できる
+	newToken, err := GenerateToken(1, nil)
+	assert.NoError(t, err)
+
+	oldClaims, err := ValidateToken(oldToken)
+	assert.NoError(t, err)
+	newClaims, err := ValidateToken(newToken)
+	assert.NoError(t, err)
+
+	assert.True(t, store.IsRevoked(oldClaims))
+	assert.False(t, store.IsRevoked(newClaims))
+}
+
+// revocationRecorder はユーザー単位の失効の保存だけを受け付ける Querier です
+type revocationRecorder struct {
+	db.Querier
+}
+
+func (r *revocationRecorder) UpsertUserTokenRevocation(ctx context.Context, arg db.UpsertUserTokenRevocationParams) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenExpiration はリフレッシュトークンの有効期間です
+const RefreshTokenExpiration = 30 * 24 * time.Hour
+
+// GenerateRandomToken は指定されたバイト数の乱数を16進文字列で返します
+func GenerateRandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateRefreshToken は不透明なリフレッシュトークンを生成します
+func GenerateRefreshToken() (string, error) {
+	return GenerateRandomToken(32)
+}
+
+// HashToken はトークンをデータベース保存用にSHA-256でハッシュ化します
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}